@@ -0,0 +1,42 @@
+// Command rollup-backfill rolls up the transitions recorded before the
+// rollup tables existed. The server does the same once on its first start;
+// the command is safe to run while the server is up and to run again, e.g.
+// to rebuild the rollups.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/pkg/database"
+	"qrcodegen/internal/repository/postgres"
+	"qrcodegen/internal/usecase"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := config.New()
+	pool, err := database.NewDBPool(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer pool.Close()
+
+	aggregator := usecase.NewRollupAggregator(postgres.NewRepository(pool), cfg)
+	last, err := aggregator.Backfill(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Int64("last_transition_id", last).Msg("Failed to backfill rollups")
+	}
+	log.Info().Int64("last_transition_id", last).Msg("Rollup backfill finished")
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -14,9 +15,78 @@ type Config struct {
 	JWTSecret         string
 	JWTTTL            time.Duration
 	AppBaseURL        string
+	TrustedProxies    []string
+
+	CustomDomainScheme string
 
 	IPInfoToken       string
 	IPInfoHTTPTimeout time.Duration
+
+	GeoIPDBPath         string
+	GeoIPReloadInterval time.Duration
+	GeoIPLanguage       string
+	GeoIPTimeout        time.Duration
+
+	GeoCountryHeader string
+	GeoRegionHeader  string
+	GeoCityHeader    string
+	GeoHeaderTimeout time.Duration
+
+	GeoCacheSize        int
+	GeoCacheTTL         time.Duration
+	GeoCacheNegativeTTL time.Duration
+	GeoCacheIPv4Prefix  int
+	GeoCacheIPv6Prefix  int
+	GeoCacheFile        string
+
+	RedirectCacheSize        int
+	RedirectCacheTTL         time.Duration
+	RedirectCacheNegativeTTL time.Duration
+
+	HealthCheckInterval     time.Duration
+	HealthCheckTimeout      time.Duration
+	HealthCheckConcurrency  int
+	HealthCheckAlertAfter   int
+	HealthCheckAllowPrivate bool
+
+	HashStrategy  string
+	HashLength    int
+	HashAlphabet  string
+	HashWordCount int
+
+	TransitionQueueSize     int
+	TransitionWorkers       int
+	TransitionEnrichWorkers int
+	TransitionBatchSize     int
+	TransitionFlushInterval time.Duration
+	TransitionEnqueueWait   time.Duration
+	TransitionExportTimeout time.Duration
+
+	RollupInterval  time.Duration
+	RollupBatchSize int
+
+	WebhookPollInterval time.Duration
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookConcurrency  int
+	WebhookRetention    time.Duration
+	WebhookAllowPrivate bool
+
+	TrashRetention time.Duration
+	LinkGoneStatus int
+	LinkGoneURL    string
+
+	FileStorageDriver string
+	FileStorageDir    string
+	FileMaxSize       int
+	FileAllowedTypes  []string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
 }
 
 func New() *Config {
@@ -38,9 +108,78 @@ func New() *Config {
 		JWTSecret:         jwtSecret,
 		JWTTTL:            time.Duration(ttlMinutes) * time.Minute,
 		AppBaseURL:        getEnv("APP_BASE_URL", "http://localhost:8080"),
+		TrustedProxies:    getEnvList("TRUSTED_PROXIES", []string{"172.16.0.0/12", "192.168.0.0/16", "10.0.0.0/8"}),
+
+		CustomDomainScheme: getEnv("CUSTOM_DOMAIN_SCHEME", "https"),
 
 		IPInfoToken:       getEnv("IPINFO_TOKEN", ""),
-		IPInfoHTTPTimeout: time.Duration(2) * time.Second,
+		IPInfoHTTPTimeout: getEnvDuration("IPINFO_TIMEOUT", 2*time.Second),
+
+		GeoIPDBPath:         getEnv("GEOIP_DB_PATH", ""),
+		GeoIPReloadInterval: getEnvDuration("GEOIP_RELOAD_INTERVAL", time.Minute),
+		GeoIPLanguage:       getEnv("GEOIP_LANGUAGE", "en"),
+		GeoIPTimeout:        getEnvDuration("GEOIP_TIMEOUT", 100*time.Millisecond),
+
+		GeoCountryHeader: getEnv("GEO_COUNTRY_HEADER", ""),
+		GeoRegionHeader:  getEnv("GEO_REGION_HEADER", ""),
+		GeoCityHeader:    getEnv("GEO_CITY_HEADER", ""),
+		GeoHeaderTimeout: getEnvDuration("GEO_HEADER_TIMEOUT", 100*time.Millisecond),
+
+		GeoCacheSize:        getEnvInt("GEO_CACHE_SIZE", 100000),
+		GeoCacheTTL:         getEnvDuration("GEO_CACHE_TTL", 24*time.Hour),
+		GeoCacheNegativeTTL: getEnvDuration("GEO_CACHE_NEGATIVE_TTL", time.Minute),
+		GeoCacheIPv4Prefix:  getEnvInt("GEO_CACHE_IPV4_PREFIX", 24),
+		GeoCacheIPv6Prefix:  getEnvInt("GEO_CACHE_IPV6_PREFIX", 48),
+		GeoCacheFile:        getEnv("GEO_CACHE_FILE", ""),
+
+		RedirectCacheSize:        getEnvInt("REDIRECT_CACHE_SIZE", 10000),
+		RedirectCacheTTL:         getEnvDuration("REDIRECT_CACHE_TTL", 5*time.Minute),
+		RedirectCacheNegativeTTL: getEnvDuration("REDIRECT_CACHE_NEGATIVE_TTL", 30*time.Second),
+
+		HealthCheckInterval:     getEnvDuration("HEALTH_CHECK_INTERVAL", 6*time.Hour),
+		HealthCheckTimeout:      getEnvDuration("HEALTH_CHECK_TIMEOUT", 10*time.Second),
+		HealthCheckConcurrency:  getEnvInt("HEALTH_CHECK_CONCURRENCY", 8),
+		HealthCheckAlertAfter:   getEnvInt("HEALTH_CHECK_ALERT_AFTER", 3),
+		HealthCheckAllowPrivate: getEnvBool("HEALTH_CHECK_ALLOW_PRIVATE", false),
+
+		HashStrategy:  getEnv("HASH_STRATEGY", "random"),
+		HashLength:    getEnvInt("HASH_LENGTH", 7),
+		HashAlphabet:  getEnv("HASH_ALPHABET", "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"),
+		HashWordCount: getEnvInt("HASH_WORD_COUNT", 3),
+
+		TransitionQueueSize:     getEnvInt("TRANSITION_QUEUE_SIZE", 10000),
+		TransitionWorkers:       getEnvInt("TRANSITION_WORKERS", 4),
+		TransitionEnrichWorkers: getEnvInt("TRANSITION_ENRICH_WORKERS", 32),
+		TransitionBatchSize:     getEnvInt("TRANSITION_BATCH_SIZE", 500),
+		TransitionFlushInterval: getEnvDuration("TRANSITION_FLUSH_INTERVAL", time.Second),
+		TransitionEnqueueWait:   getEnvDuration("TRANSITION_ENQUEUE_WAIT", 50*time.Millisecond),
+		TransitionExportTimeout: getEnvDuration("TRANSITION_EXPORT_TIMEOUT", 30*time.Minute),
+
+		RollupInterval:  getEnvDuration("ROLLUP_INTERVAL", time.Minute),
+		RollupBatchSize: getEnvInt("ROLLUP_BATCH_SIZE", 10000),
+
+		WebhookPollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookConcurrency:  getEnvInt("WEBHOOK_CONCURRENCY", 8),
+		WebhookRetention:    getEnvDuration("WEBHOOK_RETENTION", 30*24*time.Hour),
+		WebhookAllowPrivate: getEnvBool("WEBHOOK_ALLOW_PRIVATE", false),
+
+		TrashRetention: getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		LinkGoneStatus: getEnvInt("LINK_GONE_STATUS", 410),
+		LinkGoneURL:    getEnv("LINK_GONE_URL", ""),
+
+		FileStorageDriver: getEnv("FILE_STORAGE_DRIVER", "local"),
+		FileStorageDir:    getEnv("FILE_STORAGE_DIR", "./data/files"),
+		FileMaxSize:       getEnvInt("FILE_MAX_SIZE", 10<<20),
+		FileAllowedTypes:  getEnvList("FILE_ALLOWED_TYPES", []string{"application/pdf", "image/png", "image/jpeg", "image/gif", "image/webp"}),
+
+		S3Endpoint:  getEnv("S3_ENDPOINT", "localhost:9000"),
+		S3Region:    getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:    getEnv("S3_BUCKET", "qrcodegen"),
+		S3AccessKey: getEnv("S3_ACCESS_KEY", "minioadmin"),
+		S3SecretKey: getEnv("S3_SECRET_KEY", "minioadmin"),
+		S3UseSSL:    getEnvBool("S3_USE_SSL", false),
 	}
 }
 
@@ -53,3 +192,56 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Warn().Msgf("Invalid %s value, using default %d. Error: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Warn().Msgf("Invalid %s value, using default %s. Error: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return d
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warn().Msgf("Invalid %s value, using default %t. Error: %v", key, defaultValue, err)
+		return defaultValue
+	}
+	return b
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -7,7 +7,11 @@ import (
 	"qrcodegen/internal/delivery"
 	"qrcodegen/internal/delivery/http"
 	"qrcodegen/internal/pkg/database"
+	"qrcodegen/internal/pkg/dns"
 	"qrcodegen/internal/pkg/geo"
+	"qrcodegen/internal/pkg/hashid"
+	"qrcodegen/internal/pkg/probe"
+	"qrcodegen/internal/pkg/storage"
 	"qrcodegen/internal/repository/postgres"
 	"qrcodegen/internal/usecase"
 
@@ -25,16 +29,43 @@ func New() *fx.App {
 			database.NewDBPool,
 
 			postgres.NewRepository,
+			postgres.NewListener,
 
+			geo.NewLookupCache,
 			geo.NewGeoResolver,
+			dns.NewTXTResolver,
+			probe.NewProber,
+			probe.NewPageMetaFetcher,
+			probe.NewWebhookSender,
+			storage.NewFileStorage,
+			hashid.NewHashGenerator,
 
+			usecase.NewRedirectCache,
+			usecase.NewURLPolicy,
+			usecase.NewVisitorHasher,
+			usecase.NewScanBroker,
+			usecase.NewTransitionQueue,
+			usecase.NewPreviewRefresher,
 			usecase.NewUserUseCase,
 			usecase.NewLinkUseCase,
 			usecase.NewQRUseCase,
+			usecase.NewDomainUseCase,
+			usecase.NewFolderUseCase,
+			usecase.NewTagUseCase,
+			usecase.NewWebhookUseCase,
+			usecase.NewHealthChecker,
+			usecase.NewTrashPurger,
+			usecase.NewRollupAggregator,
+			usecase.NewWebhookDispatcher,
 
 			http.NewUserHandler,
 			http.NewLinkHandler,
 			http.NewQRHandler,
+			http.NewDomainHandler,
+			http.NewBlocklistHandler,
+			http.NewFolderHandler,
+			http.NewTagHandler,
+			http.NewWebhookHandler,
 
 			delivery.NewRouter,
 
@@ -42,6 +73,8 @@ func New() *fx.App {
 			NewFiberApp,
 		),
 		fx.Invoke(
+			registerGeoCache,
+			registerTransitionQueue,
 			func(lifecycle fx.Lifecycle, app *fiber.App, router *delivery.Router, cfg *config.Config) {
 				lifecycle.Append(fx.Hook{
 					OnStart: func(ctx context.Context) error {
@@ -61,14 +94,132 @@ func New() *fx.App {
 					},
 				})
 			},
+			registerRedirectCacheSync,
+			registerHealthChecker,
+			registerPreviewRefresher,
+			registerTrashPurger,
+			registerRollupAggregator,
+			registerWebhookDispatcher,
+			registerScanBroker,
+			registerTransitionExports,
 		),
 	)
 }
 
-func NewFiberApp() *fiber.App {
+// registerGeoCache restores the geo cache on start and saves it once the
+// transition queue, which resolves locations, has stopped.
+func registerGeoCache(lifecycle fx.Lifecycle, cache *geo.LookupCache) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			// A cold cache only costs lookups, so a bad file does not stop
+			// the app from starting.
+			if err := cache.Load(); err != nil {
+				log.Error().Err(err).Msg("Failed to load geo cache")
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return cache.Save()
+		},
+	})
+}
+
+// registerTransitionQueue writes out queued scans on shutdown. Hooks stop in
+// reverse order, so registering it before the server makes it flush once
+// the server has stopped taking requests.
+func registerTransitionQueue(lifecycle fx.Lifecycle, queue *usecase.TransitionQueue) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			queue.Start()
+			return nil
+		},
+		OnStop: queue.Close,
+	})
+}
+
+// registerRedirectCacheSync keeps the redirect cache consistent with edits
+// made by other instances.
+func registerRedirectCacheSync(lifecycle fx.Lifecycle, listener *postgres.Listener, cache *usecase.RedirectCache) {
+	runInBackground(lifecycle, func(ctx context.Context) {
+		listener.Listen(ctx, postgres.LinkChangesChannel, cache.Changed, cache.Purge)
+	})
+}
+
+func registerPreviewRefresher(lifecycle fx.Lifecycle, refresher *usecase.PreviewRefresher) {
+	runInBackground(lifecycle, refresher.Run)
+}
+
+func registerHealthChecker(lifecycle fx.Lifecycle, checker *usecase.HealthChecker) {
+	runInBackground(lifecycle, checker.Run)
+}
+
+func registerTrashPurger(lifecycle fx.Lifecycle, purger *usecase.TrashPurger) {
+	runInBackground(lifecycle, purger.Run)
+}
+
+func registerRollupAggregator(lifecycle fx.Lifecycle, aggregator *usecase.RollupAggregator) {
+	runInBackground(lifecycle, aggregator.Run)
+}
+
+func registerWebhookDispatcher(lifecycle fx.Lifecycle, dispatcher *usecase.WebhookDispatcher) {
+	runInBackground(lifecycle, dispatcher.Run)
+}
+
+// registerScanBroker ends live scan feeds on shutdown. Its hook is appended
+// after the server's, so it runs first; the server waits for open streams.
+func registerScanBroker(lifecycle fx.Lifecycle, broker usecase.ScanBroker) {
+	lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			broker.Close()
+			return nil
+		},
+	})
+}
+
+// registerTransitionExports ends transition exports on shutdown. Like the
+// scan broker's, its hook runs before the server's, which waits for them.
+func registerTransitionExports(lifecycle fx.Lifecycle, handler *http.LinkHandler) {
+	lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			handler.CloseExports()
+			return nil
+		},
+	})
+}
+
+// runInBackground starts run when the app starts and cancels it on stop,
+// waiting for it to return until the stop deadline.
+func runInBackground(lifecycle fx.Lifecycle, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			return nil
+		},
+	})
+}
+
+func NewFiberApp(cfg *config.Config) *fiber.App {
+	// Leave room for the multipart envelope around the largest upload.
+	bodyLimit := max(fiber.DefaultBodyLimit, cfg.FileMaxSize+1<<20)
+
 	app := fiber.New(fiber.Config{
+		BodyLimit:               bodyLimit,
 		EnableTrustedProxyCheck: true,
-		TrustedProxies:          []string{"172.16.0.0/12", "192.168.0.0/16", "10.0.0.0/8"},
+		TrustedProxies:          cfg.TrustedProxies,
 		ProxyHeader:             fiber.HeaderXForwardedFor,
 	})
 	return app
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"errors"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BlocklistHandler struct {
+	validate  *validator.Validate
+	urlPolicy *usecase.URLPolicy
+}
+
+func NewBlocklistHandler(validate *validator.Validate, urlPolicy *usecase.URLPolicy) *BlocklistHandler {
+	return &BlocklistHandler{validate: validate, urlPolicy: urlPolicy}
+}
+
+// GetBlockedDomains godoc
+// @Summary List blocked destination domains
+// @Description List domains that links are not allowed to point to. Admin only.
+// @Tags admin
+// @Produce  json
+// @Success 200 {object} dto.GetBlockedDomainsResponse
+// @Failure 401 {object} dto.GenericError
+// @Failure 403 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /admin/blocklist [get]
+func (h *BlocklistHandler) GetBlockedDomains(c *fiber.Ctx) error {
+	resp, err := h.urlPolicy.GetBlockedDomains(c.Context())
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// BlockDomain godoc
+// @Summary Block a destination domain
+// @Description Add a domain (and all of its subdomains) to the destination blocklist. Admin only.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param   domain  body      dto.BlockDomainRequest  true  "Domain to block"
+// @Success 201     {object}  dto.BlockedDomainInfo
+// @Failure 400     {object}  dto.GenericError
+// @Failure 401     {object}  dto.GenericError
+// @Failure 403     {object}  dto.GenericError
+// @Failure 500     {object}  dto.GenericError
+// @Router /admin/blocklist [post]
+func (h *BlocklistHandler) BlockDomain(c *fiber.Ctx) error {
+	var req dto.BlockDomainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	resp, err := h.urlPolicy.BlockDomain(c.Context(), req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrURLRejected) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// UnblockDomain godoc
+// @Summary Remove a domain from the blocklist
+// @Description Remove a domain from the destination blocklist. Admin only.
+// @Tags admin
+// @Param   id   path      int  true  "Blocked domain ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 403 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /admin/blocklist/{id} [delete]
+func (h *BlocklistHandler) UnblockDomain(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid blocked domain ID"})
+	}
+
+	if err := h.urlPolicy.UnblockDomain(c.Context(), int64(id)); err != nil {
+		if errors.Is(err, usecase.ErrBlockedDomainNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"qrcodegen/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportLinks godoc
+// @Summary Export links
+// @Description Export all links of the authenticated user with their QR styling and transition counts
+// @Tags links
+// @Produce  text/csv
+// @Produce  json
+// @Param   format  query  string  false  "Export format: csv|json (default csv)"
+// @Success 200 {array} dto.LinkExportItem
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/export [get]
+func (h *LinkHandler) ExportLinks(c *fiber.Ctx) error {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	format := c.Query("format", usecase.BulkFormatCSV)
+
+	var buf bytes.Buffer
+	if err := h.linkUseCase.ExportLinks(c.Context(), userID, format, &buf); err != nil {
+		if errors.Is(err, usecase.ErrUnsupportedFormat) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	c.Attachment("links." + format)
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+// ImportLinks godoc
+// @Summary Import links
+// @Description Import links from CSV or JSON, either as the request body or as a multipart "file". Every row is validated and reported on; valid rows are created even if others fail. A given hash is kept. With dry_run nothing is written.
+// @Tags links
+// @Accept  text/csv
+// @Accept  json
+// @Accept  mpfd
+// @Produce  json
+// @Param   format   query     string  false  "Import format: csv|json (default from the file name or Content-Type)"
+// @Param   dry_run  query     bool    false  "Only validate the rows"
+// @Param   file     formData  file    false  "File to import"
+// @Success 200 {object} dto.ImportLinksResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/import [post]
+func (h *LinkHandler) ImportLinks(c *fiber.Ctx) error {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	format := c.Query("format")
+	var body io.Reader = bytes.NewReader(c.Body())
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.Locals("logError", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+		}
+		defer file.Close()
+
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+		}
+	}
+	if format == "" {
+		format = usecase.BulkFormatCSV
+		if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+			format = usecase.BulkFormatJSON
+		}
+	}
+
+	dryRun := c.QueryBool("dry_run", false)
+
+	resp, err := h.linkUseCase.ImportLinks(c.Context(), userID, format, body, dryRun)
+	if err != nil {
+		if errors.Is(err, usecase.ErrUnsupportedFormat) || errors.Is(err, usecase.ErrImportMalformed) || errors.Is(err, usecase.ErrImportTooLarge) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DomainHandler struct {
+	validate      *validator.Validate
+	domainUseCase *usecase.DomainUseCase
+}
+
+func NewDomainHandler(validate *validator.Validate, domainUseCase *usecase.DomainUseCase) *DomainHandler {
+	return &DomainHandler{
+		validate:      validate,
+		domainUseCase: domainUseCase,
+	}
+}
+
+// CreateDomain godoc
+// @Summary Register a custom domain
+// @Description Register a custom domain for the authenticated user. The response contains the TXT record that proves ownership.
+// @Tags domains
+// @Accept  json
+// @Produce  json
+// @Param   domain  body      dto.CreateDomainRequest  true  "Domain data"
+// @Success 201     {object}  dto.CreateDomainResponse
+// @Failure 400     {object}  dto.GenericError
+// @Failure 401     {object}  dto.GenericError
+// @Failure 409     {object}  dto.GenericError
+// @Failure 500     {object}  dto.GenericError
+// @Router /domains [post]
+func (h *DomainHandler) CreateDomain(c *fiber.Ctx) error {
+	var req dto.CreateDomainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.domainUseCase.CreateDomain(c.Context(), req, userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrDomainAlreadyExists) || errors.Is(err, usecase.ErrDomainReserved) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// GetDomains godoc
+// @Summary Get all custom domains for a user
+// @Description Get all custom domains registered by the authenticated user
+// @Tags domains
+// @Produce  json
+// @Success 200 {object} dto.GetDomainsResponse
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /domains [get]
+func (h *DomainHandler) GetDomains(c *fiber.Ctx) error {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.domainUseCase.GetDomains(c.Context(), userID)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// VerifyDomain godoc
+// @Summary Verify a custom domain
+// @Description Check the ownership TXT record of a custom domain and mark it as verified
+// @Tags domains
+// @Produce  json
+// @Param   id   path      int  true  "Domain ID"
+// @Success 200 {object} dto.VerifyDomainResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 409 {object} dto.GenericError
+// @Failure 422 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /domains/{id}/verify [post]
+func (h *DomainHandler) VerifyDomain(c *fiber.Ctx) error {
+	domainID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid domain ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.domainUseCase.VerifyDomain(c.Context(), int64(domainID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrDomainNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrDomainVerificationFailed) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrDomainAlreadyExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// DeleteDomain godoc
+// @Summary Delete a custom domain
+// @Description Delete a custom domain that is not used by any link
+// @Tags domains
+// @Param   id   path      int  true  "Domain ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 409 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /domains/{id} [delete]
+func (h *DomainHandler) DeleteDomain(c *fiber.Ctx) error {
+	domainID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid domain ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	err = h.domainUseCase.DeleteDomain(c.Context(), int64(domainID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrDomainNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrDomainInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// ExportAllTransitions godoc
+// @Summary Export raw transitions of all links
+// @Description Stream every transition of the authenticated user's links in the order they were recorded, as CSV, NDJSON or XLSX
+// @Tags links
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param   format   query  string  false  "csv (default), ndjson or xlsx"
+// @Param   from     query  string  false  "Start of the range (YYYY-MM-DD or RFC 3339)"
+// @Param   to       query  string  false  "End of the range, a date includes the whole day"
+// @Param   columns  query  string  false  "Comma-separated columns: id, link_id, link_hash, link_name, created_at, country, region, city, geo_source, referer, user_agent, browser, os, via_preview, revision_id, schedule_id, visitor_hash (default all)"
+// @Success 200 {file} file
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/transitions/export [get]
+func (h *LinkHandler) ExportAllTransitions(c *fiber.Ctx) error {
+	return h.exportTransitions(c, nil)
+}
+
+// ExportTransitions godoc
+// @Summary Export raw transitions of a link
+// @Description Stream every transition of a link in the order they were recorded, as CSV, NDJSON or XLSX
+// @Tags links
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param   id       path   int     true   "Link ID"
+// @Param   format   query  string  false  "csv (default), ndjson or xlsx"
+// @Param   from     query  string  false  "Start of the range (YYYY-MM-DD or RFC 3339)"
+// @Param   to       query  string  false  "End of the range, a date includes the whole day"
+// @Param   columns  query  string  false  "Comma-separated columns, see /links/transitions/export (default all)"
+// @Success 200 {file} file
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/transitions/export [get]
+func (h *LinkHandler) ExportTransitions(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+	id := int64(linkID)
+	return h.exportTransitions(c, &id)
+}
+
+func (h *LinkHandler) exportTransitions(c *fiber.Ctx, linkID *int64) error {
+	rng, err := queryStatsRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	query := dto.TransitionExportQuery{
+		LinkID: linkID,
+		Range:  rng,
+		Format: c.Query("format"),
+	}
+	if columns := c.Query("columns"); columns != "" {
+		for _, column := range strings.Split(columns, ",") {
+			query.Columns = append(query.Columns, strings.TrimSpace(column))
+		}
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	export, err := h.linkUseCase.ExportTransitions(c.Context(), userID, query)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrInvalidExportQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	c.Attachment(export.Filename())
+	c.Set(fiber.HeaderContentType, export.ContentType())
+
+	// The body is written after the handler returns, so a failure from here
+	// on can only cut the download short. A client that goes away makes the
+	// next write fail and ends the query. One that stops reading blocks the
+	// writes; once the export times out or the server shuts down, they are
+	// failed through the connection's deadline.
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := h.exportContext()
+		defer cancel()
+		stop := context.AfterFunc(ctx, func() {
+			conn.SetWriteDeadline(time.Now())
+		})
+		defer stop()
+
+		if err := export.Write(ctx, w); err != nil {
+			log.Error().Err(err).Int64("user_id", userID).Msg("Transition export aborted")
+			return
+		}
+		if err := w.Flush(); err != nil {
+			log.Error().Err(err).Int64("user_id", userID).Msg("Transition export aborted")
+		}
+	})
+	return nil
+}
+
+// exportContext bounds one transition export by the export timeout and the
+// server's shutdown.
+func (h *LinkHandler) exportContext() (context.Context, context.CancelFunc) {
+	if h.cfg.TransitionExportTimeout > 0 {
+		return context.WithTimeout(h.exports, h.cfg.TransitionExportTimeout)
+	}
+	return context.WithCancel(h.exports)
+}
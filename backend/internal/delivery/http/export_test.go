@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+)
+
+func TestExportContext(t *testing.T) {
+	h := NewLinkHandler(nil, nil, &config.Config{TransitionExportTimeout: time.Minute})
+	ctx, cancel := h.exportContext()
+	defer cancel()
+
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("deadline = %v, %v; want one within the export timeout", deadline, ok)
+	}
+
+	h.CloseExports()
+	select {
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("export ended with %v, want it cancelled", ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("export kept running after the exports were closed")
+	}
+
+	// Exports started after shutdown end right away.
+	late, cancelLate := h.exportContext()
+	defer cancelLate()
+	if late.Err() == nil {
+		t.Error("export started after shutdown is not cancelled")
+	}
+}
+
+func TestExportContextWithoutTimeout(t *testing.T) {
+	h := NewLinkHandler(nil, nil, &config.Config{})
+	ctx, cancel := h.exportContext()
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("export has a deadline although the timeout is disabled")
+	}
+}
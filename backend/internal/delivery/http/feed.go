@@ -0,0 +1,116 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"qrcodegen/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	scanFeedKeepAlive = 15 * time.Second
+	scanFeedRetry     = 3 * time.Second
+)
+
+// ScanFeed godoc
+// @Summary Live feed of scans
+// @Description Server-Sent Events stream with a "scan" event for every transition of the authenticated user's links as it is recorded. Comment lines are sent as keep-alives. Scans are not replayed after a reconnect.
+// @Tags links
+// @Produce  text/event-stream
+// @Success 200 {object} dto.ScanEvent
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/feed [get]
+func (h *LinkHandler) ScanFeed(c *fiber.Ctx) error {
+	return h.scanFeed(c, nil)
+}
+
+// LinkScanFeed godoc
+// @Summary Live feed of a link's scans
+// @Description Server-Sent Events stream with a "scan" event for every transition of the link as it is recorded. Comment lines are sent as keep-alives. Scans are not replayed after a reconnect.
+// @Tags links
+// @Produce  text/event-stream
+// @Param   id   path      int  true  "Link ID"
+// @Success 200 {object} dto.ScanEvent
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/feed [get]
+func (h *LinkHandler) LinkScanFeed(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+	id := int64(linkID)
+	return h.scanFeed(c, &id)
+}
+
+func (h *LinkHandler) scanFeed(c *fiber.Ctx, linkID *int64) error {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	sub, err := h.linkUseCase.SubscribeScans(c.Context(), userID, linkID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Keeps reverse proxies such as nginx from buffering the stream.
+	c.Set("X-Accel-Buffering", "no")
+
+	// The stream ends when the broker closes the subscription on shutdown,
+	// or when a write fails because the client went away; keep-alives make
+	// sure that is noticed on quiet feeds too.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		ticker := time.NewTicker(scanFeedKeepAlive)
+		defer ticker.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", scanFeedRetry.Milliseconds())
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: scan\ndata: %s\n\n", data)
+			case <-ticker.C:
+				w.WriteString(": keep-alive\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
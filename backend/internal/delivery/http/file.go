@@ -0,0 +1,149 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateFileLink godoc
+// @Summary Create a file link
+// @Description Upload a PDF or image and create a link that serves it. Scans are counted as transitions.
+// @Tags links
+// @Accept  mpfd
+// @Produce  json
+// @Param   file       formData  file    true   "File to host"
+// @Param   name       formData  string  true   "Link name"
+// @Param   domain_id  formData  int     false  "Custom domain ID"
+// @Success 201 {object} dto.CreateLinkResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 413 {object} dto.GenericError
+// @Failure 415 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/upload [post]
+func (h *LinkHandler) CreateFileLink(c *fiber.Ctx) error {
+	var req dto.CreateFileLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse form"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File is required"})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	defer file.Close()
+
+	upload := usecase.FileUpload{Filename: fileHeader.Filename, Size: fileHeader.Size, Body: file}
+	resp, err := h.linkUseCase.CreateFileLink(c.Context(), req, upload, userID)
+	if err != nil {
+		if status, ok := uploadErrorStatus(err); ok {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrDomainNotFound) || errors.Is(err, usecase.ErrDomainNotVerified) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// ReplaceLinkFile godoc
+// @Summary Upload or replace the file of a link
+// @Description Replace the hosted file of a link while keeping its hash. A URL link becomes a file link.
+// @Tags links
+// @Accept  mpfd
+// @Produce  json
+// @Param   id    path      int   true  "Link ID"
+// @Param   file  formData  file  true  "File to host"
+// @Success 200 {object} dto.UploadLinkFileResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 413 {object} dto.GenericError
+// @Failure 415 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/file [put]
+func (h *LinkHandler) ReplaceLinkFile(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File is required"})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+	defer file.Close()
+
+	upload := usecase.FileUpload{Filename: fileHeader.Filename, Size: fileHeader.Size, Body: file}
+	resp, err := h.linkUseCase.ReplaceLinkFile(c.Context(), int64(linkID), userID, upload)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if status, ok := uploadErrorStatus(err); ok {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func uploadErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, usecase.ErrFileEmpty):
+		return fiber.StatusBadRequest, true
+	case errors.Is(err, usecase.ErrFileTooLarge):
+		return fiber.StatusRequestEntityTooLarge, true
+	case errors.Is(err, usecase.ErrFileTypeNotAllowed):
+		return fiber.StatusUnsupportedMediaType, true
+	}
+	return 0, false
+}
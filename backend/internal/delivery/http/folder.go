@@ -0,0 +1,295 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type FolderHandler struct {
+	validate      *validator.Validate
+	folderUseCase *usecase.FolderUseCase
+}
+
+func NewFolderHandler(validate *validator.Validate, folderUseCase *usecase.FolderUseCase) *FolderHandler {
+	return &FolderHandler{
+		validate:      validate,
+		folderUseCase: folderUseCase,
+	}
+}
+
+// CreateFolder godoc
+// @Summary Create a folder
+// @Description Create a folder for organizing the links of the authenticated user
+// @Tags folders
+// @Accept  json
+// @Produce  json
+// @Param   folder  body      dto.FolderRequest  true  "Folder data"
+// @Success 201     {object}  dto.FolderInfo
+// @Failure 400     {object}  dto.GenericError
+// @Failure 401     {object}  dto.GenericError
+// @Failure 409     {object}  dto.GenericError
+// @Failure 500     {object}  dto.GenericError
+// @Router /folders [post]
+func (h *FolderHandler) CreateFolder(c *fiber.Ctx) error {
+	var req dto.FolderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.folderUseCase.CreateFolder(c.Context(), req, userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrFolderAlreadyExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// GetFolders godoc
+// @Summary Get all folders for a user
+// @Description Get all folders of the authenticated user with the number of links in each
+// @Tags folders
+// @Produce  json
+// @Success 200 {object} dto.GetFoldersResponse
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /folders [get]
+func (h *FolderHandler) GetFolders(c *fiber.Ctx) error {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.folderUseCase.GetFolders(c.Context(), userID)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RenameFolder godoc
+// @Summary Rename a folder
+// @Description Rename a folder of the authenticated user
+// @Tags folders
+// @Accept  json
+// @Produce  json
+// @Param   id      path      int  true  "Folder ID"
+// @Param   folder  body      dto.FolderRequest  true  "Folder data"
+// @Success 200 {object} dto.FolderInfo
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 409 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /folders/{id} [patch]
+func (h *FolderHandler) RenameFolder(c *fiber.Ctx) error {
+	folderID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid folder ID"})
+	}
+
+	var req dto.FolderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.folderUseCase.RenameFolder(c.Context(), int64(folderID), userID, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrFolderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrFolderAlreadyExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// DeleteFolder godoc
+// @Summary Delete a folder
+// @Description Delete a folder of the authenticated user. Its links are kept and become unfiled.
+// @Tags folders
+// @Param   id   path      int  true  "Folder ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /folders/{id} [delete]
+func (h *FolderHandler) DeleteFolder(c *fiber.Ctx) error {
+	folderID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid folder ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	err = h.folderUseCase.DeleteFolder(c.Context(), int64(folderID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrFolderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetLinkFolder godoc
+// @Summary Move a link into a folder
+// @Description Put a link into a folder, or take it out of its folder when folder_id is null
+// @Tags folders
+// @Accept  json
+// @Param   id    path      int  true  "Link ID"
+// @Param   body  body      dto.SetLinkFolderRequest  true  "Target folder"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/folder [put]
+func (h *FolderHandler) SetLinkFolder(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	var req dto.SetLinkFolderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	err = h.folderUseCase.SetLinkFolder(c.Context(), int64(linkID), userID, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) || errors.Is(err, usecase.ErrFolderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetFolderStats godoc
+// @Summary Get campaign analytics for a folder
+// @Description Transitions added up across every link in the folder, per link and per day
+// @Tags folders
+// @Produce  json
+// @Param   id    path   int     true   "Folder ID"
+// @Param   from  query  string  false  "Start of the range (YYYY-MM-DD or RFC 3339)"
+// @Param   to    query  string  false  "End of the range, a date includes the whole day"
+// @Success 200 {object} dto.CampaignStatsResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /folders/{id}/stats [get]
+func (h *FolderHandler) GetFolderStats(c *fiber.Ctx) error {
+	folderID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid folder ID"})
+	}
+
+	rng, err := queryStatsRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.folderUseCase.GetFolderStats(c.Context(), int64(folderID), userID, rng)
+	if err != nil {
+		if errors.Is(err, usecase.ErrFolderNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
@@ -1,8 +1,13 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"math"
+	"mime"
+	"net/textproto"
 	"strconv"
+	"strings"
 
 	"qrcodegen/config"
 
@@ -17,16 +22,28 @@ type LinkHandler struct {
 	validate    *validator.Validate
 	linkUseCase *usecase.LinkUseCase
 	cfg         *config.Config
+	// exports is cancelled on shutdown to end transition exports, which are
+	// written after their handler has returned.
+	exports     context.Context
+	stopExports context.CancelFunc
 }
 
 func NewLinkHandler(validate *validator.Validate, linkUseCase *usecase.LinkUseCase, cfg *config.Config) *LinkHandler {
+	exports, stopExports := context.WithCancel(context.Background())
 	return &LinkHandler{
 		validate:    validate,
 		linkUseCase: linkUseCase,
 		cfg:         cfg,
+		exports:     exports,
+		stopExports: stopExports,
 	}
 }
 
+// CloseExports ends the transition exports that are still being written.
+func (h *LinkHandler) CloseExports() {
+	h.stopExports()
+}
+
 // CreateLink godoc
 // @Summary Create a new link
 // @Description Create a new shortened link for the authenticated user
@@ -62,6 +79,9 @@ func (h *LinkHandler) CreateLink(c *fiber.Ctx) error {
 
 	resp, err := h.linkUseCase.CreateLink(c.Context(), req, userID)
 	if err != nil {
+		if errors.Is(err, usecase.ErrDomainNotFound) || errors.Is(err, usecase.ErrDomainNotVerified) || errors.Is(err, usecase.ErrURLRejected) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		c.Locals("logError", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
 	}
@@ -71,13 +91,22 @@ func (h *LinkHandler) CreateLink(c *fiber.Ctx) error {
 
 // GetAllLinks godoc
 // @Summary Get all links for a user
-// @Description Get all links created by the authenticated user
+// @Description Get a page of the links created by the authenticated user. Pass next_cursor from the response as cursor to get the next page.
 // @Tags links
 // @Produce  json
-// @Param   search  query  string  false  "Filter by link name (case-insensitive)"
-// @Param   sort_by  query  string  false  "Sort by: created_at|transitions"
-// @Param   order    query  string  false  "Sort order: asc|desc"
+// @Param   search        query  string  false  "Filter by link name (case-insensitive)"
+// @Param   folder_id     query  int     false  "Only links in this folder"
+// @Param   tag_id        query  int     false  "Only links with this tag"
+// @Param   created_from  query  string  false  "Only links created at or after (YYYY-MM-DD or RFC 3339)"
+// @Param   created_to    query  string  false  "Only links created before, a date includes the whole day"
+// @Param   domain        query  string  false  "Only links whose destination is on this host"
+// @Param   has_scans     query  bool    false  "Only links with (true) or without (false) transitions"
+// @Param   sort_by       query  string  false  "Sort by: created_at|transitions|name|last_scan"
+// @Param   order         query  string  false  "Sort order: asc|desc"
+// @Param   cursor        query  string  false  "Cursor from the previous page"
+// @Param   limit         query  int     false  "Page size, 50 by default and at most 200"
 // @Success 200 {object} dto.GetAllLinksResponse
+// @Failure 400 {object} dto.GenericError
 // @Failure 401 {object} dto.GenericError
 // @Failure 500 {object} dto.GenericError
 // @Router /links [get]
@@ -93,30 +122,47 @@ func (h *LinkHandler) GetAllLinks(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
 	}
 
-	search := c.Query("search")
-	sortBy := c.Query("sort_by")
-	order := c.Query("order")
-	var resp *dto.GetAllLinksResponse
-	if search != "" {
-		resp, err = h.linkUseCase.SearchLinksByName(c.Context(), userID, search)
-	} else {
-		resp, err = h.linkUseCase.GetAllLinks(c.Context(), userID)
+	filter := dto.LinkFilter{
+		Search: c.Query("search"),
+		Domain: c.Query("domain"),
 	}
-	if err != nil {
-		c.Locals("logError", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	if filter.FolderID, err = queryInt64(c, "folder_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.TagID, err = queryInt64(c, "tag_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.CreatedFrom, err = queryTime(c, "created_from", false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.CreatedTo, err = queryTime(c, "created_to", true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.HasScans, err = queryBool(c, "has_scans"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// apply sorting in usecase layer helper
-	by := usecase.SortByCreatedAt
-	if sortBy == "transitions" {
-		by = usecase.SortByTransitions
+	page := dto.LinkPage{
+		SortBy: c.Query("sort_by"),
+		Order:  c.Query("order"),
+		Cursor: c.Query("cursor"),
+	}
+	limit, err := queryInt64(c, "limit")
+	if err != nil || (limit != nil && *limit <= 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid limit"})
+	}
+	if limit != nil {
+		page.Limit = int(min(*limit, math.MaxInt32))
 	}
-	ord := usecase.SortDesc
-	if order == "asc" {
-		ord = usecase.SortAsc
+
+	resp, err := h.linkUseCase.GetAllLinks(c.Context(), userID, filter, page)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSort) || errors.Is(err, usecase.ErrInvalidCursor) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
 	}
-	resp.Links = h.linkUseCase.SortLinks(resp.Links, by, ord)
 
 	return c.Status(fiber.StatusOK).JSON(resp)
 }
@@ -164,7 +210,7 @@ func (h *LinkHandler) GetLink(c *fiber.Ctx) error {
 
 // EditLink godoc
 // @Summary Edit a link
-// @Description Edit a specific link by its ID for the authenticated user
+// @Description Edit a specific link by its ID for the authenticated user. File links may omit original_url to keep their file; setting it removes the file. Omitting domain_id keeps the link's domain; clear_domain moves it back to the default domain.
 // @Tags links
 // @Accept  json
 // @Produce  json
@@ -207,6 +253,9 @@ func (h *LinkHandler) EditLink(c *fiber.Ctx) error {
 		if errors.Is(err, usecase.ErrLinkNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 		}
+		if errors.Is(err, usecase.ErrOriginalURLRequired) || errors.Is(err, usecase.ErrDomainNotFound) || errors.Is(err, usecase.ErrDomainNotVerified) || errors.Is(err, usecase.ErrURLRejected) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		c.Locals("logError", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
 	}
@@ -214,35 +263,194 @@ func (h *LinkHandler) EditLink(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(resp)
 }
 
+// CloneLink godoc
+// @Summary Clone a link
+// @Description Copy a link of the authenticated user with its QR styling, folder, tags and hosted file under a new hash. Fields in the optional body override the copied values. The copy starts without transitions.
+// @Tags links
+// @Accept  json
+// @Produce  json
+// @Param   id   path      int  true  "Source link ID"
+// @Param   link body      dto.CloneLinkRequest  false  "Overrides"
+// @Success 201 {object} dto.CreateLinkResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/clone [post]
+func (h *LinkHandler) CloneLink(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	var req dto.CloneLinkRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		}
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	resp, err := h.linkUseCase.CloneLink(c.Context(), int64(linkID), userID, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrDomainNotFound) || errors.Is(err, usecase.ErrDomainNotVerified) || errors.Is(err, usecase.ErrURLRejected) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
 // Redirect godoc
 // @Summary Redirect to original URL
-// @Description Redirects a shortened link to its original URL
+// @Description Redirects a shortened link to its original URL, or serves the hosted file of a file link. On a custom domain only links assigned to that domain are served. Links in interstitial mode, and any hash followed by "+", show an HTML preview page first; its Continue link adds a signed via token that marks the scan as a click-through. The link's redirect_mode picks the status code or an HTML meta refresh or JavaScript redirect; any HTTP method is accepted so 307 and 308 keep it.
 // @Tags redirect
-// @Param   hash   path      string  true  "Link hash"
-// @Success 302 {string} string "Redirects to the original URL"
+// @Produce html
+// @Param   hash   path      string  true  "Link hash, optionally followed by + to force the preview"
+// @Param   via    query     string  false "Token from the Continue link of the preview page"
+// @Success 200 {file} file "Hosted file, preview page, or meta_refresh/javascript redirect page"
+// @Success 301 {string} string "Redirects to the original URL (redirect_mode 301)"
+// @Success 302 {string} string "Redirects to the original URL (default redirect_mode)"
+// @Success 307 {string} string "Redirects keeping the request method (redirect_mode 307)"
+// @Success 308 {string} string "Redirects permanently keeping the request method (redirect_mode 308)"
 // @Failure 400 {object} dto.GenericError
 // @Failure 404 {object} dto.GenericError
+// @Failure 410 {object} dto.GenericError "Link is in the trash (status and redirect are configurable)"
 // @Failure 500 {object} dto.GenericError
 // @Router /redirect/{hash} [get]
+// @Router /redirect/{hash} [post]
 func (h *LinkHandler) Redirect(c *fiber.Ctx) error {
+	return h.redirect(c, h.linkUseCase.Redirect)
+}
+
+// RedirectCustomDomain godoc
+// @Summary Redirect to original URL on a custom domain
+// @Description Short form of /redirect/{hash}, served only on verified custom domains
+// @Tags redirect
+// @Produce html
+// @Param   hash   path      string  true  "Link hash, optionally followed by + to force the preview"
+// @Param   via    query     string  false "Token from the Continue link of the preview page"
+// @Success 200 {file} file "Hosted file, preview page, or meta_refresh/javascript redirect page"
+// @Success 301 {string} string "Redirects to the original URL (redirect_mode 301)"
+// @Success 302 {string} string "Redirects to the original URL (default redirect_mode)"
+// @Success 307 {string} string "Redirects keeping the request method (redirect_mode 307)"
+// @Success 308 {string} string "Redirects permanently keeping the request method (redirect_mode 308)"
+// @Failure 400 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 410 {object} dto.GenericError "Link is in the trash (status and redirect are configurable)"
+// @Failure 500 {object} dto.GenericError
+// @Router /{hash} [get]
+// @Router /{hash} [post]
+func (h *LinkHandler) RedirectCustomDomain(c *fiber.Ctx) error {
+	return h.redirect(c, h.linkUseCase.RedirectCustomDomain)
+}
+
+type redirectFunc func(ctx context.Context, req usecase.RedirectRequest) (*usecase.RedirectTarget, error)
+
+func (h *LinkHandler) redirect(c *fiber.Ctx, resolve redirectFunc) error {
 	hash := c.Params("hash")
+	hash, preview := strings.CutSuffix(hash, "+")
 	if hash == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Hash is required"})
 	}
 
-	referer := c.Get("Referer")
-	userAgent := c.Get("User-Agent")
-	ip := c.IP()
+	req := usecase.RedirectRequest{
+		Hash:         hash,
+		Host:         c.Hostname(),
+		Referer:      c.Get("Referer"),
+		UserAgent:    c.Get("User-Agent"),
+		IP:           c.IP(),
+		Preview:      preview,
+		PreviewToken: c.Query("via"),
+	}
+	// Location headers such as CF-IPCountry are only believed when a proxy
+	// we trust set them; clients could send their own otherwise.
+	if c.IsProxyTrusted() {
+		req.Headers = h.geoHeaders(c)
+	}
 
-	originalURL, err := h.linkUseCase.Redirect(c.Context(), hash, referer, userAgent, ip)
+	target, err := resolve(c.Context(), req)
 	if err != nil {
 		if errors.Is(err, usecase.ErrLinkNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 		}
+		if errors.Is(err, usecase.ErrLinkGone) {
+			if h.cfg.LinkGoneURL != "" {
+				return c.Redirect(h.cfg.LinkGoneURL, fiber.StatusFound)
+			}
+			return c.Status(h.cfg.LinkGoneStatus).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
 	}
 
-	return c.Redirect(originalURL, fiber.StatusFound)
+	if target.Preview != nil {
+		return renderPreview(c, target.Preview, strings.TrimSuffix(c.Path(), "+"), target.PreviewToken)
+	}
+
+	if target.File != nil {
+		disposition := mime.FormatMediaType("inline", map[string]string{"filename": target.File.Filename})
+		c.Set(fiber.HeaderContentType, target.File.ContentType)
+		c.Set(fiber.HeaderContentDisposition, disposition)
+		return c.SendStream(target.Body, int(target.File.Size))
+	}
+
+	switch target.Mode {
+	case usecase.RedirectMetaRefresh:
+		return renderRedirectPage(c, target.URL, false)
+	case usecase.RedirectJavaScript:
+		return renderRedirectPage(c, target.URL, true)
+	}
+
+	status, ok := redirectStatuses[target.Mode]
+	if !ok {
+		status = fiber.StatusFound
+	}
+	return c.Redirect(target.URL, status)
+}
+
+// geoHeaders copies the configured location headers of the request and
+// nothing else, so cookies and credentials never reach the scan queue.
+func (h *LinkHandler) geoHeaders(c *fiber.Ctx) map[string][]string {
+	var headers map[string][]string
+	for _, name := range []string{h.cfg.GeoCountryHeader, h.cfg.GeoRegionHeader, h.cfg.GeoCityHeader} {
+		if name == "" {
+			continue
+		}
+		if value := c.Get(name); value != "" {
+			if headers == nil {
+				headers = make(map[string][]string, 3)
+			}
+			headers[textproto.CanonicalMIMEHeaderKey(name)] = []string{value}
+		}
+	}
+	return headers
+}
+
+var redirectStatuses = map[usecase.RedirectMode]int{
+	usecase.RedirectMovedPermanently: fiber.StatusMovedPermanently,
+	usecase.RedirectFound:            fiber.StatusFound,
+	usecase.RedirectTemporary:        fiber.StatusTemporaryRedirect,
+	usecase.RedirectPermanent:        fiber.StatusPermanentRedirect,
 }
 
 // GetTransitionsByLink godoc
@@ -281,8 +489,8 @@ func (h *LinkHandler) GetTransitionsByLink(c *fiber.Ctx) error {
 }
 
 // DeleteLink godoc
-// @Summary Delete a link
-// @Description Delete a specific link by its ID for the authenticated user
+// @Summary Move a link to the trash
+// @Description Move a link of the authenticated user to the trash. It stops redirecting and can be restored until it is purged.
 // @Tags links
 // @Param   id   path      int  true  "Link ID"
 // @Success 204 "No Content"
@@ -318,4 +526,4 @@ func (h *LinkHandler) DeleteLink(c *fiber.Ctx) error {
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
-}
\ No newline at end of file
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newRedirectTestApp serves /redirect/:hash with the handler's redirect
+// logic in front of a stub resolver that always returns target. Only
+// proxies in 10.0.0.0/8 are trusted unless others are given.
+func newRedirectTestApp(target *usecase.RedirectTarget, got *usecase.RedirectRequest, trustedProxies ...string) *fiber.App {
+	h := &LinkHandler{cfg: &config.Config{
+		GeoCountryHeader: "CF-IPCountry",
+		GeoCityHeader:    "x-geo-city",
+	}}
+	resolve := func(ctx context.Context, req usecase.RedirectRequest) (*usecase.RedirectTarget, error) {
+		if got != nil {
+			*got = req
+		}
+		return target, nil
+	}
+
+	app := fiber.New(fiber.Config{
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          append([]string{"10.0.0.0/8"}, trustedProxies...),
+	})
+	app.All("/redirect/:hash", func(c *fiber.Ctx) error {
+		return h.redirect(c, resolve)
+	})
+	return app
+}
+
+func TestRedirectStatus(t *testing.T) {
+	const dest = "https://example.org/landing?a=1&b=2"
+
+	tests := []struct {
+		mode   usecase.RedirectMode
+		method string
+		status int
+	}{
+		{usecase.RedirectMovedPermanently, fiber.MethodGet, fiber.StatusMovedPermanently},
+		{usecase.RedirectFound, fiber.MethodGet, fiber.StatusFound},
+		{usecase.RedirectTemporary, fiber.MethodPost, fiber.StatusTemporaryRedirect},
+		{usecase.RedirectPermanent, fiber.MethodPost, fiber.StatusPermanentRedirect},
+		// Links stored before redirect modes existed have no mode.
+		{"", fiber.MethodGet, fiber.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			app := newRedirectTestApp(&usecase.RedirectTarget{URL: dest, Mode: tt.mode}, nil)
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, "/redirect/abc123", nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if loc := resp.Header.Get(fiber.HeaderLocation); loc != dest {
+				t.Errorf("Location = %q, want %q", loc, dest)
+			}
+		})
+	}
+}
+
+func TestRedirectPage(t *testing.T) {
+	// Quotes and angle brackets must not break out of the attribute or the
+	// script.
+	const dest = `https://example.org/p?q="x"&r=<b>`
+
+	tests := []struct {
+		mode usecase.RedirectMode
+		want []string
+	}{
+		{
+			mode: usecase.RedirectMetaRefresh,
+			want: []string{
+				`<meta http-equiv="refresh" content="0; url=https://example.org/p?q=&#34;x&#34;&amp;r=&lt;b&gt;">`,
+				`<a href="https://example.org/p?q=%22x%22&amp;r=%3cb%3e" rel="nofollow">https://example.org/p?q=&#34;x&#34;&amp;r=&lt;b&gt;</a>`,
+			},
+		},
+		{
+			mode: usecase.RedirectJavaScript,
+			want: []string{
+				`<script>window.location.replace("https://example.org/p?q=\"x\"\u0026r=\u003cb\u003e");</script>`,
+				`<noscript><meta http-equiv="refresh" content="0; url=https://example.org/p?q=&#34;x&#34;&amp;r=&lt;b&gt;"></noscript>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			app := newRedirectTestApp(&usecase.RedirectTarget{URL: dest, Mode: tt.mode}, nil)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/redirect/abc123", nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+			}
+			if loc := resp.Header.Get(fiber.HeaderLocation); loc != "" {
+				t.Errorf("Location = %q, want none", loc)
+			}
+			if ct := resp.Header.Get(fiber.HeaderContentType); ct != fiber.MIMETextHTMLCharsetUTF8 {
+				t.Errorf("Content-Type = %q, want %q", ct, fiber.MIMETextHTMLCharsetUTF8)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("body does not contain %s\n%s", want, body)
+				}
+			}
+			if strings.Contains(string(body), `="x"`) || strings.Contains(string(body), "<b>") {
+				t.Errorf("body contains the unescaped destination\n%s", body)
+			}
+		})
+	}
+}
+
+func TestRedirectRequest(t *testing.T) {
+	var got usecase.RedirectRequest
+	app := newRedirectTestApp(&usecase.RedirectTarget{URL: "https://example.org", Mode: usecase.RedirectFound}, &got)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/redirect/abc123", nil)
+	req.Header.Set("Referer", "https://news.example.com/")
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("CF-IPCountry", "DE")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+
+	if got.Hash != "abc123" || got.Preview {
+		t.Errorf("hash = %q, preview = %v, want abc123 without preview", got.Hash, got.Preview)
+	}
+	if got.Referer != "https://news.example.com/" || got.UserAgent != "test-agent" {
+		t.Errorf("referer = %q, user agent = %q", got.Referer, got.UserAgent)
+	}
+	// The test client is not a trusted proxy, so location headers are not
+	// passed on.
+	if got.Headers != nil {
+		t.Errorf("headers = %v, want none from an untrusted client", got.Headers)
+	}
+}
+
+func TestRedirectRequestGeoHeaders(t *testing.T) {
+	var got usecase.RedirectRequest
+	// app.Test connects from 0.0.0.0.
+	app := newRedirectTestApp(&usecase.RedirectTarget{URL: "https://example.org", Mode: usecase.RedirectFound}, &got, "0.0.0.0")
+
+	req := httptest.NewRequest(fiber.MethodGet, "/redirect/abc123", nil)
+	req.Header.Set("CF-IPCountry", "DE")
+	req.Header.Set("X-Geo-City", "Berlin")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+
+	want := http.Header{"Cf-Ipcountry": {"DE"}, "X-Geo-City": {"Berlin"}}
+	if !reflect.DeepEqual(got.Headers, want) {
+		t.Errorf("headers = %v, want only the configured location headers %v", got.Headers, want)
+	}
+	if got.Headers.Get("CF-IPCountry") != "DE" || got.Headers.Get("x-geo-city") != "Berlin" {
+		t.Errorf("headers %v cannot be looked up by their configured names", got.Headers)
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/url"
+
+	"qrcodegen/internal/dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var (
+	previewTemplate  = template.Must(template.ParseFS(templateFS, "templates/preview.html"))
+	redirectTemplate = template.Must(template.ParseFS(templateFS, "templates/redirect.html"))
+)
+
+type previewPage struct {
+	dto.LinkPreview
+	ContinueURL string
+}
+
+// renderPreview serves the interstitial page. Continuing goes back to the
+// same short link with the token that marks it as a click-through from the
+// preview.
+func renderPreview(c *fiber.Ctx, preview *dto.LinkPreview, path, token string) error {
+	page := previewPage{
+		LinkPreview: *preview,
+		ContinueURL: path + "?via=" + url.QueryEscape(token),
+	}
+
+	return renderHTML(c, previewTemplate, page)
+}
+
+// renderRedirectPage sends the visitor on with a meta refresh, or with
+// JavaScript falling back to a meta refresh when scripts are disabled.
+func renderRedirectPage(c *fiber.Ctx, url string, javaScript bool) error {
+	page := struct {
+		URL        string
+		JavaScript bool
+	}{URL: url, JavaScript: javaScript}
+
+	return renderHTML(c, redirectTemplate, page)
+}
+
+func renderHTML(c *fiber.Ctx, tmpl *template.Template, data any) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
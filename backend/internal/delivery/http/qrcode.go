@@ -102,6 +102,9 @@ func (h *LinkHandler) DownloadQR(c *fiber.Ctx) error {
 	}
 
 	redirectURL := fmt.Sprintf("%s/redirect/%s", h.cfg.AppBaseURL, link.Hash)
+	if link.Domain != nil {
+		redirectURL = fmt.Sprintf("%s://%s/%s", h.cfg.CustomDomainScheme, *link.Domain, link.Hash)
+	}
 
 	var (
 		data        []byte
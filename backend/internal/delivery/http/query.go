@@ -0,0 +1,74 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"qrcodegen/internal/dto"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const queryDateLayout = "2006-01-02"
+
+// queryInt64 returns the integer query parameter key, or nil when it is absent.
+func queryInt64(c *fiber.Ctx, key string) (*int64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid " + key)
+	}
+	return &v, nil
+}
+
+// queryBool returns the boolean query parameter key, or nil when it is absent.
+func queryBool(c *fiber.Ctx, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, errors.New("invalid " + key)
+	}
+	return &v, nil
+}
+
+// queryStatsRange reads the from and to query parameters as RFC 3339
+// timestamps or dates. A date in to includes that whole day.
+func queryStatsRange(c *fiber.Ctx) (dto.StatsRange, error) {
+	var rng dto.StatsRange
+	var err error
+	if rng.From, err = queryTime(c, "from", false); err != nil {
+		return rng, err
+	}
+	if rng.To, err = queryTime(c, "to", true); err != nil {
+		return rng, err
+	}
+	if rng.From != nil && rng.To != nil && !rng.From.Before(*rng.To) {
+		return rng, errors.New("from must be before to")
+	}
+	return rng, nil
+}
+
+func queryTime(c *fiber.Ctx, key string, endOfDay bool) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(queryDateLayout, raw)
+	if err != nil {
+		return nil, errors.New("invalid " + key + ", use YYYY-MM-DD or RFC 3339")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"qrcodegen/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetRevisions godoc
+// @Summary Get change history of a link
+// @Description List destination and QR design revisions of a link, newest first, with the number of transitions recorded under each revision
+// @Tags links
+// @Produce  json
+// @Param   id   path      int  true  "Link ID"
+// @Success 200 {object} dto.GetLinkRevisionsResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/revisions [get]
+func (h *LinkHandler) GetRevisions(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.linkUseCase.GetRevisions(c.Context(), int64(linkID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RollbackLink godoc
+// @Summary Roll a link back to a revision
+// @Description Restore the destination and QR design of a past revision. The rollback is recorded as a new revision.
+// @Tags links
+// @Produce  json
+// @Param   id           path      int  true  "Link ID"
+// @Param   revisionId   path      int  true  "Revision ID"
+// @Success 200 {object} dto.RollbackLinkResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 409 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/revisions/{revisionId}/rollback [post]
+func (h *LinkHandler) RollbackLink(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	revisionID, err := c.ParamsInt("revisionId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid revision ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.linkUseCase.RollbackLink(c.Context(), int64(linkID), int64(revisionID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrRevisionNotFound) || errors.Is(err, usecase.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrRevisionFileGone) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrDomainNotFound) || errors.Is(err, usecase.ErrDomainNotVerified) || errors.Is(err, usecase.ErrURLRejected) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
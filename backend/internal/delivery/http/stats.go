@@ -0,0 +1,79 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetLinkStats godoc
+// @Summary Get aggregated scan statistics of a link
+// @Description Transitions of a link counted per hour, day, week or month, with the most frequent countries, cities, browsers, operating systems and referers. Counts are read from hourly and daily rollups, so the range is widened to whole hours and the latest scans appear after the next rollup run. Unique visitors are counted per hour or per UTC day; week and month buckets and the total add up the daily counts, as unique_visitors_per and series_unique_visitors_per say.
+// @Tags links
+// @Produce  json
+// @Param   id           path   int     true   "Link ID"
+// @Param   from         query  string  false  "Start of the range (YYYY-MM-DD or RFC 3339)"
+// @Param   to           query  string  false  "End of the range, a date includes the whole day"
+// @Param   granularity  query  string  false  "hour, day (default), week or month"
+// @Param   tz           query  string  false  "IANA time zone buckets are aligned to, default UTC"
+// @Param   top          query  int     false  "Values per breakdown, default 10, at most 100"
+// @Success 200 {object} dto.LinkStatsResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/stats [get]
+func (h *LinkHandler) GetLinkStats(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	rng, err := queryStatsRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	top, err := queryInt64(c, "top")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	query := dto.LinkStatsQuery{
+		Range:       rng,
+		Granularity: c.Query("granularity"),
+		TimeZone:    c.Query("tz"),
+	}
+	if top != nil {
+		query.Top = int(*top)
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.linkUseCase.GetLinkStats(c.Context(), int64(linkID), userID, query)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrInvalidStatsQuery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,295 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TagHandler struct {
+	validate   *validator.Validate
+	tagUseCase *usecase.TagUseCase
+}
+
+func NewTagHandler(validate *validator.Validate, tagUseCase *usecase.TagUseCase) *TagHandler {
+	return &TagHandler{
+		validate:   validate,
+		tagUseCase: tagUseCase,
+	}
+}
+
+// CreateTag godoc
+// @Summary Create a tag
+// @Description Create a tag for organizing the links of the authenticated user
+// @Tags tags
+// @Accept  json
+// @Produce  json
+// @Param   tag  body      dto.TagRequest  true  "Tag data"
+// @Success 201     {object}  dto.TagInfo
+// @Failure 400     {object}  dto.GenericError
+// @Failure 401     {object}  dto.GenericError
+// @Failure 409     {object}  dto.GenericError
+// @Failure 500     {object}  dto.GenericError
+// @Router /tags [post]
+func (h *TagHandler) CreateTag(c *fiber.Ctx) error {
+	var req dto.TagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.tagUseCase.CreateTag(c.Context(), req, userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrTagAlreadyExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// GetTags godoc
+// @Summary Get all tags for a user
+// @Description Get all tags of the authenticated user with the number of links in each
+// @Tags tags
+// @Produce  json
+// @Success 200 {object} dto.GetTagsResponse
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /tags [get]
+func (h *TagHandler) GetTags(c *fiber.Ctx) error {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.tagUseCase.GetTags(c.Context(), userID)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RenameTag godoc
+// @Summary Rename a tag
+// @Description Rename a tag of the authenticated user
+// @Tags tags
+// @Accept  json
+// @Produce  json
+// @Param   id      path      int  true  "Tag ID"
+// @Param   tag  body      dto.TagRequest  true  "Tag data"
+// @Success 200 {object} dto.TagInfo
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 409 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /tags/{id} [patch]
+func (h *TagHandler) RenameTag(c *fiber.Ctx) error {
+	tagID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tag ID"})
+	}
+
+	var req dto.TagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.tagUseCase.RenameTag(c.Context(), int64(tagID), userID, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrTagNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrTagAlreadyExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// DeleteTag godoc
+// @Summary Delete a tag
+// @Description Delete a tag of the authenticated user and remove it from all links
+// @Tags tags
+// @Param   id   path      int  true  "Tag ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTag(c *fiber.Ctx) error {
+	tagID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tag ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	err = h.tagUseCase.DeleteTag(c.Context(), int64(tagID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrTagNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetLinkTags godoc
+// @Summary Set the tags of a link
+// @Description Replace the tags of a link with the given tag IDs
+// @Tags tags
+// @Accept  json
+// @Param   id    path      int  true  "Link ID"
+// @Param   body  body      dto.SetLinkTagsRequest  true  "Tag IDs"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /links/{id}/tags [put]
+func (h *TagHandler) SetLinkTags(c *fiber.Ctx) error {
+	linkID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	var req dto.SetLinkTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	err = h.tagUseCase.SetLinkTags(c.Context(), int64(linkID), userID, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrLinkNotFound) || errors.Is(err, usecase.ErrTagNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetTagStats godoc
+// @Summary Get campaign analytics for a tag
+// @Description Transitions added up across every link carrying the tag, per link and per day
+// @Tags tags
+// @Produce  json
+// @Param   id    path   int     true   "Tag ID"
+// @Param   from  query  string  false  "Start of the range (YYYY-MM-DD or RFC 3339)"
+// @Param   to    query  string  false  "End of the range, a date includes the whole day"
+// @Success 200 {object} dto.CampaignStatsResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /tags/{id}/stats [get]
+func (h *TagHandler) GetTagStats(c *fiber.Ctx) error {
+	tagID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tag ID"})
+	}
+
+	rng, err := queryStatsRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.tagUseCase.GetTagStats(c.Context(), int64(tagID), userID, rng)
+	if err != nil {
+		if errors.Is(err, usecase.ErrTagNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
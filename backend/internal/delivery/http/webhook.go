@@ -0,0 +1,339 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/usecase"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	validate       *validator.Validate
+	webhookUseCase *usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(validate *validator.Validate, webhookUseCase *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		validate:       validate,
+		webhookUseCase: webhookUseCase,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description Subscribe a URL to link.scanned, link.created, link.updated, link.deleted or link.restored events. Every request is a POST of a dto.WebhookEvent with an X-Webhook-Signature header "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed with the secret>". The secret is only returned here.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   webhook  body      dto.CreateWebhookRequest  true  "Webhook data"
+// @Success 201      {object}  dto.WebhookInfo
+// @Failure 400      {object}  dto.GenericError
+// @Failure 401      {object}  dto.GenericError
+// @Failure 500      {object}  dto.GenericError
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	var req dto.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.webhookUseCase.CreateWebhook(c.Context(), req, userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidWebhookURL) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// GetWebhooks godoc
+// @Summary Get all webhooks for a user
+// @Description Get all webhooks of the authenticated user, without their secrets
+// @Tags webhooks
+// @Produce  json
+// @Success 200 {object} dto.GetWebhooksResponse
+// @Failure 401 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.webhookUseCase.GetWebhooks(c.Context(), userID)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Change the URL or events of a webhook, or pause and resume it with active. Events of a paused webhook are not recorded.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   id       path      int  true  "Webhook ID"
+// @Param   webhook  body      dto.UpdateWebhookRequest  true  "Fields to change"
+// @Success 200 {object} dto.WebhookInfo
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid webhook ID"})
+	}
+
+	var req dto.UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.webhookUseCase.UpdateWebhook(c.Context(), int64(webhookID), userID, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrWebhookNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, usecase.ErrInvalidWebhookURL) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook of the authenticated user together with its delivery log. Pending deliveries are dropped.
+// @Tags webhooks
+// @Param   id   path      int  true  "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid webhook ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	err = h.webhookUseCase.DeleteWebhook(c.Context(), int64(webhookID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrWebhookNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TestWebhook godoc
+// @Summary Send a test event
+// @Description Queue a webhook.test event for the webhook, even if it is paused. It is sent within seconds; its outcome shows up in the delivery log.
+// @Tags webhooks
+// @Produce  json
+// @Param   id   path      int  true  "Webhook ID"
+// @Success 202 {object} dto.WebhookDeliveryInfo
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /webhooks/{id}/test [post]
+func (h *WebhookHandler) TestWebhook(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid webhook ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.webhookUseCase.TestWebhook(c.Context(), int64(webhookID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrWebhookNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(resp)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get the delivery log of a webhook
+// @Description List deliveries of a webhook newest first, 100 at a time. status=dead lists the dead letters: deliveries that failed every attempt.
+// @Tags webhooks
+// @Produce  json
+// @Param   id         path   int     true   "Webhook ID"
+// @Param   status     query  string  false  "pending, succeeded or dead"
+// @Param   before_id  query  int     false  "next_before_id of the previous page"
+// @Success 200 {object} dto.GetWebhookDeliveriesResponse
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid webhook ID"})
+	}
+
+	var status *string
+	switch s := c.Query("status"); s {
+	case "":
+	case usecase.WebhookDeliveryPending, usecase.WebhookDeliverySucceeded, usecase.WebhookDeliveryDead:
+		status = &s
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid status"})
+	}
+
+	beforeID, err := queryInt64(c, "before_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.webhookUseCase.GetWebhookDeliveries(c.Context(), int64(webhookID), userID, status, beforeID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrWebhookNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RetryWebhookDelivery godoc
+// @Summary Retry a webhook delivery
+// @Description Queue a dead or delivered delivery again with a fresh set of attempts
+// @Tags webhooks
+// @Produce  json
+// @Param   id          path      int  true  "Webhook ID"
+// @Param   deliveryId  path      int  true  "Delivery ID"
+// @Success 202 {object} dto.WebhookDeliveryInfo
+// @Failure 400 {object} dto.GenericError
+// @Failure 401 {object} dto.GenericError
+// @Failure 404 {object} dto.GenericError
+// @Failure 500 {object} dto.GenericError
+// @Router /webhooks/{id}/deliveries/{deliveryId}/retry [post]
+func (h *WebhookHandler) RetryWebhookDelivery(c *fiber.Ctx) error {
+	webhookID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid webhook ID"})
+	}
+
+	deliveryID, err := c.ParamsInt("deliveryId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid delivery ID"})
+	}
+
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	resp, err := h.webhookUseCase.RetryWebhookDelivery(c.Context(), int64(webhookID), int64(deliveryID), userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrWebhookNotFound) || errors.Is(err, usecase.ErrWebhookDeliveryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals("logError", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(resp)
+}
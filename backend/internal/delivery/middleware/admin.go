@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Admin must run after Auth.
+func Admin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isAdmin, _ := c.Locals("isAdmin").(bool); !isAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+		}
+		return c.Next()
+	}
+}
@@ -32,6 +32,7 @@ func Auth(cfg *config.Config) fiber.Handler {
 
 		c.Locals("userID", claims["sub"])
 		c.Locals("email", claims["email"])
+		c.Locals("isAdmin", claims["admin"] == true)
 
 		return c.Next()
 	}
@@ -12,18 +12,28 @@ import (
 )
 
 type Router struct {
-	userHandler *http.UserHandler
-	linkHandler *http.LinkHandler
-	qrHandler   *http.QRHandler
-	cfg         *config.Config
+	userHandler      *http.UserHandler
+	linkHandler      *http.LinkHandler
+	qrHandler        *http.QRHandler
+	domainHandler    *http.DomainHandler
+	blocklistHandler *http.BlocklistHandler
+	folderHandler    *http.FolderHandler
+	tagHandler       *http.TagHandler
+	webhookHandler   *http.WebhookHandler
+	cfg              *config.Config
 }
 
-func NewRouter(userHandler *http.UserHandler, linkHandler *http.LinkHandler, qrHandler *http.QRHandler, cfg *config.Config) *Router {
+func NewRouter(userHandler *http.UserHandler, linkHandler *http.LinkHandler, qrHandler *http.QRHandler, domainHandler *http.DomainHandler, blocklistHandler *http.BlocklistHandler, folderHandler *http.FolderHandler, tagHandler *http.TagHandler, webhookHandler *http.WebhookHandler, cfg *config.Config) *Router {
 	return &Router{
-		userHandler: userHandler,
-		linkHandler: linkHandler,
-		qrHandler:   qrHandler,
-		cfg:         cfg,
+		userHandler:      userHandler,
+		linkHandler:      linkHandler,
+		qrHandler:        qrHandler,
+		domainHandler:    domainHandler,
+		blocklistHandler: blocklistHandler,
+		folderHandler:    folderHandler,
+		tagHandler:       tagHandler,
+		webhookHandler:   webhookHandler,
+		cfg:              cfg,
 	}
 }
 
@@ -34,7 +44,8 @@ func (r *Router) Register(app *fiber.App) {
 
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
-	app.Get("/redirect/:hash", r.linkHandler.Redirect)
+	// Short links answer any method so 307 and 308 redirects can keep it.
+	app.All("/redirect/:hash", r.linkHandler.Redirect)
 
 	apiV1 := app.Group("/api/v1")
 
@@ -46,10 +57,68 @@ func (r *Router) Register(app *fiber.App) {
 
 	links := authenticated.Group("/links")
 	links.Post("/create", r.linkHandler.CreateLink)
+	links.Post("/upload", r.linkHandler.CreateFileLink)
+	links.Post("/import", r.linkHandler.ImportLinks)
+	links.Get("/export", r.linkHandler.ExportLinks)
+	links.Get("/transitions/export", r.linkHandler.ExportAllTransitions)
+	links.Get("/feed", r.linkHandler.ScanFeed)
+	links.Get("/trash", r.linkHandler.GetTrash)
+	links.Post("/trash/:id<int>/restore", r.linkHandler.RestoreLink)
+	links.Delete("/trash/:id<int>", r.linkHandler.PurgeLink)
 	links.Get("/", r.linkHandler.GetAllLinks)
 	links.Get("/:id<int>", r.linkHandler.GetLink)
 	links.Patch("/:id<int>", r.linkHandler.EditLink)
 	links.Delete("/:id<int>", r.linkHandler.DeleteLink)
+	links.Put("/:id<int>/file", r.linkHandler.ReplaceLinkFile)
+	links.Post("/:id<int>/clone", r.linkHandler.CloneLink)
+	links.Put("/:id<int>/folder", r.folderHandler.SetLinkFolder)
+	links.Put("/:id<int>/tags", r.tagHandler.SetLinkTags)
 	links.Get("/:id<int>/download", r.linkHandler.DownloadQR)
 	links.Get("/:id<int>/transitions", r.linkHandler.GetTransitionsByLink)
+	links.Get("/:id<int>/transitions/export", r.linkHandler.ExportTransitions)
+	links.Get("/:id<int>/feed", r.linkHandler.LinkScanFeed)
+	links.Get("/:id<int>/stats", r.linkHandler.GetLinkStats)
+	links.Get("/:id<int>/revisions", r.linkHandler.GetRevisions)
+	links.Post("/:id<int>/revisions/:revisionId<int>/rollback", r.linkHandler.RollbackLink)
+	links.Get("/:id<int>/schedules", r.linkHandler.GetSchedules)
+	links.Post("/:id<int>/schedules", r.linkHandler.CreateSchedule)
+	links.Delete("/:id<int>/schedules/:scheduleId<int>", r.linkHandler.DeleteSchedule)
+
+	domains := authenticated.Group("/domains")
+	domains.Post("/", r.domainHandler.CreateDomain)
+	domains.Get("/", r.domainHandler.GetDomains)
+	domains.Post("/:id<int>/verify", r.domainHandler.VerifyDomain)
+	domains.Delete("/:id<int>", r.domainHandler.DeleteDomain)
+
+	folders := authenticated.Group("/folders")
+	folders.Post("/", r.folderHandler.CreateFolder)
+	folders.Get("/", r.folderHandler.GetFolders)
+	folders.Patch("/:id<int>", r.folderHandler.RenameFolder)
+	folders.Delete("/:id<int>", r.folderHandler.DeleteFolder)
+	folders.Get("/:id<int>/stats", r.folderHandler.GetFolderStats)
+
+	tags := authenticated.Group("/tags")
+	tags.Post("/", r.tagHandler.CreateTag)
+	tags.Get("/", r.tagHandler.GetTags)
+	tags.Patch("/:id<int>", r.tagHandler.RenameTag)
+	tags.Delete("/:id<int>", r.tagHandler.DeleteTag)
+	tags.Get("/:id<int>/stats", r.tagHandler.GetTagStats)
+
+	webhooks := authenticated.Group("/webhooks")
+	webhooks.Post("/", r.webhookHandler.CreateWebhook)
+	webhooks.Get("/", r.webhookHandler.GetWebhooks)
+	webhooks.Patch("/:id<int>", r.webhookHandler.UpdateWebhook)
+	webhooks.Delete("/:id<int>", r.webhookHandler.DeleteWebhook)
+	webhooks.Post("/:id<int>/test", r.webhookHandler.TestWebhook)
+	webhooks.Get("/:id<int>/deliveries", r.webhookHandler.GetWebhookDeliveries)
+	webhooks.Post("/:id<int>/deliveries/:deliveryId<int>/retry", r.webhookHandler.RetryWebhookDelivery)
+
+	admin := authenticated.Group("/admin", middleware.Admin())
+	admin.Get("/blocklist", r.blocklistHandler.GetBlockedDomains)
+	admin.Post("/blocklist", r.blocklistHandler.BlockDomain)
+	admin.Delete("/blocklist/:id<int>", r.blocklistHandler.UnblockDomain)
+
+	// Bare short links on custom domains; registered last so it never
+	// shadows the routes above.
+	app.All("/:hash", r.linkHandler.RedirectCustomDomain)
 }
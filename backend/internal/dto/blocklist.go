@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+type BlockDomainRequest struct {
+	Host   string `json:"host" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+type BlockedDomainInfo struct {
+	ID        int64     `json:"id"`
+	Host      string    `json:"host"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetBlockedDomainsResponse struct {
+	Domains []BlockedDomainInfo `json:"domains"`
+}
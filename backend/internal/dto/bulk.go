@@ -0,0 +1,53 @@
+package dto
+
+import "time"
+
+// LinkExportItem is one exported link. File is the name of the hosted file
+// of a file link, which has no original_url and cannot be imported.
+type LinkExportItem struct {
+	Name         string    `json:"name"`
+	OriginalURL  string    `json:"original_url"`
+	Hash         string    `json:"hash"`
+	Domain       *string   `json:"domain,omitempty"`
+	Color        string    `json:"color"`
+	Background   string    `json:"background"`
+	Smoothing    *float64  `json:"smoothing"`
+	Interstitial bool      `json:"interstitial"`
+	RedirectMode string    `json:"redirect_mode"`
+	File         *string   `json:"file,omitempty"`
+	Transitions  int64     `json:"transitions_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ImportLinkRow is one link to import. Everything but name and original_url
+// is optional; an exported LinkExportItem is a valid row. Rows with a file
+// are exported file links and are reported as failed.
+type ImportLinkRow struct {
+	Name         string   `json:"name"`
+	OriginalURL  string   `json:"original_url"`
+	Hash         string   `json:"hash,omitempty"`
+	Domain       string   `json:"domain,omitempty"`
+	Color        string   `json:"color,omitempty"`
+	Background   string   `json:"background,omitempty"`
+	Smoothing    *float64 `json:"smoothing,omitempty"`
+	Interstitial bool     `json:"interstitial,omitempty"`
+	RedirectMode string   `json:"redirect_mode,omitempty"`
+	File         string   `json:"file,omitempty"`
+}
+
+type ImportRowResult struct {
+	Row   int    `json:"row"`
+	Name  string `json:"name"`
+	ID    *int64 `json:"id,omitempty"`
+	Hash  string `json:"hash,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type ImportLinksResponse struct {
+	Message  string            `json:"message"`
+	DryRun   bool              `json:"dry_run"`
+	Total    int               `json:"total"`
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Rows     []ImportRowResult `json:"rows"`
+}
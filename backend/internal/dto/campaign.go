@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+// StatsRange limits statistics to transitions in [From, To). Nil bounds are
+// open.
+type StatsRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+type DailyTransitions struct {
+	Date        time.Time `json:"date"`
+	Transitions int64     `json:"transitions_count"`
+}
+
+type CampaignLinkStats struct {
+	ID               int64      `json:"id"`
+	Name             string     `json:"name"`
+	Hash             string     `json:"hash"`
+	Transitions      int64      `json:"transitions_count"`
+	LastTransitionAt *time.Time `json:"last_transition_at,omitempty"`
+}
+
+type CampaignStatsResponse struct {
+	From        *time.Time          `json:"from,omitempty"`
+	To          *time.Time          `json:"to,omitempty"`
+	LinksCount  int                 `json:"links_count"`
+	Transitions int64               `json:"transitions_count"`
+	Daily       []DailyTransitions  `json:"daily"`
+	Links       []CampaignLinkStats `json:"links"`
+}
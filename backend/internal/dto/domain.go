@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+type CreateDomainRequest struct {
+	Host string `json:"host" validate:"required,fqdn"`
+}
+
+type DomainInfo struct {
+	ID             int64      `json:"id"`
+	Host           string     `json:"host"`
+	Verified       bool       `json:"verified"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	TXTRecordName  string     `json:"txt_record_name"`
+	TXTRecordValue string     `json:"txt_record_value"`
+}
+
+type CreateDomainResponse struct {
+	Domain  DomainInfo `json:"domain"`
+	Message string     `json:"message"`
+}
+
+type GetDomainsResponse struct {
+	Domains []DomainInfo `json:"domains"`
+}
+
+type VerifyDomainResponse struct {
+	Domain  DomainInfo `json:"domain"`
+	Message string     `json:"message"`
+}
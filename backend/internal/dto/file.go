@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type CreateFileLinkRequest struct {
+	Name     string `form:"name" validate:"required"`
+	DomainID *int64 `form:"domain_id"`
+}
+
+type LinkFileInfo struct {
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UploadLinkFileResponse struct {
+	Message string       `json:"message"`
+	ID      int64        `json:"id"`
+	File    LinkFileInfo `json:"file"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type FolderRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type FolderInfo struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	LinksCount int64     `json:"links_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type GetFoldersResponse struct {
+	Folders []FolderInfo `json:"folders"`
+}
+
+type SetLinkFolderRequest struct {
+	FolderID *int64 `json:"folder_id"`
+}
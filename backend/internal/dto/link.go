@@ -3,8 +3,12 @@ package dto
 import "time"
 
 type CreateLinkRequest struct {
-	OriginalURL string `json:"original_url" validate:"required,url"`
-	Name        string `json:"name" validate:"required"`
+	OriginalURL  string `json:"original_url" validate:"required,url"`
+	Name         string `json:"name" validate:"required"`
+	DomainID     *int64 `json:"domain_id,omitempty"`
+	Interstitial bool   `json:"interstitial"`
+	// RedirectMode defaults to 302.
+	RedirectMode string `json:"redirect_mode" validate:"omitempty,oneof=301 302 307 308 meta_refresh javascript"`
 }
 
 type CreateLinkResponse struct {
@@ -12,36 +16,109 @@ type CreateLinkResponse struct {
 	Message string `json:"message"`
 }
 
+// LinkInfo is a row of the link list. UniqueVisitors counts each visitor
+// once per UTC day and adds up the days. Both counts come from the rollups
+// and trail live scans by up to a rollup interval.
 type LinkInfo struct {
-	ID          int64     `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	Name        string    `json:"name"`
-	CreatedAt   time.Time `json:"created_at"`
-	Transitions int64     `json:"transitions_count"`
+	ID               int64      `json:"id"`
+	OriginalURL      string     `json:"original_url"`
+	Name             string     `json:"name"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Transitions      int64      `json:"transitions_count"`
+	UniqueVisitors   int64      `json:"unique_visitors"`
+	LastTransitionAt *time.Time `json:"last_transition_at,omitempty"`
+	Broken           bool       `json:"broken"`
+	FolderID         *int64     `json:"folder_id,omitempty"`
+	TagIDs           []int64    `json:"tag_ids"`
+}
+
+// LinkFilter narrows the link list. Zero fields do not filter.
+type LinkFilter struct {
+	Search      string
+	FolderID    *int64
+	TagID       *int64
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Domain      string
+	HasScans    *bool
+}
+
+// LinkPage selects the ordering and the page of the link list.
+type LinkPage struct {
+	SortBy string
+	Order  string
+	Cursor string
+	Limit  int
 }
 
 type GetAllLinksResponse struct {
-	Links   []LinkInfo `json:"links"`
-	Message string     `json:"message"`
+	Links      []LinkInfo `json:"links"`
+	Total      int64      `json:"total"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	HasMore    bool       `json:"has_more"`
+	Message    string     `json:"message"`
 }
 
 type GetLinkResponse struct {
-	ID          int64     `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	Hash        string    `json:"hash"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Name        string    `json:"name"`
-	Color       string    `json:"color"`
-	Background  string    `json:"background"`
-	Smoothing   *float64  `json:"smoothing"`
+	ID          int64           `json:"id"`
+	OriginalURL string          `json:"original_url"`
+	Hash        string          `json:"hash"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Name        string          `json:"name"`
+	Color       string          `json:"color"`
+	Background  string          `json:"background"`
+	Smoothing   *float64        `json:"smoothing"`
+	DomainID    *int64          `json:"domain_id,omitempty"`
+	Domain      *string         `json:"domain,omitempty"`
+	Health      *LinkHealthInfo `json:"health,omitempty"`
+	RevisionID  *int64          `json:"revision_id,omitempty"`
+	File        *LinkFileInfo   `json:"file,omitempty"`
+	FolderID    *int64          `json:"folder_id,omitempty"`
+	Tags        []TagInfo       `json:"tags"`
+	ClonedFrom  *int64          `json:"cloned_from,omitempty"`
+
+	Interstitial         bool   `json:"interstitial"`
+	RedirectMode         string `json:"redirect_mode"`
+	PreviewViews         int64  `json:"preview_views"`
+	PreviewClickThroughs int64  `json:"preview_click_throughs"`
+}
+
+type LinkHealthInfo struct {
+	Healthy             bool      `json:"healthy"`
+	StatusCode          *int64    `json:"status_code,omitempty"`
+	RedirectChain       []string  `json:"redirect_chain"`
+	Error               *string   `json:"error,omitempty"`
+	ConsecutiveFailures int64     `json:"consecutive_failures"`
+	Broken              bool      `json:"broken"`
+	CheckedAt           time.Time `json:"checked_at"`
 }
 
 type EditLinkRequest struct {
-	OriginalURL string  `json:"original_url" validate:"required,url"`
+	OriginalURL string  `json:"original_url" validate:"omitempty,url"`
 	Color       string  `json:"color" validate:"required,hexadecimal,len=6"`
 	Background  string  `json:"background" validate:"required,hexadecimal,len=6"`
 	Smoothing   float64 `json:"smoothing" validate:"gte=0,lte=0.5"`
+	// DomainID moves the link to a custom domain; the domain is left
+	// unchanged when omitted.
+	DomainID *int64 `json:"domain_id,omitempty"`
+	// ClearDomain moves the link back to the default domain.
+	ClearDomain bool `json:"clear_domain,omitempty" validate:"excluded_with=DomainID"`
+	// Interstitial is left unchanged when omitted.
+	Interstitial *bool `json:"interstitial,omitempty"`
+	// RedirectMode is left unchanged when empty.
+	RedirectMode string `json:"redirect_mode,omitempty" validate:"omitempty,oneof=301 302 307 308 meta_refresh javascript"`
+}
+
+// CloneLinkRequest overrides fields of the copied link; empty fields keep
+// the source's values.
+type CloneLinkRequest struct {
+	OriginalURL string   `json:"original_url" validate:"omitempty,url"`
+	Name        string   `json:"name"`
+	Color       string   `json:"color" validate:"omitempty,hexadecimal,len=6"`
+	Background  string   `json:"background" validate:"omitempty,hexadecimal,len=6"`
+	Smoothing   *float64 `json:"smoothing" validate:"omitempty,gte=0,lte=0.5"`
+	DomainID    *int64   `json:"domain_id,omitempty"`
 }
 
 type EditLinkResponse struct {
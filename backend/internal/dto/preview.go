@@ -0,0 +1,13 @@
+package dto
+
+// LinkPreview is what the interstitial page shows before a visitor
+// continues to the destination.
+type LinkPreview struct {
+	Name        string
+	Host        string
+	Destination string
+	Title       string
+	Description string
+	ImageURL    string
+	FileName    string
+}
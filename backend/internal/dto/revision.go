@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type LinkRevisionItem struct {
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"user_id"`
+	Action         string    `json:"action"`
+	OriginalURL    string    `json:"original_url"`
+	Color          string    `json:"color"`
+	Background     string    `json:"background"`
+	Smoothing      *float64  `json:"smoothing"`
+	DomainID       *int64    `json:"domain_id,omitempty"`
+	RolledBackFrom *int64    `json:"rolled_back_from,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Transitions    int64     `json:"transitions_count"`
+	Current        bool      `json:"current"`
+}
+
+type GetLinkRevisionsResponse struct {
+	Revisions []LinkRevisionItem `json:"revisions"`
+}
+
+type RollbackLinkResponse struct {
+	Message string `json:"message"`
+	ID      int64  `json:"id"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// CreateLinkScheduleRequest adds a schedule entry. "once" entries need
+// starts_at; "weekly" entries need weekdays, start_time and end_time as
+// HH:MM in time_zone. An end_time before start_time runs past midnight.
+type CreateLinkScheduleRequest struct {
+	Kind        string     `json:"kind" validate:"required,oneof=once weekly"`
+	OriginalURL string     `json:"original_url" validate:"required,url"`
+	TimeZone    string     `json:"time_zone" example:"Europe/Berlin"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	Weekdays    []string   `json:"weekdays,omitempty" validate:"dive,oneof=sun mon tue wed thu fri sat"`
+	StartTime   string     `json:"start_time,omitempty" example:"11:00"`
+	EndTime     string     `json:"end_time,omitempty" example:"16:00"`
+}
+
+type LinkScheduleInfo struct {
+	ID          int64      `json:"id"`
+	Kind        string     `json:"kind"`
+	OriginalURL string     `json:"original_url"`
+	TimeZone    string     `json:"time_zone"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	Weekdays    []string   `json:"weekdays,omitempty"`
+	StartTime   string     `json:"start_time,omitempty"`
+	EndTime     string     `json:"end_time,omitempty"`
+	Active      bool       `json:"active"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type GetLinkSchedulesResponse struct {
+	Schedules []LinkScheduleInfo `json:"schedules"`
+}
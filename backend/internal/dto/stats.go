@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+type LinkStatsQuery struct {
+	Range StatsRange
+	// Granularity is hour, day, week or month.
+	Granularity string
+	// TimeZone is the IANA zone that buckets are aligned to.
+	TimeZone string
+	// Top limits each breakdown to its most frequent values.
+	Top int
+}
+
+// StatsBucket is one period of the series. UniqueVisitors counts each
+// visitor once per SeriesUniqueVisitorsPer window; week and month buckets
+// add up the unique visitors of their days, so a visitor seen on three days
+// of a week counts three times.
+type StatsBucket struct {
+	Time           time.Time `json:"time"`
+	Transitions    int64     `json:"transitions_count"`
+	UniqueVisitors int64     `json:"unique_visitors"`
+}
+
+// BreakdownItem counts the transitions with one value of a dimension. An
+// empty value stands for transitions where it was not recorded.
+type BreakdownItem struct {
+	Value       string `json:"value"`
+	Transitions int64  `json:"transitions_count"`
+}
+
+type LinkStatsBreakdowns struct {
+	Countries []BreakdownItem `json:"countries"`
+	Cities    []BreakdownItem `json:"cities"`
+	Browsers  []BreakdownItem `json:"browsers"`
+	OS        []BreakdownItem `json:"os"`
+	Referers  []BreakdownItem `json:"referers"`
+}
+
+// LinkStatsResponse reports total and unique counts. Visitors are counted
+// once per UTC day and the daily counts are added up, so over several days
+// UniqueVisitors is the number of visitor-days, not of distinct visitors.
+// The *UniqueVisitorsPer fields name the window each count is unique in:
+// "hour" or "day".
+type LinkStatsResponse struct {
+	LinkID                  int64               `json:"link_id"`
+	From                    *time.Time          `json:"from,omitempty"`
+	To                      *time.Time          `json:"to,omitempty"`
+	Granularity             string              `json:"granularity"`
+	TimeZone                string              `json:"time_zone"`
+	Transitions             int64               `json:"transitions_count"`
+	UniqueVisitors          int64               `json:"unique_visitors"`
+	UniqueVisitorsPer       string              `json:"unique_visitors_per"`
+	Series                  []StatsBucket       `json:"series"`
+	SeriesUniqueVisitorsPer string              `json:"series_unique_visitors_per"`
+	Breakdowns              LinkStatsBreakdowns `json:"breakdowns"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type TagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type TagInfo struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	LinksCount int64     `json:"links_count,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type GetTagsResponse struct {
+	Tags []TagInfo `json:"tags"`
+}
+
+type SetLinkTagsRequest struct {
+	TagIDs []int64 `json:"tag_ids" validate:"max=50"`
+}
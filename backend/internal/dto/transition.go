@@ -3,16 +3,51 @@ package dto
 import "time"
 
 type TransitionItem struct {
-	ID        int64     `json:"id"`
-	Country   *string   `json:"country,omitempty"`
-	City      *string   `json:"city,omitempty"`
-	Referer   *string   `json:"referer,omitempty"`
-	UserAgent *string   `json:"user_agent,omitempty"`
-	Browser   *string   `json:"browser,omitempty"`
-	OS        *string   `json:"os,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int64     `json:"id"`
+	Country    *string   `json:"country,omitempty"`
+	Region     *string   `json:"region,omitempty"`
+	City       *string   `json:"city,omitempty"`
+	Referer    *string   `json:"referer,omitempty"`
+	UserAgent  *string   `json:"user_agent,omitempty"`
+	Browser    *string   `json:"browser,omitempty"`
+	OS         *string   `json:"os,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	RevisionID *int64    `json:"revision_id,omitempty"`
+	ScheduleID *int64    `json:"schedule_id,omitempty"`
+	GeoSource  *string   `json:"geo_source,omitempty"`
 }
 
 type GetTransitionsResponse struct {
 	Transitions []TransitionItem `json:"transitions"`
 }
+
+type TransitionExportQuery struct {
+	// LinkID limits the export to one link; nil exports all links.
+	LinkID *int64
+	Range  StatsRange
+	// Format is csv, ndjson or xlsx.
+	Format string
+	// Columns are written in this order; empty means all of them.
+	Columns []string
+}
+
+// ScanEvent is a transition as it is recorded, sent to live scan feeds.
+// Destination is where the scan was sent, which differs from the link's
+// URL while a schedule entry is active.
+type ScanEvent struct {
+	UserID      int64     `json:"user_id"`
+	LinkID      int64     `json:"link_id"`
+	Hash        string    `json:"hash"`
+	Name        string    `json:"name"`
+	Destination string    `json:"destination"`
+	Country     *string   `json:"country,omitempty"`
+	Region      *string   `json:"region,omitempty"`
+	City        *string   `json:"city,omitempty"`
+	GeoSource   *string   `json:"geo_source,omitempty"`
+	Referer     *string   `json:"referer,omitempty"`
+	Browser     *string   `json:"browser,omitempty"`
+	OS          *string   `json:"os,omitempty"`
+	ViaPreview  bool      `json:"via_preview"`
+	ScheduleID  *int64    `json:"schedule_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+type TrashedLinkInfo struct {
+	ID          int64      `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	Name        string     `json:"name"`
+	Hash        string     `json:"hash"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   time.Time  `json:"deleted_at"`
+	PurgeAt     *time.Time `json:"purge_at,omitempty"`
+}
+
+type GetTrashResponse struct {
+	Links   []TrashedLinkInfo `json:"links"`
+	Message string            `json:"message"`
+}
+
+type RestoreLinkResponse struct {
+	Message string `json:"message"`
+	ID      int64  `json:"id"`
+}
@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048" example:"https://crm.example.com/hooks/qr"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=link.scanned link.created link.updated link.deleted link.restored" example:"link.scanned"`
+}
+
+// UpdateWebhookRequest changes the fields that are set; active pauses or
+// resumes deliveries.
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url,omitempty" validate:"omitempty,url,max=2048"`
+	Events []string `json:"events,omitempty" validate:"omitempty,min=1,dive,oneof=link.scanned link.created link.updated link.deleted link.restored"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// WebhookInfo carries the signing secret only in the response to creation.
+type WebhookInfo struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetWebhooksResponse struct {
+	Webhooks []WebhookInfo `json:"webhooks"`
+}
+
+type WebhookDeliveryInfo struct {
+	ID             int64           `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status" example:"pending"`
+	Attempts       int64           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int64          `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// GetWebhookDeliveriesResponse lists deliveries newest first. NextBeforeID
+// is set when older deliveries remain; pass it as before_id.
+type GetWebhookDeliveriesResponse struct {
+	Deliveries   []WebhookDeliveryInfo `json:"deliveries"`
+	NextBeforeID *int64                `json:"next_before_id,omitempty"`
+}
+
+// WebhookEvent is the body of every webhook request. id stays the same
+// across retries of a delivery, so receivers can drop duplicates.
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type" example:"link.scanned"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookLinkData is the data of link.created, link.updated, link.deleted
+// and link.restored events.
+type WebhookLinkData struct {
+	ID          int64  `json:"id"`
+	Hash        string `json:"hash"`
+	Name        string `json:"name,omitempty"`
+	OriginalURL string `json:"original_url,omitempty"`
+	DomainID    *int64 `json:"domain_id,omitempty"`
+}
+
+// WebhookTestData is the data of webhook.test events.
+type WebhookTestData struct {
+	WebhookID int64 `json:"webhook_id"`
+}
@@ -0,0 +1,11 @@
+package dns
+
+import (
+	"net"
+
+	"qrcodegen/internal/usecase"
+)
+
+func NewTXTResolver() usecase.TXTResolver {
+	return net.DefaultResolver
+}
@@ -0,0 +1,204 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/pkg/lru"
+	"qrcodegen/internal/usecase"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// LookupCache remembers locations per network prefix for resolvers that go
+// over the network. Addresses of one prefix share an entry, so a /24 or /48
+// is looked up once, and concurrent misses for a prefix share one lookup.
+type LookupCache struct {
+	entries     *lru.Cache[netip.Prefix, usecase.GeoLocation]
+	flight      singleflight.Group
+	ttl         time.Duration
+	negativeTTL time.Duration
+	ipv4Bits    int
+	ipv6Bits    int
+	path        string
+}
+
+// geoLookup resolves one address without caching. An empty location is
+// cached as a miss; an error means the answer is unknown and is not cached.
+type geoLookup func(ctx context.Context, addr netip.Addr) (usecase.GeoLocation, error)
+
+// cachedGeoEntry is the form entries take in the cache file.
+type cachedGeoEntry struct {
+	Prefix    netip.Prefix `json:"prefix"`
+	Country   string       `json:"country,omitempty"`
+	Region    string       `json:"region,omitempty"`
+	City      string       `json:"city,omitempty"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+func NewLookupCache(cfg *config.Config) *LookupCache {
+	return &LookupCache{
+		entries:     lru.New[netip.Prefix, usecase.GeoLocation](cfg.GeoCacheSize),
+		ttl:         cfg.GeoCacheTTL,
+		negativeTTL: cfg.GeoCacheNegativeTTL,
+		ipv4Bits:    min(max(cfg.GeoCacheIPv4Prefix, 0), 32),
+		ipv6Bits:    min(max(cfg.GeoCacheIPv6Prefix, 0), 128),
+		path:        cfg.GeoCacheFile,
+	}
+}
+
+// wrap puts the cache in front of lookup, which is given up to timeout.
+func (c *LookupCache) wrap(lookup geoLookup, timeout time.Duration) geoStep {
+	return &cachedResolver{cache: c, lookup: lookup, timeout: timeout}
+}
+
+func (c *LookupCache) prefix(addr netip.Addr) netip.Prefix {
+	bits := c.ipv6Bits
+	if addr.Is4() {
+		bits = c.ipv4Bits
+	}
+	p, _ := addr.Prefix(bits)
+	return p
+}
+
+// Load fills the cache from its file, skipping entries that expired in the
+// meantime or were stored under other prefix lengths. A missing file is not
+// an error.
+func (c *LookupCache) Load() error {
+	if c.path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read geo cache: %w", err)
+	}
+
+	var entries []cachedGeoEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return fmt.Errorf("failed to decode geo cache: %w", err)
+	}
+
+	now, loaded := time.Now(), 0
+	for _, e := range entries {
+		ttl := e.ExpiresAt.Sub(now)
+		if ttl <= 0 || !e.Prefix.IsValid() || c.prefix(e.Prefix.Addr()) != e.Prefix {
+			continue
+		}
+		c.entries.Set(e.Prefix, usecase.GeoLocation{Country: e.Country, Region: e.Region, City: e.City}, ttl)
+		loaded++
+	}
+	log.Info().Int("entries", loaded).Str("path", c.path).Msg("Loaded geo cache")
+	return nil
+}
+
+// Save writes the cache to its file, replacing it in one rename so a crash
+// never leaves a partial file behind.
+func (c *LookupCache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	var entries []cachedGeoEntry
+	c.entries.Each(func(p netip.Prefix, loc usecase.GeoLocation, exp time.Time) {
+		entries = append(entries, cachedGeoEntry{
+			Prefix:    p,
+			Country:   loc.Country,
+			Region:    loc.Region,
+			City:      loc.City,
+			ExpiresAt: exp,
+		})
+	})
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode geo cache: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save geo cache: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save geo cache: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save geo cache: %w", err)
+	}
+	if err := os.Rename(f.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to save geo cache: %w", err)
+	}
+	log.Info().Int("entries", len(entries)).Str("path", c.path).Msg("Saved geo cache")
+	return nil
+}
+
+type cachedResolver struct {
+	cache   *LookupCache
+	lookup  geoLookup
+	timeout time.Duration
+}
+
+// resolve answers from the cache, or waits for a lookup of the prefix that
+// is already running before starting one. The lookup has its own timeout,
+// so the caller that started it giving up does not fail the others.
+func (r *cachedResolver) resolve(ctx context.Context, q usecase.GeoQuery) (usecase.GeoLocation, error) {
+	addr, err := netip.ParseAddr(q.IP)
+	if err != nil {
+		return usecase.GeoLocation{}, nil
+	}
+	addr = addr.Unmap()
+	key := r.cache.prefix(addr)
+
+	if loc, ok := r.cache.entries.Get(key); ok {
+		return loc, nil
+	}
+
+	result := r.cache.flight.DoChan(key.String(), func() (any, error) {
+		// A lookup for the prefix may have finished since the miss above.
+		if loc, ok := r.cache.entries.Get(key); ok {
+			return loc, nil
+		}
+
+		lookupCtx := context.WithoutCancel(ctx)
+		if r.timeout > 0 {
+			var cancel context.CancelFunc
+			lookupCtx, cancel = context.WithTimeout(lookupCtx, r.timeout)
+			defer cancel()
+		}
+		loc, err := r.lookup(lookupCtx, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ttl := r.cache.ttl
+		if loc == (usecase.GeoLocation{}) {
+			ttl = r.cache.negativeTTL
+		}
+		if ttl > 0 {
+			r.cache.entries.Set(key, loc, ttl)
+		}
+		return loc, nil
+	})
+
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return usecase.GeoLocation{}, res.Err
+		}
+		return res.Val.(usecase.GeoLocation), nil
+	case <-ctx.Done():
+		return usecase.GeoLocation{}, ctx.Err()
+	}
+}
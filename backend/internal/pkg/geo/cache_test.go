@@ -0,0 +1,234 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+)
+
+func newTestLookupCache(path string) *LookupCache {
+	return NewLookupCache(&config.Config{
+		GeoCacheSize:        100,
+		GeoCacheTTL:         time.Hour,
+		GeoCacheNegativeTTL: time.Minute,
+		GeoCacheIPv4Prefix:  24,
+		GeoCacheIPv6Prefix:  48,
+		GeoCacheFile:        path,
+	})
+}
+
+// countingLookup answers every address with one location and counts calls.
+type countingLookup struct {
+	calls atomic.Int32
+	loc   usecase.GeoLocation
+	err   error
+}
+
+func (l *countingLookup) lookup(ctx context.Context, addr netip.Addr) (usecase.GeoLocation, error) {
+	l.calls.Add(1)
+	return l.loc, l.err
+}
+
+func TestLookupCachePrefix(t *testing.T) {
+	c := newTestLookupCache("")
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"1.2.3.4", "1.2.3.0/24"},
+		{"1.2.3.255", "1.2.3.0/24"},
+		{"2001:db8:1:2::1", "2001:db8:1::/48"},
+		{"2001:db8:1:ffff::1", "2001:db8:1::/48"},
+	}
+	for _, tt := range tests {
+		if got := c.prefix(netip.MustParseAddr(tt.ip)); got.String() != tt.want {
+			t.Errorf("prefix(%s) = %s, want %s", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCachedResolverSharesPrefix(t *testing.T) {
+	lookup := &countingLookup{loc: usecase.GeoLocation{Country: "AU"}}
+	r := newTestLookupCache("").wrap(lookup.lookup, 0)
+	ctx := context.Background()
+
+	// Mapped IPv4 addresses share the entry of the plain address.
+	for _, ip := range []string{"1.2.3.4", "1.2.3.200", "::ffff:1.2.3.9", "2001:db8:1:2::1", "2001:db8:1:3::1"} {
+		got, err := r.resolve(ctx, usecase.GeoQuery{IP: ip})
+		if err != nil || got.Country != "AU" {
+			t.Fatalf("resolve(%s) = %+v, %v", ip, got, err)
+		}
+	}
+	if n := lookup.calls.Load(); n != 2 {
+		t.Errorf("lookups = %d, want 2, one per prefix", n)
+	}
+
+	if got, _ := r.resolve(ctx, usecase.GeoQuery{IP: "not an ip"}); got != (usecase.GeoLocation{}) {
+		t.Errorf("resolve(not an ip) = %+v, want nothing", got)
+	}
+}
+
+func TestCachedResolverMissesAndErrors(t *testing.T) {
+	c := newTestLookupCache("")
+	ctx := context.Background()
+	q := usecase.GeoQuery{IP: "1.2.3.4"}
+
+	failing := &countingLookup{err: errors.New("rate limited")}
+	if _, err := c.wrap(failing.lookup, 0).resolve(ctx, q); err == nil {
+		t.Fatal("resolve() hid the lookup error")
+	}
+	if c.entries.Len() != 0 {
+		t.Fatal("a failed lookup was cached")
+	}
+
+	// A miss is cached, but for the negative TTL only.
+	missing := &countingLookup{}
+	if _, err := c.wrap(missing.lookup, 0).resolve(ctx, q); err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	var exp time.Time
+	c.entries.Each(func(_ netip.Prefix, _ usecase.GeoLocation, e time.Time) { exp = e })
+	if ttl := time.Until(exp); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("miss cached for %v, want the negative TTL of a minute", ttl)
+	}
+}
+
+// blockingLookup holds every lookup until release is closed.
+type blockingLookup struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (l *blockingLookup) lookup(ctx context.Context, addr netip.Addr) (usecase.GeoLocation, error) {
+	if l.calls.Add(1) == 1 {
+		close(l.started)
+	}
+	select {
+	case <-l.release:
+		return usecase.GeoLocation{Country: "AU"}, nil
+	case <-ctx.Done():
+		return usecase.GeoLocation{}, ctx.Err()
+	}
+}
+
+func TestCachedResolverCoalesces(t *testing.T) {
+	lookup := &blockingLookup{started: make(chan struct{}), release: make(chan struct{})}
+	r := newTestLookupCache("").wrap(lookup.lookup, time.Minute)
+
+	// The first caller gives up while its lookup is running; the callers
+	// waiting on that lookup still get the answer.
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := r.resolve(firstCtx, usecase.GeoQuery{IP: "1.2.3.1"})
+		firstErr <- err
+	}()
+	<-lookup.started
+
+	var wg sync.WaitGroup
+	results := make([]usecase.GeoLocation, 10)
+	errs := make([]error, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = r.resolve(context.Background(), usecase.GeoQuery{IP: "1.2.3.2"})
+		}()
+	}
+
+	cancelFirst()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("first resolve() error = %v, want context.Canceled", err)
+	}
+	// Let the waiters join the flight before it lands.
+	time.Sleep(10 * time.Millisecond)
+	close(lookup.release)
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil || results[i].Country != "AU" {
+			t.Errorf("resolve() = %+v, %v; want AU", results[i], errs[i])
+		}
+	}
+	if n := lookup.calls.Load(); n != 1 {
+		t.Errorf("lookups = %d, want 1 shared by every caller", n)
+	}
+}
+
+func TestCachedResolverTimeout(t *testing.T) {
+	lookup := &blockingLookup{started: make(chan struct{}), release: make(chan struct{})}
+	r := newTestLookupCache("").wrap(lookup.lookup, 10*time.Millisecond)
+
+	_, err := r.resolve(context.Background(), usecase.GeoQuery{IP: "1.2.3.4"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("resolve() error = %v, want the lookup to time out", err)
+	}
+}
+
+func TestLookupCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo-cache.json")
+	c := newTestLookupCache(path)
+
+	v4 := netip.MustParsePrefix("1.2.3.0/24")
+	v6 := netip.MustParsePrefix("2001:db8:1::/48")
+	c.entries.Set(v4, usecase.GeoLocation{Country: "AU", Region: "NSW", City: "Sydney"}, time.Hour)
+	c.entries.Set(v6, usecase.GeoLocation{}, time.Hour)
+	c.entries.Set(netip.MustParsePrefix("5.6.7.0/24"), usecase.GeoLocation{Country: "NZ"}, time.Millisecond)
+	// Entries of another prefix length are dropped on load.
+	c.entries.Set(netip.MustParsePrefix("9.9.0.0/16"), usecase.GeoLocation{Country: "US"}, time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := newTestLookupCache(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if n := loaded.entries.Len(); n != 2 {
+		t.Errorf("loaded %d entries, want 2", n)
+	}
+	if got, ok := loaded.entries.Get(v4); !ok || got.City != "Sydney" || got.Region != "NSW" {
+		t.Errorf("entry for %s = %+v, %v", v4, got, ok)
+	}
+	if got, ok := loaded.entries.Get(v6); !ok || got != (usecase.GeoLocation{}) {
+		t.Errorf("miss for %s = %+v, %v; want it kept", v6, got, ok)
+	}
+
+	// The lookup is skipped for loaded entries.
+	lookup := &countingLookup{loc: usecase.GeoLocation{Country: "XX"}}
+	got, _ := loaded.wrap(lookup.lookup, 0).resolve(context.Background(), usecase.GeoQuery{IP: "1.2.3.4"})
+	if got.City != "Sydney" || lookup.calls.Load() != 0 {
+		t.Errorf("resolve() = %+v after %d lookups, want the loaded entry", got, lookup.calls.Load())
+	}
+}
+
+func TestLookupCacheLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := newTestLookupCache(filepath.Join(dir, "missing.json")).Load(); err != nil {
+		t.Errorf("Load() error = %v for a missing file", err)
+	}
+	if err := newTestLookupCache("").Save(); err != nil {
+		t.Errorf("Save() error = %v without a file", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestLookupCache(corrupt).Load(); err == nil {
+		t.Error("Load() succeeded for a corrupt file")
+	}
+}
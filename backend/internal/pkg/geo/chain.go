@@ -0,0 +1,152 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"qrcodegen/internal/usecase"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	sourceHeader = "header"
+	sourceMMDB   = "mmdb"
+	sourceIPInfo = "ipinfo"
+)
+
+// geoStatsInterval is how often the chain logs the counters of its steps.
+const geoStatsInterval = 10 * time.Minute
+
+// geoStep is one source of locations. An empty location is a miss; errors,
+// timeouts included, are misses that are counted apart. Steps that block
+// must return when ctx is done.
+type geoStep interface {
+	resolve(ctx context.Context, q usecase.GeoQuery) (usecase.GeoLocation, error)
+}
+
+// chainStep is a step with its name, which becomes the source of its
+// answers, and its timeout. An answer that comes after the timeout is
+// counted as a timeout and not used.
+type chainStep struct {
+	name    string
+	step    geoStep
+	timeout time.Duration
+
+	hits     atomic.Int64
+	misses   atomic.Int64
+	timeouts atomic.Int64
+	failures atomic.Int64
+
+	// reported holds the counters as of the last stats log.
+	reported StepStats
+}
+
+// StepStats are the counters of one step of the chain since startup.
+type StepStats struct {
+	Source   string
+	Hits     int64
+	Misses   int64
+	Timeouts int64
+	Errors   int64
+}
+
+// chainResolver asks its steps in order and returns the first answer.
+type chainResolver struct {
+	steps     []*chainStep
+	nextStats atomic.Int64
+	statsMu   sync.Mutex
+}
+
+func newChainResolver(steps []*chainStep) *chainResolver {
+	r := &chainResolver{steps: steps}
+	r.nextStats.Store(time.Now().Add(geoStatsInterval).UnixNano())
+	return r
+}
+
+func (r *chainResolver) Resolve(ctx context.Context, q usecase.GeoQuery) (usecase.GeoLocation, bool) {
+	r.checkStats()
+
+	for _, s := range r.steps {
+		if loc, ok := s.resolve(ctx, q); ok {
+			loc.Source = s.name
+			return loc, true
+		}
+	}
+	return usecase.GeoLocation{}, false
+}
+
+// Stats returns the counters of the steps in chain order.
+func (r *chainResolver) Stats() []StepStats {
+	stats := make([]StepStats, len(r.steps))
+	for i, s := range r.steps {
+		stats[i] = s.stats()
+	}
+	return stats
+}
+
+func (s *chainStep) resolve(ctx context.Context, q usecase.GeoQuery) (usecase.GeoLocation, bool) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	loc, err := s.step.resolve(ctx, q)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		s.timeouts.Add(1)
+	case err != nil:
+		s.failures.Add(1)
+		log.Debug().Err(err).Str("step", s.name).Msg("Geo lookup failed")
+	case loc == (usecase.GeoLocation{}):
+		s.misses.Add(1)
+	default:
+		s.hits.Add(1)
+		return loc, true
+	}
+	return usecase.GeoLocation{}, false
+}
+
+func (s *chainStep) stats() StepStats {
+	return StepStats{
+		Source:   s.name,
+		Hits:     s.hits.Load(),
+		Misses:   s.misses.Load(),
+		Timeouts: s.timeouts.Load(),
+		Errors:   s.failures.Load(),
+	}
+}
+
+// checkStats logs the counters of every step that was used since the last
+// time, at most once per interval.
+func (r *chainResolver) checkStats() {
+	now := time.Now().UnixNano()
+	next := r.nextStats.Load()
+	if now < next || !r.nextStats.CompareAndSwap(next, now+int64(geoStatsInterval)) {
+		return
+	}
+
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	for _, s := range r.steps {
+		stats := s.stats()
+		if stats == s.reported {
+			continue
+		}
+		log.Info().
+			Str("step", s.name).
+			Int64("hits", stats.Hits-s.reported.Hits).
+			Int64("misses", stats.Misses-s.reported.Misses).
+			Int64("timeouts", stats.Timeouts-s.reported.Timeouts).
+			Int64("errors", stats.Errors-s.reported.Errors).
+			Msg("Geo resolver stats")
+		s.reported = stats
+	}
+}
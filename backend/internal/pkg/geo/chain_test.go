@@ -0,0 +1,173 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+)
+
+// stubStep answers with loc and err, after blocking until ctx is done when
+// block is set. It records the order in which steps are asked.
+type stubStep struct {
+	name  string
+	loc   usecase.GeoLocation
+	err   error
+	block bool
+	asked *[]string
+}
+
+func (s *stubStep) resolve(ctx context.Context, q usecase.GeoQuery) (usecase.GeoLocation, error) {
+	*s.asked = append(*s.asked, s.name)
+	if s.block {
+		<-ctx.Done()
+		return usecase.GeoLocation{}, ctx.Err()
+	}
+	return s.loc, s.err
+}
+
+// lateStep answers after its deadline without watching ctx.
+type lateStep struct{}
+
+func (lateStep) resolve(ctx context.Context, q usecase.GeoQuery) (usecase.GeoLocation, error) {
+	<-ctx.Done()
+	return usecase.GeoLocation{Country: "FR"}, nil
+}
+
+func TestChainResolver(t *testing.T) {
+	de := usecase.GeoLocation{Country: "DE"}
+	us := usecase.GeoLocation{Country: "US", City: "Boston"}
+
+	tests := []struct {
+		name      string
+		steps     []stubStep
+		want      usecase.GeoLocation
+		wantOK    bool
+		wantAsked []string
+		wantStats []StepStats
+	}{
+		{
+			name:      "first answer wins",
+			steps:     []stubStep{{name: "a", loc: de}, {name: "b", loc: us}},
+			want:      usecase.GeoLocation{Country: "DE", Source: "a"},
+			wantOK:    true,
+			wantAsked: []string{"a"},
+			wantStats: []StepStats{{Source: "a", Hits: 1}, {Source: "b"}},
+		},
+		{
+			name:      "miss falls through",
+			steps:     []stubStep{{name: "a"}, {name: "b", loc: us}},
+			want:      usecase.GeoLocation{Country: "US", City: "Boston", Source: "b"},
+			wantOK:    true,
+			wantAsked: []string{"a", "b"},
+			wantStats: []StepStats{{Source: "a", Misses: 1}, {Source: "b", Hits: 1}},
+		},
+		{
+			name:      "error falls through",
+			steps:     []stubStep{{name: "a", loc: de, err: errors.New("boom")}, {name: "b", loc: us}},
+			want:      usecase.GeoLocation{Country: "US", City: "Boston", Source: "b"},
+			wantOK:    true,
+			wantAsked: []string{"a", "b"},
+			wantStats: []StepStats{{Source: "a", Errors: 1}, {Source: "b", Hits: 1}},
+		},
+		{
+			name:      "timeout falls through",
+			steps:     []stubStep{{name: "a", block: true}, {name: "b", loc: us}},
+			want:      usecase.GeoLocation{Country: "US", City: "Boston", Source: "b"},
+			wantOK:    true,
+			wantAsked: []string{"a", "b"},
+			wantStats: []StepStats{{Source: "a", Timeouts: 1}, {Source: "b", Hits: 1}},
+		},
+		{
+			name:      "no answer",
+			steps:     []stubStep{{name: "a"}, {name: "b"}},
+			wantAsked: []string{"a", "b"},
+			wantStats: []StepStats{{Source: "a", Misses: 1}, {Source: "b", Misses: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var asked []string
+			var steps []*chainStep
+			for i := range tt.steps {
+				s := &tt.steps[i]
+				s.asked = &asked
+				steps = append(steps, &chainStep{name: s.name, step: s, timeout: 10 * time.Millisecond})
+			}
+			r := newChainResolver(steps)
+
+			got, ok := r.Resolve(context.Background(), usecase.GeoQuery{IP: "203.0.113.7"})
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Resolve() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if !slices.Equal(asked, tt.wantAsked) {
+				t.Errorf("asked %v, want %v", asked, tt.wantAsked)
+			}
+			if stats := r.Stats(); !slices.Equal(stats, tt.wantStats) {
+				t.Errorf("Stats() = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestChainStepLateAnswer(t *testing.T) {
+	r := newChainResolver([]*chainStep{{name: "late", step: lateStep{}, timeout: time.Millisecond}})
+
+	if loc, ok := r.Resolve(context.Background(), usecase.GeoQuery{}); ok {
+		t.Errorf("Resolve() = %+v, want the answer after the timeout dropped", loc)
+	}
+	if stats := r.Stats(); stats[0].Timeouts != 1 || stats[0].Hits != 0 {
+		t.Errorf("Stats() = %+v, want one timeout", stats)
+	}
+}
+
+func TestNewGeoResolverOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeMMDB(t, path, []mmdbNetwork{{"1.2.3.0/24", cityRecord("AU", "Sydney")}})
+
+	cfg := &config.Config{
+		GeoCountryHeader:  "CF-IPCountry",
+		GeoHeaderTimeout:  time.Second,
+		GeoIPDBPath:       path,
+		GeoIPTimeout:      time.Second,
+		IPInfoToken:       "token",
+		IPInfoHTTPTimeout: time.Second,
+		GeoCacheSize:      10,
+	}
+	resolver, err := NewGeoResolver(cfg, NewLookupCache(cfg))
+	if err != nil {
+		t.Fatalf("NewGeoResolver() error = %v", err)
+	}
+	chain, ok := resolver.(*chainResolver)
+	if !ok {
+		t.Fatalf("NewGeoResolver() = %T, want a chain", resolver)
+	}
+
+	var sources []string
+	for _, s := range chain.steps {
+		sources = append(sources, s.name)
+		if s.timeout != time.Second {
+			t.Errorf("step %s timeout = %v, want the configured 1s", s.name, s.timeout)
+		}
+	}
+	if want := []string{sourceHeader, sourceMMDB, sourceIPInfo}; !slices.Equal(sources, want) {
+		t.Errorf("steps = %v, want %v", sources, want)
+	}
+
+	// The header answers before the database is asked.
+	loc, ok := resolver.Resolve(context.Background(), usecase.GeoQuery{IP: "1.2.3.4", Headers: http.Header{"Cf-Ipcountry": {"NZ"}}})
+	if !ok || loc.Country != "NZ" || loc.Source != sourceHeader {
+		t.Errorf("Resolve() with a header = %+v, %v; want NZ from the header", loc, ok)
+	}
+	loc, ok = resolver.Resolve(context.Background(), usecase.GeoQuery{IP: "1.2.3.4"})
+	if !ok || loc.Country != "AU" || loc.Source != sourceMMDB {
+		t.Errorf("Resolve() without a header = %+v, %v; want AU from the database", loc, ok)
+	}
+}
@@ -0,0 +1,49 @@
+package geo
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"qrcodegen/internal/usecase"
+)
+
+// headerResolver reads the location a CDN already put in the request, such
+// as Cloudflare's CF-IPCountry. Requests only carry headers when they came
+// through a trusted proxy.
+type headerResolver struct {
+	country string
+	region  string
+	city    string
+}
+
+func (r *headerResolver) resolve(_ context.Context, q usecase.GeoQuery) (usecase.GeoLocation, error) {
+	if q.Headers == nil {
+		return usecase.GeoLocation{}, nil
+	}
+
+	var loc usecase.GeoLocation
+	if r.country != "" {
+		// Cloudflare sends XX for unknown addresses and T1 for Tor.
+		country := strings.ToUpper(strings.TrimSpace(q.Headers.Get(r.country)))
+		if len(country) == 2 && country != "XX" && country != "T1" {
+			loc.Country = country
+		}
+	}
+	if r.region != "" {
+		loc.Region = headerText(q.Headers.Get(r.region))
+	}
+	if r.city != "" {
+		loc.City = headerText(q.Headers.Get(r.city))
+	}
+	return loc, nil
+}
+
+// headerText decodes names that CDNs such as Vercel percent-encode.
+func headerText(v string) string {
+	v = strings.TrimSpace(v)
+	if decoded, err := url.PathUnescape(v); err == nil {
+		return decoded
+	}
+	return v
+}
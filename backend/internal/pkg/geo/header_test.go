@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"qrcodegen/internal/usecase"
+)
+
+func TestHeaderResolver(t *testing.T) {
+	cloudflare := &headerResolver{country: "CF-IPCountry", region: "CF-Region", city: "CF-IPCity"}
+
+	tests := []struct {
+		name     string
+		resolver *headerResolver
+		headers  http.Header
+		want     usecase.GeoLocation
+	}{
+		{
+			name:     "all headers",
+			resolver: cloudflare,
+			headers:  http.Header{"Cf-Ipcountry": {"de"}, "Cf-Region": {"Bavaria"}, "Cf-Ipcity": {"Munich"}},
+			want:     usecase.GeoLocation{Country: "DE", Region: "Bavaria", City: "Munich"},
+		},
+		{
+			name:     "percent-encoded names",
+			resolver: &headerResolver{country: "X-Vercel-IP-Country", city: "X-Vercel-IP-City"},
+			headers:  http.Header{"X-Vercel-Ip-Country": {"FR"}, "X-Vercel-Ip-City": {"Saint-%C3%89tienne"}},
+			want:     usecase.GeoLocation{Country: "FR", City: "Saint-Étienne"},
+		},
+		{
+			name:     "unknown country",
+			resolver: cloudflare,
+			headers:  http.Header{"Cf-Ipcountry": {"XX"}},
+		},
+		{
+			name:     "tor",
+			resolver: cloudflare,
+			headers:  http.Header{"Cf-Ipcountry": {"T1"}},
+		},
+		{
+			name:     "not a country code",
+			resolver: cloudflare,
+			headers:  http.Header{"Cf-Ipcountry": {"Germany"}},
+		},
+		{
+			name:     "unconfigured header",
+			resolver: &headerResolver{country: "CF-IPCountry"},
+			headers:  http.Header{"Cf-Ipcountry": {"DE"}, "Cf-Ipcity": {"Munich"}},
+			want:     usecase.GeoLocation{Country: "DE"},
+		},
+		{
+			name:     "no headers",
+			resolver: cloudflare,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.resolver.resolve(context.Background(), usecase.GeoQuery{IP: "203.0.113.7", Headers: tt.headers})
+			if err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"qrcodegen/internal/usecase"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/rs/zerolog/log"
+)
+
+// mmdbResolver looks addresses up in a local MaxMind or DB-IP database, so
+// resolving never leaves the host. Country databases only fill the country.
+type mmdbResolver struct {
+	path           string
+	lang           string
+	reloadInterval time.Duration
+
+	db        atomic.Pointer[mmdbFile]
+	nextCheck atomic.Int64
+	reloading atomic.Bool
+}
+
+// mmdbFile is a loaded database together with the file version it came
+// from.
+type mmdbFile struct {
+	reader  *maxminddb.Reader
+	modTime time.Time
+	size    int64
+}
+
+func newMMDBResolver(path, lang string, reloadInterval time.Duration) (*mmdbResolver, error) {
+	r := &mmdbResolver{path: path, lang: lang, reloadInterval: reloadInterval}
+	db, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.db.Store(db)
+	r.nextCheck.Store(time.Now().Add(reloadInterval).UnixNano())
+
+	log.Info().
+		Str("path", path).
+		Str("type", db.reader.Metadata.DatabaseType).
+		Time("built_at", time.Unix(int64(db.reader.Metadata.BuildEpoch), 0)).
+		Msg("Using GeoIP database for Geo resolving")
+	return r, nil
+}
+
+// resolve looks up in memory and does not block, so it ignores ctx.
+func (r *mmdbResolver) resolve(_ context.Context, q usecase.GeoQuery) (usecase.GeoLocation, error) {
+	r.checkReload()
+
+	addr, err := netip.ParseAddr(q.IP)
+	if err != nil {
+		return usecase.GeoLocation{}, nil
+	}
+
+	var record mmdbRecord
+	if err := r.db.Load().reader.Lookup(addr.Unmap().AsSlice(), &record); err != nil {
+		return usecase.GeoLocation{}, err
+	}
+	return record.location(r.lang), nil
+}
+
+// checkReload starts a reload in the background at most once per reload
+// interval, so lookups never wait for the file system.
+func (r *mmdbResolver) checkReload() {
+	if r.reloadInterval <= 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	next := r.nextCheck.Load()
+	if now < next || !r.nextCheck.CompareAndSwap(next, now+int64(r.reloadInterval)) {
+		return
+	}
+	if !r.reloading.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer r.reloading.Store(false)
+		r.reload()
+	}()
+}
+
+// reload swaps in the database file if it was replaced. A file that fails
+// to load, for example because it is still being copied, leaves the current
+// database in use and is tried again after the next interval; replacing the
+// file with a rename avoids that.
+func (r *mmdbResolver) reload() {
+	current := r.db.Load()
+	info, err := os.Stat(r.path)
+	if err != nil {
+		log.Error().Err(err).Str("path", r.path).Msg("Failed to check GeoIP database")
+		return
+	}
+	if info.ModTime().Equal(current.modTime) && info.Size() == current.size {
+		return
+	}
+
+	db, err := r.load()
+	if err != nil {
+		log.Error().Err(err).Str("path", r.path).Msg("Failed to reload GeoIP database, keeping the current one")
+		return
+	}
+	r.db.Store(db)
+	log.Info().
+		Str("path", r.path).
+		Time("built_at", time.Unix(int64(db.reader.Metadata.BuildEpoch), 0)).
+		Msg("Reloaded GeoIP database")
+}
+
+func (r *mmdbResolver) load() (*mmdbFile, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	// Reading the file instead of mapping it lets a replaced database be
+	// dropped without closing it under lookups still using it.
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	reader, err := maxminddb.FromBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	return &mmdbFile{reader: reader, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// mmdbRecord is the part of the GeoIP2/GeoLite2 record layout, which DB-IP
+// databases share, that a location is read from.
+type mmdbRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// location reads names in lang, falling back to English when lang is
+// missing.
+func (rec *mmdbRecord) location(lang string) usecase.GeoLocation {
+	loc := usecase.GeoLocation{Country: rec.Country.ISOCode}
+	if loc.Country == "" {
+		loc.Country = rec.RegisteredCountry.ISOCode
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = mmdbName(rec.Subdivisions[0].Names, lang)
+	}
+	loc.City = mmdbName(rec.City.Names, lang)
+	return loc
+}
+
+func mmdbName(names map[string]string, lang string) string {
+	if name := names[lang]; name != "" {
+		return name
+	}
+	return names["en"]
+}
@@ -0,0 +1,266 @@
+package geo
+
+import (
+	"context"
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"qrcodegen/internal/usecase"
+)
+
+// The tests write small databases instead of shipping binary fixtures. The
+// writer covers only what GeoIP2 city records need: strings, unsigned
+// integers, maps and arrays in an IPv6 tree with 24-bit records.
+
+const (
+	mmdbTypeString = 2
+	mmdbTypeMap    = 7
+	mmdbTypeUint64 = 9
+	mmdbTypeArray  = 11
+)
+
+func encodeMMDB(v any) []byte {
+	switch v := v.(type) {
+	case string:
+		return append(encodeMMDBControl(mmdbTypeString, len(v)), v...)
+	case uint64:
+		b := binary.BigEndian.AppendUint64(nil, v)
+		for len(b) > 0 && b[0] == 0 {
+			b = b[1:]
+		}
+		return append(encodeMMDBControl(mmdbTypeUint64, len(b)), b...)
+	case []any:
+		b := encodeMMDBControl(mmdbTypeArray, len(v))
+		for _, e := range v {
+			b = append(b, encodeMMDB(e)...)
+		}
+		return b
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		b := encodeMMDBControl(mmdbTypeMap, len(v))
+		for _, k := range keys {
+			b = append(b, encodeMMDB(k)...)
+			b = append(b, encodeMMDB(v[k])...)
+		}
+		return b
+	}
+	panic("unsupported value")
+}
+
+// encodeMMDBControl encodes a type and a size below 285.
+func encodeMMDBControl(typ, size int) []byte {
+	b := []byte{byte(size)}
+	if size >= 29 {
+		b = []byte{29, byte(size - 29)}
+	}
+	if typ > 7 {
+		return append([]byte{b[0], byte(typ - 7)}, b[1:]...)
+	}
+	b[0] |= byte(typ << 5)
+	return b
+}
+
+type mmdbNetwork struct {
+	prefix string
+	record map[string]any
+}
+
+// buildMMDB writes a database mapping each network to its record.
+func buildMMDB(t *testing.T, networks []mmdbNetwork) []byte {
+	t.Helper()
+
+	// Children are another node (>= 0), nothing (-1) or a record (-2 - i).
+	nodes := [][2]int{{-1, -1}}
+	for i, n := range networks {
+		prefix := netip.MustParsePrefix(n.prefix)
+		addr, bits := prefix.Addr().As16(), prefix.Bits()
+		if prefix.Addr().Is4() {
+			// IPv4 lives under ::/96 in an IPv6 tree.
+			addr, bits = [16]byte{}, bits+96
+			v4 := prefix.Addr().As4()
+			copy(addr[12:], v4[:])
+		}
+
+		node := 0
+		for depth := range bits {
+			bit := (addr[depth/8] >> (7 - depth%8)) & 1
+			if depth == bits-1 {
+				nodes[node][bit] = -2 - i
+				break
+			}
+			next := nodes[node][bit]
+			if next < 0 {
+				nodes = append(nodes, [2]int{-1, -1})
+				next = len(nodes) - 1
+				nodes[node][bit] = next
+			}
+			node = next
+		}
+	}
+
+	var data []byte
+	offsets := make([]int, len(networks))
+	for i, n := range networks {
+		offsets[i] = len(data)
+		data = append(data, encodeMMDB(n.record)...)
+	}
+
+	const separator = 16
+	var tree []byte
+	for _, children := range nodes {
+		for _, child := range children {
+			rec := child
+			switch {
+			case child == -1:
+				rec = len(nodes)
+			case child < -1:
+				rec = len(nodes) + separator + offsets[-2-child]
+			}
+			tree = append(tree, byte(rec>>16), byte(rec>>8), byte(rec))
+		}
+	}
+
+	meta := encodeMMDB(map[string]any{
+		"binary_format_major_version": uint64(2),
+		"binary_format_minor_version": uint64(0),
+		"build_epoch":                 uint64(1700000000),
+		"database_type":               "Test-City",
+		"description":                 map[string]any{"en": "Test database"},
+		"ip_version":                  uint64(6),
+		"languages":                   []any{"en", "de"},
+		"node_count":                  uint64(len(nodes)),
+		"record_size":                 uint64(24),
+	})
+
+	b := append(tree, make([]byte, separator)...)
+	b = append(b, data...)
+	b = append(b, "\xAB\xCD\xEFMaxMind.com"...)
+	return append(b, meta...)
+}
+
+func cityRecord(iso, city string) map[string]any {
+	return map[string]any{
+		"country": map[string]any{"iso_code": iso},
+		"city":    map[string]any{"names": map[string]any{"en": city}},
+	}
+}
+
+func writeMMDB(t *testing.T, path string, networks []mmdbNetwork) {
+	t.Helper()
+	// Replace the file with a rename, as the reload expects.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buildMMDB(t, networks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMMDBResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeMMDB(t, path, []mmdbNetwork{
+		{"1.2.3.0/24", cityRecord("AU", "Sydney")},
+		{"2001:db8::/32", cityRecord("DE", "Berlin")},
+		{"81.2.69.0/24", map[string]any{
+			"registered_country": map[string]any{"iso_code": "GB"},
+			"subdivisions": []any{
+				map[string]any{"names": map[string]any{"en": "England", "de": "England"}},
+				map[string]any{"names": map[string]any{"en": "Greater London"}},
+			},
+			"city": map[string]any{"names": map[string]any{"en": "London", "de": "London (DE)"}},
+		}},
+	})
+
+	tests := []struct {
+		ip   string
+		lang string
+		want usecase.GeoLocation
+	}{
+		{"1.2.3.4", "en", usecase.GeoLocation{Country: "AU", City: "Sydney"}},
+		{"::ffff:1.2.3.4", "en", usecase.GeoLocation{Country: "AU", City: "Sydney"}},
+		{"2001:db8::1", "en", usecase.GeoLocation{Country: "DE", City: "Berlin"}},
+		// The registered country stands in for a missing country, the
+		// first subdivision is the region, and names fall back to English.
+		{"81.2.69.1", "de", usecase.GeoLocation{Country: "GB", Region: "England", City: "London (DE)"}},
+		{"81.2.69.1", "fr", usecase.GeoLocation{Country: "GB", Region: "England", City: "London"}},
+		{"8.8.8.8", "en", usecase.GeoLocation{}},
+		{"not an ip", "en", usecase.GeoLocation{}},
+	}
+
+	for _, tt := range tests {
+		r, err := newMMDBResolver(path, tt.lang, 0)
+		if err != nil {
+			t.Fatalf("newMMDBResolver() error = %v", err)
+		}
+		got, err := r.resolve(context.Background(), usecase.GeoQuery{IP: tt.ip})
+		if err != nil {
+			t.Errorf("resolve(%s) error = %v", tt.ip, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolve(%s, %s) = %+v, want %+v", tt.ip, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestMMDBResolverOpenFails(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := newMMDBResolver(filepath.Join(dir, "missing.mmdb"), "en", 0); err == nil {
+		t.Error("newMMDBResolver() succeeded for a missing file")
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.mmdb")
+	if err := os.WriteFile(corrupt, []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newMMDBResolver(corrupt, "en", 0); err == nil {
+		t.Error("newMMDBResolver() succeeded for a corrupt file")
+	}
+}
+
+func TestMMDBResolverReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeMMDB(t, path, []mmdbNetwork{{"1.2.3.0/24", cityRecord("AU", "Sydney")}})
+
+	r, err := newMMDBResolver(path, "en", time.Millisecond)
+	if err != nil {
+		t.Fatalf("newMMDBResolver() error = %v", err)
+	}
+	q := usecase.GeoQuery{IP: "1.2.3.4"}
+
+	// A corrupt replacement keeps the current database in use.
+	if err := os.WriteFile(path, []byte("half copied"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	r.reload()
+	if got, _ := r.resolve(context.Background(), q); got.City != "Sydney" {
+		t.Fatalf("city = %q after a corrupt replacement, want Sydney", got.City)
+	}
+
+	writeMMDB(t, path, []mmdbNetwork{{"1.2.3.0/24", cityRecord("NZ", "Auckland")}})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := r.resolve(context.Background(), q)
+		if err != nil {
+			t.Fatalf("resolve() error = %v", err)
+		}
+		if got.City == "Auckland" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("city = %q, want the replaced database to be loaded", got.City)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
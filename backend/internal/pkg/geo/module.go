@@ -1,21 +1,50 @@
 package geo
 
 import (
+	"context"
+
 	"qrcodegen/config"
 	"qrcodegen/internal/usecase"
 
 	"github.com/rs/zerolog/log"
 )
 
-func NewGeoResolver(cfg *config.Config) (usecase.GeoResolver, error) {
+// NewGeoResolver chains the configured sources: location headers set by a
+// trusted CDN, a local database, then IPinfo. The first one that knows an
+// address answers, so cheap sources spare the slower ones.
+func NewGeoResolver(cfg *config.Config, cache *LookupCache) (usecase.GeoResolver, error) {
+	var steps []*chainStep
+
+	if cfg.GeoCountryHeader != "" || cfg.GeoRegionHeader != "" || cfg.GeoCityHeader != "" {
+		log.Info().Msg("Using trusted proxy headers for Geo resolving")
+		steps = append(steps, &chainStep{name: sourceHeader, timeout: cfg.GeoHeaderTimeout, step: &headerResolver{
+			country: cfg.GeoCountryHeader,
+			region:  cfg.GeoRegionHeader,
+			city:    cfg.GeoCityHeader,
+		}})
+	}
+	if cfg.GeoIPDBPath != "" {
+		resolver, err := newMMDBResolver(cfg.GeoIPDBPath, cfg.GeoIPLanguage, cfg.GeoIPReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, &chainStep{name: sourceMMDB, step: resolver, timeout: cfg.GeoIPTimeout})
+	}
 	if cfg.IPInfoToken != "" {
 		log.Info().Msg("Using IPinfo Lite for Geo resolving")
-		return newIPInfoLite(cfg.IPInfoToken, cfg.IPInfoHTTPTimeout), nil
+		client := newIPInfoLite(cfg.IPInfoToken, cfg.IPInfoHTTPTimeout)
+		steps = append(steps, &chainStep{name: sourceIPInfo, step: cache.wrap(client.lookup, cfg.IPInfoHTTPTimeout), timeout: cfg.IPInfoHTTPTimeout})
 	}
-	log.Warn().Msg("No IPINFO_TOKEN; geo resolving disabled.")
-	return &noOpResolver{}, nil
+
+	if len(steps) == 0 {
+		log.Warn().Msg("No geo headers, GEOIP_DB_PATH or IPINFO_TOKEN; geo resolving disabled.")
+		return &noOpResolver{}, nil
+	}
+	return newChainResolver(steps), nil
 }
 
 type noOpResolver struct{}
 
-func (r *noOpResolver) Resolve(ip string) (string, string, bool) { return "", "", false }
+func (r *noOpResolver) Resolve(ctx context.Context, q usecase.GeoQuery) (usecase.GeoLocation, bool) {
+	return usecase.GeoLocation{}, false
+}
@@ -0,0 +1,27 @@
+package hashid
+
+import (
+	"fmt"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/repository/postgres"
+	"qrcodegen/internal/usecase"
+
+	"github.com/rs/zerolog/log"
+)
+
+func NewHashGenerator(cfg *config.Config, repo postgres.Repository) (usecase.HashGenerator, error) {
+	switch cfg.HashStrategy {
+	case "random", "":
+		log.Info().Msgf("Generating random base62 link hashes of length %d", cfg.HashLength)
+		return newRandomGenerator(cfg.HashLength)
+	case "sqids":
+		log.Info().Msgf("Generating sqids link hashes of length %d or more", cfg.HashLength)
+		return newSqidsGenerator(cfg.HashAlphabet, cfg.HashLength, repo.NextLinkHashSequence)
+	case "words":
+		log.Info().Msgf("Generating %d-word link hashes", cfg.HashWordCount)
+		return newWordsGenerator(cfg.HashWordCount)
+	default:
+		return nil, fmt.Errorf("unknown hash strategy %q", cfg.HashStrategy)
+	}
+}
@@ -0,0 +1,46 @@
+package hashid
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// randomGenerator draws every character uniformly from the base62 alphabet.
+type randomGenerator struct {
+	length int
+}
+
+func newRandomGenerator(length int) (*randomGenerator, error) {
+	if length < 4 || length > 32 {
+		return nil, errors.New("random hash length must be between 4 and 32")
+	}
+	return &randomGenerator{length: length}, nil
+}
+
+func (g *randomGenerator) Generate(context.Context) (string, error) {
+	// Bytes at or above the largest multiple of 62 are rejected so that
+	// the modulo does not favour the start of the alphabet.
+	const limit = 256 - 256%len(base62Alphabet)
+
+	out := make([]byte, 0, g.length)
+	buf := make([]byte, g.length*2)
+	for len(out) < g.length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			out = append(out, base62Alphabet[int(b)%len(base62Alphabet)])
+			if len(out) == g.length {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
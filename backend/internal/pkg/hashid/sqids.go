@@ -0,0 +1,114 @@
+package hashid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const defaultSqidsAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// sqidsGenerator encodes numbers from a database sequence the way Sqids
+// encodes a single number: short, unique by construction and not obviously
+// sequential. Shuffling the alphabet makes the ids specific to this
+// deployment.
+type sqidsGenerator struct {
+	alphabet  []byte
+	minLength int
+	next      func(ctx context.Context) (int64, error)
+}
+
+func newSqidsGenerator(alphabet string, minLength int, next func(ctx context.Context) (int64, error)) (*sqidsGenerator, error) {
+	if alphabet == "" {
+		alphabet = defaultSqidsAlphabet
+	}
+	if len(alphabet) < 5 {
+		return nil, errors.New("sqids alphabet must have at least 5 characters")
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if !isHashChar(c) {
+			return nil, fmt.Errorf("sqids alphabet contains %q; only letters and digits are allowed", c)
+		}
+		if strings.IndexByte(alphabet[i+1:], c) >= 0 {
+			return nil, fmt.Errorf("sqids alphabet contains %q more than once", c)
+		}
+	}
+	if minLength < 0 || minLength > 32 {
+		return nil, errors.New("sqids minimum length must be between 0 and 32")
+	}
+
+	return &sqidsGenerator{
+		alphabet:  shuffle([]byte(alphabet)),
+		minLength: minLength,
+		next:      next,
+	}, nil
+}
+
+func (g *sqidsGenerator) Generate(ctx context.Context) (string, error) {
+	n, err := g.next(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get next hash number: %w", err)
+	}
+	if n < 0 {
+		return "", errors.New("hash number must not be negative")
+	}
+	return g.encode(uint64(n)), nil
+}
+
+func (g *sqidsGenerator) encode(n uint64) string {
+	size := len(g.alphabet)
+	offset := (int(g.alphabet[n%uint64(size)]) + 1) % size
+
+	alphabet := make([]byte, 0, size)
+	alphabet = append(alphabet, g.alphabet[offset:]...)
+	alphabet = append(alphabet, g.alphabet[:offset]...)
+
+	prefix := alphabet[0]
+	reverse(alphabet)
+
+	id := []byte{prefix}
+	id = append(id, toID(n, alphabet[1:])...)
+
+	if len(id) < g.minLength {
+		id = append(id, alphabet[0])
+		for len(id) < g.minLength {
+			alphabet = shuffle(alphabet)
+			id = append(id, alphabet[:min(g.minLength-len(id), size)]...)
+		}
+	}
+	return string(id)
+}
+
+func toID(n uint64, alphabet []byte) []byte {
+	size := uint64(len(alphabet))
+	var id []byte
+	for {
+		id = append([]byte{alphabet[n%size]}, id...)
+		n /= size
+		if n == 0 {
+			return id
+		}
+	}
+}
+
+// shuffle is the deterministic Sqids alphabet shuffle.
+func shuffle(alphabet []byte) []byte {
+	chars := append([]byte(nil), alphabet...)
+	for i, j := 0, len(chars)-1; j > 0; i, j = i+1, j-1 {
+		r := (i*j + int(chars[i]) + int(chars[j])) % len(chars)
+		chars[i], chars[r] = chars[r], chars[i]
+	}
+	return chars
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+func isHashChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,75 @@
+package hashid
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// wordsGenerator builds readable hashes such as "brave-otter-maple" from a
+// fixed list of short words. The words are lowercase letters of at most six
+// characters, so four of them joined by "-" stay within the 32 characters
+// and the [A-Za-z0-9_-] set that bulk import accepts for hashes, and a hash
+// is always a single path segment for the /:hash and /redirect/:hash
+// routes.
+type wordsGenerator struct {
+	count int
+}
+
+func newWordsGenerator(count int) (*wordsGenerator, error) {
+	if count < 2 || count > 4 {
+		return nil, errors.New("hash word count must be between 2 and 4")
+	}
+	return &wordsGenerator{count: count}, nil
+}
+
+func (g *wordsGenerator) Generate(context.Context) (string, error) {
+	size := big.NewInt(int64(len(wordList)))
+	parts := make([]string, g.count)
+	for i := range parts {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed to read random number: %w", err)
+		}
+		parts[i] = wordList[n.Int64()]
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+var wordList = []string{
+	"able", "acid", "aged", "airy", "amber", "apple", "april", "arch", "arrow",
+	"aspen", "atlas", "aunt", "autumn", "avid", "awake", "bacon", "badge",
+	"baker", "balmy", "bamboo", "banjo", "barn", "basil", "beach", "beam",
+	"bean", "bear", "beet", "bell", "berry", "bird", "birch", "bison", "blaze",
+	"bloom", "blue", "board", "boat", "bold", "bolt", "bonus", "book", "boot",
+	"brave", "bread", "breeze", "brick", "brook", "broom", "brown", "bubble",
+	"bunny", "cabin", "cable", "cactus", "cake", "calm", "camel", "candy",
+	"canoe", "cape", "cargo", "carrot", "castle", "cedar", "chalk", "charm",
+	"cheer", "cherry", "chess", "chief", "cider", "cinema", "citrus", "clay",
+	"cliff", "cloud", "clover", "coast", "cobalt", "cocoa", "comet", "coral",
+	"cosmos", "cotton", "cozy", "crane", "creek", "crisp", "crow", "crown",
+	"cube", "curry", "daisy", "dance", "dawn", "deer", "delta", "denim", "desk",
+	"dingo", "dove", "dream", "drift", "drum", "dune", "eagle", "early",
+	"earth", "easy", "echo", "eden", "elbow", "elder", "elm", "ember", "emu",
+	"epic", "fable", "fairy", "falcon", "fancy", "farm", "feast", "fern",
+	"ferry", "fiddle", "field", "fig", "finch", "fjord", "flame", "flint",
+	"flute", "foam", "focus", "forest", "fox", "frost", "fudge", "gale",
+	"garden", "gecko", "gentle", "giant", "ginger", "glade", "glow", "goat",
+	"gold", "grape", "grass", "gravy", "green", "grove", "guava", "gull",
+	"happy", "harbor", "hazel", "heron", "hill", "holly", "honey", "hope",
+	"horse", "husky", "icy", "idea", "igloo", "indigo", "iris", "island",
+	"ivory", "ivy", "jade", "jazz", "jelly", "jolly", "juice", "jungle",
+	"kayak", "kettle", "kiwi", "koala", "lake", "lamp", "lark", "lava", "lemon",
+	"lilac", "lime", "linen", "lion", "lively", "llama", "lotus", "lucky",
+	"lunar", "mango", "maple", "marble", "meadow", "melon", "mint", "misty",
+	"moose", "moss", "mouse", "olive", "opal", "orbit", "otter", "owl", "palm",
+	"panda", "paper", "peach", "pearl", "pepper", "pine", "plum", "polar",
+	"pony", "poppy", "prism", "quail", "quiet", "quill", "radar", "rain",
+	"raven", "reef", "river", "robin", "rocket", "rose", "ruby", "sage", "salt",
+	"sandy", "satin", "shell", "silk", "sky", "solar", "spark", "sprout",
+	"star", "stone", "storm", "sugar", "sunny", "swan", "tango", "teal",
+	"tiger", "topaz", "tulip", "tundra", "velvet", "violet", "walrus", "willow",
+}
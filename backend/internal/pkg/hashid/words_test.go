@@ -0,0 +1,44 @@
+package hashid
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+// hashPattern mirrors importHashPattern in usecase/bulk.go: generated hashes
+// must be ones a user could also import.
+var hashPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{4,32}$`)
+
+var wordPattern = regexp.MustCompile(`^[a-z]{2,6}$`)
+
+func TestWordList(t *testing.T) {
+	seen := make(map[string]bool, len(wordList))
+	for _, w := range wordList {
+		if !wordPattern.MatchString(w) {
+			t.Errorf("word %q is not 2 to 6 lowercase letters", w)
+		}
+		if seen[w] {
+			t.Errorf("word %q is listed twice", w)
+		}
+		seen[w] = true
+	}
+}
+
+func TestWordsGeneratorHashes(t *testing.T) {
+	for count := 2; count <= 4; count++ {
+		g, err := newWordsGenerator(count)
+		if err != nil {
+			t.Fatalf("newWordsGenerator(%d) error = %v", count, err)
+		}
+		for range 1000 {
+			hash, err := g.Generate(context.Background())
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if !hashPattern.MatchString(hash) {
+				t.Fatalf("hash %q does not match %s", hash, hashPattern)
+			}
+		}
+	}
+}
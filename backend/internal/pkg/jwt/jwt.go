@@ -12,6 +12,7 @@ import (
 
 type Claims struct {
 	Email string `json:"email"`
+	Admin bool   `json:"admin,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -20,6 +21,7 @@ func Sign(user sqldb.User, cfg *config.Config) (string, time.Time, error) {
 
 	claims := &Claims{
 		Email: user.Email,
+		Admin: user.IsAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   strconv.FormatInt(user.ID, 10),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
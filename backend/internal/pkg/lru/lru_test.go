@@ -0,0 +1,116 @@
+package lru
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func keys(c *Cache[string, int]) []string {
+	var got []string
+	c.Each(func(key string, _ int, _ time.Time) {
+		got = append(got, key)
+	})
+	return got
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := New[string, int](2)
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+
+	// Reading a makes b the least recently used.
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v; want 1, true", v, ok)
+	}
+	c.Set("c", 3, 0)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b survived, want it evicted as the least recently used")
+	}
+	if got := keys(c); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("entries = %v, want [a c] from least to most recently used", got)
+	}
+
+	// Setting an existing key updates it in place.
+	c.Set("a", 10, 0)
+	if v, _ := c.Get("a"); v != 10 || c.Len() != 2 {
+		t.Errorf("Get(a) = %d with %d entries, want 10 with 2", v, c.Len())
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := New[string, int](10)
+	c.Set("short", 1, time.Millisecond)
+	c.Set("long", 2, time.Hour)
+	c.Set("forever", 3, 0)
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Error("expired entry returned")
+	}
+	if got := keys(c); !slices.Equal(got, []string{"long", "forever"}) {
+		t.Errorf("entries = %v, want the unexpired ones", got)
+	}
+
+	var exps []time.Time
+	c.Each(func(_ string, _ int, exp time.Time) { exps = append(exps, exp) })
+	if exps[0].IsZero() || !exps[1].IsZero() {
+		t.Errorf("expiries = %v, want one set and one zero for never", exps)
+	}
+}
+
+func TestCacheZeroCapacity(t *testing.T) {
+	c := New[string, int](0)
+	c.Set("a", 1, 0)
+	if _, ok := c.Get("a"); ok || c.Len() != 0 {
+		t.Error("cache with no capacity stored an entry")
+	}
+}
+
+func TestCacheDeleteAndPurge(t *testing.T) {
+	c := New[string, int](10)
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+
+	c.Delete("a")
+	c.Delete("missing")
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Errorf("after Delete(a): %d entries, want 1", c.Len())
+	}
+
+	c.Purge()
+	if c.Len() != 0 || len(keys(c)) != 0 {
+		t.Errorf("after Purge(): %d entries, want none", c.Len())
+	}
+}
+
+// TestCacheConcurrent is meant for go test -race.
+func TestCacheConcurrent(t *testing.T) {
+	c := New[string, int](50)
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				key := fmt.Sprint((g*1000 + i) % 100)
+				c.Set(key, i, time.Minute)
+				c.Get(key)
+				if i%100 == 0 {
+					c.Delete(key)
+					c.Each(func(string, int, time.Time) {})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := c.Len(); n > 50 {
+		t.Errorf("Len() = %d, want at most the capacity 50", n)
+	}
+}
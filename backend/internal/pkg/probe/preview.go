@@ -0,0 +1,164 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+
+	"golang.org/x/net/html"
+)
+
+const maxPageToRead = 512 << 10
+
+type pageMetaFetcher struct {
+	client *http.Client
+}
+
+// NewPageMetaFetcher reads the title, description and open-graph image of
+// destination pages for the interstitial preview. It shares the health
+// checker's rules for private addresses.
+func NewPageMetaFetcher(cfg *config.Config) usecase.PageMetaFetcher {
+	dialer := &net.Dialer{Timeout: cfg.HealthCheckTimeout}
+	if !cfg.HealthCheckAllowPrivate {
+		dialer.Control = denyPrivateAddresses
+	}
+
+	return &pageMetaFetcher{
+		client: &http.Client{
+			Timeout: cfg.HealthCheckTimeout,
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   cfg.HealthCheckTimeout,
+				ResponseHeaderTimeout: cfg.HealthCheckTimeout,
+				MaxIdleConnsPerHost:   2,
+				IdleConnTimeout:       30 * time.Second,
+			},
+			CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return errTooManyRedirects
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func (f *pageMetaFetcher) Fetch(ctx context.Context, rawURL string) (usecase.PageMeta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return usecase.PageMeta{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return usecase.PageMeta{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return usecase.PageMeta{}, fmt.Errorf("destination returned status %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return usecase.PageMeta{}, errors.New("destination is not an HTML page")
+	}
+
+	meta := parsePageMeta(io.LimitReader(resp.Body, maxPageToRead))
+	meta.ImageURL = resolveImageURL(resp.Request.URL, meta.ImageURL)
+	return meta, nil
+}
+
+// parsePageMeta scans the document head. Open-graph values win over the
+// plain title and description.
+func parsePageMeta(r io.Reader) usecase.PageMeta {
+	var meta usecase.PageMeta
+	var title, description string
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finishPageMeta(meta, title, description)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				return finishPageMeta(meta, title, description)
+			case "title":
+				if z.Next() == html.TextToken && title == "" {
+					title = strings.TrimSpace(string(z.Text()))
+				}
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				var key, content string
+				for more := true; more; {
+					var attr, val []byte
+					attr, val, more = z.TagAttr()
+					switch string(attr) {
+					case "property", "name":
+						key = strings.ToLower(string(val))
+					case "content":
+						content = strings.TrimSpace(string(val))
+					}
+				}
+				switch key {
+				case "og:title":
+					meta.Title = content
+				case "og:description":
+					meta.Description = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if meta.ImageURL == "" {
+						meta.ImageURL = content
+					}
+				case "twitter:image":
+					if meta.ImageURL == "" {
+						meta.ImageURL = content
+					}
+				case "description":
+					description = content
+				}
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return finishPageMeta(meta, title, description)
+			}
+		}
+	}
+}
+
+func finishPageMeta(meta usecase.PageMeta, title, description string) usecase.PageMeta {
+	if meta.Title == "" {
+		meta.Title = title
+	}
+	if meta.Description == "" {
+		meta.Description = description
+	}
+	return meta
+}
+
+// resolveImageURL makes a relative image URL absolute and drops anything
+// that is not http(s).
+func resolveImageURL(base *url.URL, image string) string {
+	if image == "" {
+		return ""
+	}
+	u, err := base.Parse(image)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
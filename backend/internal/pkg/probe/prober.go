@@ -0,0 +1,117 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+)
+
+const (
+	maxRedirects  = 10
+	maxBodyToRead = 64 << 10
+	userAgent     = "qrcodegen-healthcheck/1.0"
+)
+
+var (
+	errTooManyRedirects = errors.New("too many redirects")
+	errPrivateAddress   = errors.New("destination resolves to a private address")
+)
+
+type httpProber struct {
+	client *http.Client
+}
+
+func NewProber(cfg *config.Config) usecase.DestinationProber {
+	dialer := &net.Dialer{Timeout: cfg.HealthCheckTimeout}
+	if !cfg.HealthCheckAllowPrivate {
+		dialer.Control = denyPrivateAddresses
+	}
+
+	return &httpProber{
+		client: &http.Client{
+			Timeout: cfg.HealthCheckTimeout,
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   cfg.HealthCheckTimeout,
+				ResponseHeaderTimeout: cfg.HealthCheckTimeout,
+				MaxIdleConnsPerHost:   2,
+				IdleConnTimeout:       30 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Probe follows redirects by hand so every hop ends up in the chain. HEAD is
+// tried first; servers that reject it get a GET.
+func (p *httpProber) Probe(ctx context.Context, url string) usecase.ProbeResult {
+	var chain []string
+	current := url
+
+	for range maxRedirects + 1 {
+		status, location, err := p.request(ctx, http.MethodHead, current)
+		if err == nil && status >= 400 {
+			status, location, err = p.request(ctx, http.MethodGet, current)
+		}
+		if err != nil {
+			return usecase.ProbeResult{RedirectChain: chain, Err: err}
+		}
+		if location == "" {
+			return usecase.ProbeResult{StatusCode: status, RedirectChain: chain}
+		}
+		chain = append(chain, location)
+		current = location
+	}
+
+	return usecase.ProbeResult{RedirectChain: chain, Err: errTooManyRedirects}
+}
+
+// request returns the status code and, for redirects, the absolute target.
+func (p *httpProber) request(ctx context.Context, method, url string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyToRead))
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 || resp.StatusCode == http.StatusNotModified {
+		return resp.StatusCode, "", nil
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return resp.StatusCode, "", fmt.Errorf("redirect without location: %w", err)
+	}
+	return resp.StatusCode, location.String(), nil
+}
+
+// denyPrivateAddresses runs after DNS resolution, so it also catches public
+// names that resolve to internal addresses.
+func denyPrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errPrivateAddress
+	}
+	return nil
+}
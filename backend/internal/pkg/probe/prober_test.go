@@ -0,0 +1,212 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+)
+
+// newTestProber allows private addresses, since httptest servers listen on
+// loopback.
+func newTestProber() *httpProber {
+	return NewProber(&config.Config{
+		HealthCheckTimeout:      5 * time.Second,
+		HealthCheckAllowPrivate: true,
+	}).(*httpProber)
+}
+
+func TestProbeStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		healthy bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusNoContent, true},
+		{http.StatusNotModified, true},
+		{http.StatusNotFound, false},
+		{http.StatusGone, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			var methods []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				methods = append(methods, r.Method)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			result := newTestProber().Probe(context.Background(), srv.URL)
+			if result.Err != nil {
+				t.Fatalf("Probe() error = %v", result.Err)
+			}
+			if result.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", result.StatusCode, tt.status)
+			}
+			if result.Healthy() != tt.healthy {
+				t.Errorf("healthy = %v, want %v", result.Healthy(), tt.healthy)
+			}
+
+			// Error statuses are retried with GET in case only HEAD fails.
+			want := []string{http.MethodHead}
+			if tt.status >= 400 {
+				want = append(want, http.MethodGet)
+			}
+			if !slices.Equal(methods, want) {
+				t.Errorf("methods = %v, want %v", methods, want)
+			}
+		})
+	}
+}
+
+func TestProbeHeadFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if ua := r.Header.Get("User-Agent"); ua != userAgent {
+			t.Errorf("User-Agent = %q, want %q", ua, userAgent)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	result := newTestProber().Probe(context.Background(), srv.URL)
+	if result.Err != nil || result.StatusCode != http.StatusOK {
+		t.Fatalf("Probe() = %d, %v, want 200", result.StatusCode, result.Err)
+	}
+	if !result.Healthy() {
+		t.Error("destination that only rejects HEAD reported unhealthy")
+	}
+}
+
+func TestProbeRedirectChain(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/final" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer target.Close()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/next", http.StatusMovedPermanently)
+		case "/next":
+			// Only GET redirects; HEAD gets a 404 and falls back.
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			http.Redirect(w, r, target.URL+"/final", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	result := newTestProber().Probe(context.Background(), srv.URL+"/start")
+	if result.Err != nil {
+		t.Fatalf("Probe() error = %v", result.Err)
+	}
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", result.StatusCode)
+	}
+	want := []string{srv.URL + "/next", target.URL + "/final"}
+	if !slices.Equal(result.RedirectChain, want) {
+		t.Errorf("chain = %v, want %v", result.RedirectChain, want)
+	}
+}
+
+func TestProbeTooManyRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	result := newTestProber().Probe(context.Background(), srv.URL+"/")
+	if !errors.Is(result.Err, errTooManyRedirects) {
+		t.Fatalf("Probe() error = %v, want %v", result.Err, errTooManyRedirects)
+	}
+	if len(result.RedirectChain) != maxRedirects+1 {
+		t.Errorf("chain has %d hops, want %d", len(result.RedirectChain), maxRedirects+1)
+	}
+	if result.Healthy() {
+		t.Error("redirect loop reported healthy")
+	}
+}
+
+func TestProbeRedirectWithoutLocation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer srv.Close()
+
+	result := newTestProber().Probe(context.Background(), srv.URL)
+	if result.Err == nil || result.Healthy() {
+		t.Fatalf("Probe() = %d, %v, want an error", result.StatusCode, result.Err)
+	}
+}
+
+func TestProbePrivateAddress(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	prober := NewProber(&config.Config{HealthCheckTimeout: 5 * time.Second})
+	result := prober.Probe(context.Background(), srv.URL)
+	if !errors.Is(result.Err, errPrivateAddress) {
+		t.Fatalf("Probe() error = %v, want %v", result.Err, errPrivateAddress)
+	}
+	if requests != 0 {
+		t.Errorf("server received %d requests", requests)
+	}
+}
+
+func TestDenyPrivateAddresses(t *testing.T) {
+	tests := []struct {
+		address string
+		denied  bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"[fd00::1]:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"[::]:80", true},
+		{"224.0.0.1:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"[::ffff:10.0.0.1]:80", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := denyPrivateAddresses("tcp", tt.address, nil)
+			if tt.denied && !errors.Is(err, errPrivateAddress) {
+				t.Errorf("denyPrivateAddresses() error = %v, want %v", err, errPrivateAddress)
+			}
+			if !tt.denied && err != nil {
+				t.Errorf("denyPrivateAddresses() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+)
+
+const webhookUserAgent = "qrcodegen-webhooks/1.0"
+
+type webhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender posts webhook deliveries. Like the health checker it
+// refuses private addresses unless allowed, and it does not follow
+// redirects, which would drop the body and signature.
+func NewWebhookSender(cfg *config.Config) usecase.WebhookSender {
+	dialer := &net.Dialer{Timeout: cfg.WebhookTimeout}
+	if !cfg.WebhookAllowPrivate {
+		dialer.Control = denyPrivateAddresses
+	}
+
+	return &webhookSender{
+		client: &http.Client{
+			Timeout: cfg.WebhookTimeout,
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   cfg.WebhookTimeout,
+				ResponseHeaderTimeout: cfg.WebhookTimeout,
+				MaxIdleConnsPerHost:   2,
+				IdleConnTimeout:       30 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (s *webhookSender) Send(ctx context.Context, req usecase.WebhookRequest) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("User-Agent", webhookUserAgent)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Webhook-Id", req.EventID)
+	httpReq.Header.Set("X-Webhook-Event", req.EventType)
+	httpReq.Header.Set("X-Webhook-Signature", req.Signature)
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Reading a little of the body lets the connection be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyToRead))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"qrcodegen/internal/usecase"
+)
+
+func newTestLocalStorage(t *testing.T) *localStorage {
+	t.Helper()
+	s, err := newLocalStorage(filepath.Join(t.TempDir(), "files"))
+	if err != nil {
+		t.Fatalf("newLocalStorage() error = %v", err)
+	}
+	return s
+}
+
+func putString(s usecase.FileStorage, key, content string) error {
+	return s.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), "text/plain")
+}
+
+func readString(t *testing.T, s usecase.FileStorage, key string) string {
+	t.Helper()
+	r, err := s.Open(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Open(%q) error = %v", key, err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read %q: %v", key, err)
+	}
+	return string(b)
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	const key = "links/abc123/first"
+
+	if err := putString(s, key, "hello"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got := readString(t, s, key); got != "hello" {
+		t.Errorf("content = %q, want hello", got)
+	}
+
+	if err := putString(s, key, "replaced"); err != nil {
+		t.Fatalf("Put() over an existing file error = %v", err)
+	}
+	if got := readString(t, s, key); got != "replaced" {
+		t.Errorf("content = %q, want replaced", got)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, usecase.ErrStoredFileNotFound) {
+		t.Errorf("Open() after Delete() error = %v, want %v", err, usecase.ErrStoredFileNotFound)
+	}
+	// Deleting twice is not an error, so cleanup can be retried.
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("second Delete() error = %v", err)
+	}
+
+	// No temporary files are left next to the stored ones.
+	entries, err := os.ReadDir(filepath.Join(s.root, "links", "abc123"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("directory still holds %d entries", len(entries))
+	}
+}
+
+func TestLocalStorageOpenMissing(t *testing.T) {
+	s := newTestLocalStorage(t)
+	if _, err := s.Open(context.Background(), "links/missing/key"); !errors.Is(err, usecase.ErrStoredFileNotFound) {
+		t.Errorf("Open() error = %v, want %v", err, usecase.ErrStoredFileNotFound)
+	}
+}
+
+func TestLocalStorageSizeMismatch(t *testing.T) {
+	s := newTestLocalStorage(t)
+	const key = "links/abc123/short"
+
+	err := s.Put(context.Background(), key, strings.NewReader("abc"), 10, "text/plain")
+	if err == nil {
+		t.Fatal("Put() accepted a body shorter than its size")
+	}
+	if _, err := s.Open(context.Background(), key); !errors.Is(err, usecase.ErrStoredFileNotFound) {
+		t.Errorf("Open() error = %v, want the incomplete file to be discarded", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(s.root, "links", "abc123"))
+	if len(entries) != 0 {
+		t.Errorf("directory still holds %d entries", len(entries))
+	}
+}
+
+func TestLocalStoragePathTraversal(t *testing.T) {
+	base := t.TempDir()
+	s, err := newLocalStorage(filepath.Join(base, "files"))
+	if err != nil {
+		t.Fatalf("newLocalStorage() error = %v", err)
+	}
+	// A sibling directory sharing the root's prefix must not be reachable.
+	if err := os.WriteFile(filepath.Join(base, "secret"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(base, "files-other"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{
+		"",
+		".",
+		"..",
+		"../secret",
+		"links/../../secret",
+		"links/../..",
+		"../files-other/x",
+		"links/../../files/../secret",
+	}
+
+	ctx := context.Background()
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			if err := putString(s, key, "x"); !errors.Is(err, errInvalidKey) {
+				t.Errorf("Put() error = %v, want %v", err, errInvalidKey)
+			}
+			if _, err := s.Open(ctx, key); !errors.Is(err, errInvalidKey) {
+				t.Errorf("Open() error = %v, want %v", err, errInvalidKey)
+			}
+			if err := s.Delete(ctx, key); !errors.Is(err, errInvalidKey) {
+				t.Errorf("Delete() error = %v, want %v", err, errInvalidKey)
+			}
+		})
+	}
+
+	if b, err := os.ReadFile(filepath.Join(base, "secret")); err != nil || string(b) != "secret" {
+		t.Errorf("file outside the root changed: %q, %v", b, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(base, "files-other")); len(entries) != 0 {
+		t.Errorf("wrote %d entries outside the root", len(entries))
+	}
+
+	// Keys that stay inside the root after cleaning are fine.
+	if err := putString(s, "links/x/../abc/key", "ok"); err != nil {
+		t.Errorf("Put() error = %v", err)
+	}
+	if got := readString(t, s, "links/abc/key"); got != "ok" {
+		t.Errorf("content = %q, want ok", got)
+	}
+}
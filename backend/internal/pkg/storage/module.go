@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"fmt"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+
+	"github.com/rs/zerolog/log"
+)
+
+func NewFileStorage(cfg *config.Config) (usecase.FileStorage, error) {
+	switch cfg.FileStorageDriver {
+	case "s3":
+		log.Info().Msgf("Storing files in S3 bucket %s at %s", cfg.S3Bucket, cfg.S3Endpoint)
+		return newS3Storage(cfg)
+	case "local", "":
+		log.Info().Msgf("Storing files in %s", cfg.FileStorageDir)
+		return newLocalStorage(cfg.FileStorageDir)
+	default:
+		return nil, fmt.Errorf("unknown file storage driver %q", cfg.FileStorageDriver)
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/usecase"
+)
+
+// TestS3Storage runs against a real S3-compatible server and is skipped
+// unless S3_TEST_ENDPOINT is set, e.g. for the compose MinIO:
+//
+//	docker compose --profile s3 up -d minio
+//	S3_TEST_ENDPOINT=localhost:9000 go test ./internal/pkg/storage/
+func TestS3Storage(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set")
+	}
+
+	cfg := &config.Config{
+		S3Endpoint:  endpoint,
+		S3Region:    "us-east-1",
+		S3Bucket:    "qrcodegen-test-" + strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-")),
+		S3AccessKey: getTestEnv("S3_TEST_ACCESS_KEY", "minioadmin"),
+		S3SecretKey: getTestEnv("S3_TEST_SECRET_KEY", "minioadmin"),
+	}
+	s, err := newS3Storage(cfg)
+	if err != nil {
+		t.Fatalf("newS3Storage() error = %v", err)
+	}
+	// A second start finds the bucket it created.
+	if _, err := newS3Storage(cfg); err != nil {
+		t.Fatalf("newS3Storage() with an existing bucket error = %v", err)
+	}
+
+	ctx := context.Background()
+	const key = "links/abc123/first"
+	t.Cleanup(func() { s.Delete(context.Background(), key) })
+
+	if _, err := s.Open(ctx, key); !errors.Is(err, usecase.ErrStoredFileNotFound) {
+		t.Errorf("Open() before Put() error = %v, want %v", err, usecase.ErrStoredFileNotFound)
+	}
+
+	if err := putString(s, key, "hello"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got := readString(t, s, key); got != "hello" {
+		t.Errorf("content = %q, want hello", got)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, usecase.ErrStoredFileNotFound) {
+		t.Errorf("Open() after Delete() error = %v, want %v", err, usecase.ErrStoredFileNotFound)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("second Delete() error = %v", err)
+	}
+}
+
+func getTestEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,91 @@
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	ErrInvalidURL       = errors.New("invalid url")
+	ErrSchemeNotAllowed = errors.New("only http and https urls are allowed")
+	ErrCredentialsInURL = errors.New("urls with embedded credentials are not allowed")
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize parses rawURL and returns it in canonical form: lowercase scheme
+// and host, internationalized hosts converted to punycode, trailing dot and
+// default port removed. Only http and https URLs are accepted.
+func Normalize(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	defaultPort, ok := defaultPorts[u.Scheme]
+	if !ok {
+		return nil, ErrSchemeNotAllowed
+	}
+	if u.User != nil {
+		return nil, ErrCredentialsInURL
+	}
+
+	host, err := NormalizeHost(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	port := u.Port()
+	if port == defaultPort {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host = host + ":" + port
+	}
+
+	u.Host = host
+	return u, nil
+}
+
+// NormalizeHost lowercases host, strips a trailing dot and converts
+// internationalized names to punycode. IP addresses are returned as is.
+func NormalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" {
+		return "", fmt.Errorf("%w: missing host", ErrInvalidURL)
+	}
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	return ascii, nil
+}
+
+// ParentDomains returns host followed by each of its parent domains, so a
+// rule for "example.com" also matches "www.example.com".
+func ParentDomains(host string) []string {
+	if net.ParseIP(host) != nil {
+		return []string{host}
+	}
+	labels := strings.Split(host, ".")
+	domains := make([]string, 0, len(labels))
+	for i := range labels {
+		domains = append(domains, strings.Join(labels[i:], "."))
+	}
+	return domains
+}
@@ -0,0 +1,255 @@
+// Package xlsx writes spreadsheets row by row without holding them in
+// memory. It covers what exports need: strings, numbers, booleans and
+// timestamps on sheets that share a header row.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	// MaxRows is the row limit of a sheet; further rows continue on a new
+	// sheet that repeats the header.
+	MaxRows = 1 << 20
+
+	maxCellLength = 32767
+)
+
+// excelEpoch is day zero of Excel date serials in the 1900 date system.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type Writer struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	name   string
+	header []string
+	sheets int
+	rows   int
+	err    error
+}
+
+// NewWriter starts a workbook whose sheets are called name, name 2, ...
+// Close must be called to complete it.
+func NewWriter(w io.Writer, name string, header []string) *Writer {
+	return &Writer{zw: zip.NewWriter(w), name: name, header: header}
+}
+
+// WriteRow appends a row. Values may be nil, string, bool, any integer or
+// float type, or time.Time, which is written in UTC.
+func (w *Writer) WriteRow(values []any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.sheet == nil || w.rows == MaxRows {
+		if w.err = w.startSheet(); w.err != nil {
+			return w.err
+		}
+	}
+	w.err = w.writeRow(values)
+	return w.err
+}
+
+// Close writes the remaining parts of the workbook. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.sheet == nil {
+		if err := w.startSheet(); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", w.workbook()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for _, p := range parts {
+		f, err := w.zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+	return w.zw.Close()
+}
+
+func (w *Writer) startSheet() error {
+	if w.sheet != nil {
+		if err := w.endSheet(); err != nil {
+			return err
+		}
+	}
+
+	w.sheets++
+	w.rows = 0
+	f, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", w.sheets))
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(f)
+	w.sheet.WriteString(xml.Header)
+	w.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	if len(w.header) == 0 {
+		return nil
+	}
+	header := make([]any, len(w.header))
+	for i, h := range w.header {
+		header[i] = h
+	}
+	return w.writeRow(header)
+}
+
+func (w *Writer) endSheet() error {
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	return w.sheet.Flush()
+}
+
+func (w *Writer) writeRow(values []any) error {
+	w.rows++
+	row := strconv.Itoa(w.rows)
+	w.sheet.WriteString(`<row r="` + row + `">`)
+	for i, v := range values {
+		ref := columnName(i) + row
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			w.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(w.sheet, []byte(truncate(v))); err != nil {
+				return err
+			}
+			w.sheet.WriteString(`</t></is></c>`)
+		case bool:
+			b := "0"
+			if v {
+				b = "1"
+			}
+			w.sheet.WriteString(`<c r="` + ref + `" t="b"><v>` + b + `</v></c>`)
+		case time.Time:
+			days := float64(v.UTC().Sub(excelEpoch)) / float64(24*time.Hour)
+			w.sheet.WriteString(`<c r="` + ref + `" s="1"><v>` + strconv.FormatFloat(days, 'f', -1, 64) + `</v></c>`)
+		case int:
+			w.writeNumber(ref, strconv.FormatInt(int64(v), 10))
+		case int32:
+			w.writeNumber(ref, strconv.FormatInt(int64(v), 10))
+		case int64:
+			w.writeNumber(ref, strconv.FormatInt(v, 10))
+		case float32:
+			w.writeNumber(ref, strconv.FormatFloat(float64(v), 'g', -1, 32))
+		case float64:
+			w.writeNumber(ref, strconv.FormatFloat(v, 'g', -1, 64))
+		default:
+			return fmt.Errorf("xlsx: unsupported cell type %T", v)
+		}
+	}
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (w *Writer) writeNumber(ref, n string) {
+	w.sheet.WriteString(`<c r="` + ref + `"><v>` + n + `</v></c>`)
+}
+
+func (w *Writer) sheetName(i int) string {
+	if i == 1 {
+		return w.name
+	}
+	return fmt.Sprintf("%s %d", w.name, i)
+}
+
+func (w *Writer) contentTypes() string {
+	s := xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`
+	for i := 1; i <= w.sheets; i++ {
+		s += fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	return s + `</Types>`
+}
+
+func (w *Writer) workbook() string {
+	s := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`
+	for i := 1; i <= w.sheets; i++ {
+		s += fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeAttr(w.sheetName(i)), i, i)
+	}
+	return s + `</sheets></workbook>`
+}
+
+func (w *Writer) workbookRels() string {
+	s := xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
+	for i := 1; i <= w.sheets; i++ {
+		s += fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	s += fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, w.sheets+1)
+	return s + `</Relationships>`
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles defines cell format 1, used for timestamps.
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// columnName turns a zero-based index into A, B, ..., Z, AA, ...
+func columnName(i int) string {
+	var b []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		b = append([]byte{byte('A' + (i-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+// truncate cuts s to the cell length limit, which Excel counts in UTF-16
+// code units.
+func truncate(s string) string {
+	if len(s) <= maxCellLength {
+		return s
+	}
+	n := 0
+	for i, r := range s {
+		n += utf16.RuneLen(r)
+		if n > maxCellLength {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// LinkChangesChannel carries the hash of every link that was created, edited
+// or deleted, and "domain:<host>" for every custom domain that was verified
+// or deleted. Payloads are sent with pg_notify inside the changing
+// transaction, so they are delivered only after commit.
+const LinkChangesChannel = "link_changes"
+
+const listenRetryDelay = 2 * time.Second
+
+type Listener struct {
+	pool *pgxpool.Pool
+}
+
+func NewListener(pool *pgxpool.Pool) *Listener {
+	return &Listener{pool: pool}
+}
+
+// Listen blocks until ctx is done, calling onNotify for every payload on
+// channel. The connection is re-established on failure; onReconnect is called
+// after every (re)subscription because notifications sent while disconnected
+// are lost.
+func (l *Listener) Listen(ctx context.Context, channel string, onNotify func(payload string), onReconnect func()) {
+	for ctx.Err() == nil {
+		if err := l.listen(ctx, channel, onNotify, onReconnect); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Str("channel", channel).Msg("Listener connection lost, retrying")
+			select {
+			case <-ctx.Done():
+			case <-time.After(listenRetryDelay):
+			}
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, channel string, onNotify func(payload string), onReconnect func()) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	defer func() {
+		// The connection goes back to the pool, so it must not stay subscribed.
+		unlistenCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlistenCtx, "UNLISTEN *"); err != nil {
+			conn.Conn().Close(unlistenCtx)
+		}
+	}()
+
+	if onReconnect != nil {
+		onReconnect()
+	}
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		onNotify(n.Payload)
+	}
+}
@@ -13,16 +13,19 @@ type Repository interface {
 	sqlc_repo.Querier
 	WithTX(tx pgx.Tx) Repository
 	BeginTx(ctx context.Context) (pgx.Tx, error)
+	StreamTransitions(ctx context.Context, arg StreamTransitionsParams, fn func(StreamTransitionsRow) error) error
 }
 
 type repository struct {
 	*sqlc_repo.Queries
+	db   sqlc_repo.DBTX
 	pool *pgxpool.Pool
 }
 
 func NewRepository(pool *pgxpool.Pool) Repository {
 	return &repository{
 		Queries: sqlc_repo.New(pool),
+		db:      pool,
 		pool:    pool,
 	}
 }
@@ -30,6 +33,7 @@ func NewRepository(pool *pgxpool.Pool) Repository {
 func (r *repository) WithTX(tx pgx.Tx) Repository {
 	return &repository{
 		Queries: sqlc_repo.New(tx),
+		db:      tx,
 		pool:    r.pool,
 	}
 }
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// streamTransitions is not generated by sqlc: its :many queries collect
+// every row into a slice, while exports need to handle one row at a time.
+const streamTransitions = `
+SELECT
+  t.id,
+  t.link_id,
+  l.hash,
+  l.name,
+  t.created_at,
+  t.country,
+  t.region,
+  t.city,
+  t.referer,
+  t.user_agent,
+  t.browser,
+  t.os,
+  t.via_preview,
+  t.revision_id,
+  t.schedule_id,
+  t.visitor_hash,
+  t.geo_source
+FROM transitions t
+JOIN links l ON l.id = t.link_id
+WHERE l.user_id = $1 AND l.deleted_at IS NULL
+  AND ($2::integer IS NULL OR t.link_id = $2)
+  AND ($3::timestamptz IS NULL OR t.created_at >= $3)
+  AND ($4::timestamptz IS NULL OR t.created_at < $4)
+ORDER BY t.id
+`
+
+type StreamTransitionsParams struct {
+	UserID   int64
+	LinkID   *int64
+	FromTime *time.Time
+	ToTime   *time.Time
+}
+
+type StreamTransitionsRow struct {
+	ID          int64
+	LinkID      int64
+	LinkHash    string
+	LinkName    string
+	CreatedAt   time.Time
+	Country     *string
+	Region      *string
+	City        *string
+	Referer     *string
+	UserAgent   *string
+	Browser     *string
+	Os          *string
+	ViaPreview  bool
+	RevisionID  *int64
+	ScheduleID  *int64
+	VisitorHash *string
+	GeoSource   *string
+}
+
+// StreamTransitions calls fn for each transition of the user's live links in
+// id order, as rows arrive from the server. An error from fn stops the
+// query and is returned.
+func (r *repository) StreamTransitions(ctx context.Context, arg StreamTransitionsParams, fn func(StreamTransitionsRow) error) error {
+	rows, err := r.db.Query(ctx, streamTransitions, arg.UserID, arg.LinkID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i StreamTransitionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LinkID,
+			&i.LinkHash,
+			&i.LinkName,
+			&i.CreatedAt,
+			&i.Country,
+			&i.Region,
+			&i.City,
+			&i.Referer,
+			&i.UserAgent,
+			&i.Browser,
+			&i.Os,
+			&i.ViaPreview,
+			&i.RevisionID,
+			&i.ScheduleID,
+			&i.VisitorHash,
+			&i.GeoSource,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,442 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"qrcodegen/internal/dto"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	BulkFormatCSV  = "csv"
+	BulkFormatJSON = "json"
+
+	maxImportRows = 1000
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format, use csv or json")
+	ErrImportMalformed   = errors.New("import file is malformed")
+	ErrImportTooLarge    = fmt.Errorf("import is limited to %d rows", maxImportRows)
+	ErrInvalidImportRow  = errors.New("invalid row")
+)
+
+var (
+	hexColorPattern   = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+	importHashPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{4,32}$`)
+)
+
+var linkCSVHeader = []string{"name", "original_url", "hash", "domain", "color", "background", "smoothing", "interstitial", "redirect_mode", "file", "transitions_count", "created_at"}
+
+type importRow struct {
+	dto.ImportLinkRow
+	err error
+}
+
+type plannedLink struct {
+	index int
+	link  sqldb.CreateLinkParams
+	qr    sqldb.CreateQRCodeParams
+}
+
+// ExportLinks writes all links of the user with their QR styling and
+// transition counts. The output can be fed back into ImportLinks, except for
+// file links, whose file is named but not included.
+func (uc *LinkUseCase) ExportLinks(ctx context.Context, userID int64, format string, w io.Writer) error {
+	if format != BulkFormatCSV && format != BulkFormatJSON {
+		return ErrUnsupportedFormat
+	}
+
+	rows, err := uc.repo.GetLinksForExport(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get links for export: %w", err)
+	}
+
+	items := make([]dto.LinkExportItem, len(rows))
+	for i, r := range rows {
+		items[i] = dto.LinkExportItem{
+			Name:         r.Name,
+			OriginalURL:  r.OriginalUrl,
+			Hash:         r.Hash,
+			Domain:       r.DomainHost,
+			Color:        r.Color,
+			Background:   r.Background,
+			Smoothing:    r.Smoothing,
+			Interstitial: r.Interstitial,
+			RedirectMode: r.RedirectMode,
+			File:         r.FileName,
+			Transitions:  r.TransitionsCount,
+			CreatedAt:    r.CreatedAt,
+		}
+	}
+
+	if format == BulkFormatJSON {
+		return json.NewEncoder(w).Encode(items)
+	}
+	return writeLinksCSV(w, items)
+}
+
+// ImportLinks validates every row and creates the valid ones, reporting the
+// outcome per row. Rows keep their hash when one is given, so a re-imported
+// export keeps working QR codes. In dry-run mode nothing is written.
+func (uc *LinkUseCase) ImportLinks(ctx context.Context, userID int64, format string, r io.Reader, dryRun bool) (*dto.ImportLinksResponse, error) {
+	var rows []importRow
+	var err error
+	switch format {
+	case BulkFormatCSV:
+		rows, err = readLinksCSV(r)
+	case BulkFormatJSON:
+		rows, err = readLinksJSON(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > maxImportRows {
+		return nil, ErrImportTooLarge
+	}
+
+	resp := &dto.ImportLinksResponse{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]dto.ImportRowResult, len(rows)),
+	}
+
+	var planned []plannedLink
+	hashes := make(map[string]bool)
+	domains := make(map[string]int64)
+	for i, row := range rows {
+		resp.Rows[i] = dto.ImportRowResult{Row: i + 1, Name: row.Name, Hash: row.Hash}
+
+		plan, err := uc.planImportRow(ctx, userID, row, hashes, domains)
+		if err != nil {
+			if !isImportRowError(err) {
+				return nil, err
+			}
+			resp.Rows[i].Error = err.Error()
+			resp.Failed++
+			continue
+		}
+		plan.index = i
+		planned = append(planned, plan)
+	}
+
+	if dryRun || len(planned) == 0 {
+		resp.Message = "Import checked, nothing was written"
+		if !dryRun {
+			resp.Message = "No links were imported"
+		}
+		return resp, nil
+	}
+
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	var created []plannedLink
+	for _, plan := range planned {
+		createdLink, err := uc.insertLink(ctx, repoWithTx, plan.link, plan.qr)
+		if err != nil {
+			// A hash taken by a concurrent create after the rows were
+			// checked. The insert does nothing on conflict, so the
+			// transaction can go on with the other rows.
+			if errors.Is(err, ErrHashTaken) {
+				resp.Rows[plan.index].Error = hashTakenRowError(plan.link.Hash).Error()
+				resp.Failed++
+				continue
+			}
+			return nil, err
+		}
+		resp.Rows[plan.index].ID = &createdLink.ID
+		resp.Rows[plan.index].Hash = createdLink.Hash
+		created = append(created, plan)
+	}
+
+	if len(created) == 0 {
+		resp.Message = "No links were imported"
+		return resp, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, plan := range created {
+		uc.cache.Invalidate(resp.Rows[plan.index].Hash)
+		if plan.link.Interstitial {
+			uc.previews.Refresh(*resp.Rows[plan.index].ID, plan.link.OriginalUrl)
+		}
+	}
+
+	resp.Imported = len(created)
+	resp.Message = "Links imported successfully"
+	return resp, nil
+}
+
+// planImportRow turns a row into insert parameters. Row-level problems are
+// returned as errors matched by isImportRowError; anything else aborts the
+// import.
+func (uc *LinkUseCase) planImportRow(ctx context.Context, userID int64, row importRow, hashes map[string]bool, domains map[string]int64) (plannedLink, error) {
+	if row.err != nil {
+		return plannedLink{}, row.err
+	}
+
+	if strings.TrimSpace(row.Name) == "" {
+		return plannedLink{}, fmt.Errorf("%w: name is required", ErrInvalidImportRow)
+	}
+	if row.File != "" {
+		return plannedLink{}, fmt.Errorf("%w: file links cannot be imported, upload %q to a new link instead", ErrInvalidImportRow, row.File)
+	}
+	if strings.TrimSpace(row.OriginalURL) == "" {
+		return plannedLink{}, fmt.Errorf("%w: original_url is required", ErrInvalidImportRow)
+	}
+	originalURL, err := uc.policy.Check(ctx, row.OriginalURL)
+	if err != nil {
+		return plannedLink{}, err
+	}
+
+	qr := defaultQRCodeParams()
+	if row.Color != "" {
+		if !hexColorPattern.MatchString(row.Color) {
+			return plannedLink{}, fmt.Errorf("%w: color must be 6 hex digits", ErrInvalidImportRow)
+		}
+		qr.Color = row.Color
+	}
+	if row.Background != "" {
+		if !hexColorPattern.MatchString(row.Background) {
+			return plannedLink{}, fmt.Errorf("%w: background must be 6 hex digits", ErrInvalidImportRow)
+		}
+		qr.Background = row.Background
+	}
+	if row.Smoothing != nil {
+		if *row.Smoothing < 0 || *row.Smoothing > 0.5 {
+			return plannedLink{}, fmt.Errorf("%w: smoothing must be between 0 and 0.5", ErrInvalidImportRow)
+		}
+		smoothing := *row.Smoothing
+		qr.Smoothing = &smoothing
+	}
+
+	if row.RedirectMode != "" && !RedirectMode(row.RedirectMode).valid() {
+		return plannedLink{}, fmt.Errorf("%w: redirect_mode must be 301, 302, 307, 308, meta_refresh or javascript", ErrInvalidImportRow)
+	}
+
+	link := sqldb.CreateLinkParams{
+		OriginalUrl:  originalURL,
+		UserID:       userID,
+		Name:         row.Name,
+		Interstitial: row.Interstitial,
+		RedirectMode: row.RedirectMode,
+	}
+
+	if row.Hash != "" {
+		if !importHashPattern.MatchString(row.Hash) {
+			return plannedLink{}, fmt.Errorf("%w: hash must be 4-32 letters, digits, '-' or '_'", ErrInvalidImportRow)
+		}
+		if hashes[row.Hash] {
+			return plannedLink{}, fmt.Errorf("%w: hash %q appears more than once", ErrInvalidImportRow, row.Hash)
+		}
+		taken, err := uc.repo.IsLinkHashTaken(ctx, row.Hash)
+		if err != nil {
+			return plannedLink{}, fmt.Errorf("failed to check hash uniqueness: %w", err)
+		}
+		if taken {
+			return plannedLink{}, hashTakenRowError(row.Hash)
+		}
+		hashes[row.Hash] = true
+		link.Hash = row.Hash
+	}
+
+	if row.Domain != "" {
+		domainID, err := uc.importDomainID(ctx, userID, row.Domain, domains)
+		if err != nil {
+			return plannedLink{}, err
+		}
+		link.DomainID = &domainID
+	}
+
+	return plannedLink{link: link, qr: qr}, nil
+}
+
+// importDomainID resolves a domain host to one of the user's verified
+// domains, caching lookups for the rest of the import.
+func (uc *LinkUseCase) importDomainID(ctx context.Context, userID int64, host string, domains map[string]int64) (int64, error) {
+	host = normalizeHost(host)
+	if id, ok := domains[host]; ok {
+		return id, nil
+	}
+
+	domain, err := uc.repo.GetDomainByUserAndHost(ctx, sqldb.GetDomainByUserAndHostParams{UserID: userID, Host: host})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrDomainNotFound
+		}
+		return 0, fmt.Errorf("failed to get domain by host: %w", err)
+	}
+	if domain.VerifiedAt == nil {
+		return 0, ErrDomainNotVerified
+	}
+
+	domains[host] = domain.ID
+	return domain.ID, nil
+}
+
+func hashTakenRowError(hash string) error {
+	return fmt.Errorf("%w: hash %q is already in use", ErrInvalidImportRow, hash)
+}
+
+func isImportRowError(err error) bool {
+	return errors.Is(err, ErrInvalidImportRow) ||
+		errors.Is(err, ErrURLRejected) ||
+		errors.Is(err, ErrDomainNotFound) ||
+		errors.Is(err, ErrDomainNotVerified)
+}
+
+func writeLinksCSV(w io.Writer, items []dto.LinkExportItem) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(linkCSVHeader); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var domain, smoothing, file string
+		if item.Domain != nil {
+			domain = *item.Domain
+		}
+		if item.Smoothing != nil {
+			smoothing = strconv.FormatFloat(*item.Smoothing, 'f', -1, 64)
+		}
+		if item.File != nil {
+			file = *item.File
+		}
+
+		record := []string{
+			item.Name,
+			item.OriginalURL,
+			item.Hash,
+			domain,
+			item.Color,
+			item.Background,
+			smoothing,
+			strconv.FormatBool(item.Interstitial),
+			item.RedirectMode,
+			file,
+			strconv.FormatInt(item.Transitions, 10),
+			item.CreatedAt.Format(time.RFC3339),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// readLinksCSV reads rows by header name, so columns may come in any order
+// and unknown ones such as transitions_count are ignored.
+func readLinksCSV(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "original_url"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: missing %s column", ErrImportMalformed, required)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, ErrImportTooLarge
+		}
+
+		cell := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+
+		// Names are kept verbatim so that a round trip reproduces them.
+		row := importRow{ImportLinkRow: dto.ImportLinkRow{
+			Name:         cell("name"),
+			OriginalURL:  strings.TrimSpace(cell("original_url")),
+			Hash:         strings.TrimSpace(cell("hash")),
+			Domain:       strings.TrimSpace(cell("domain")),
+			Color:        strings.TrimSpace(cell("color")),
+			Background:   strings.TrimSpace(cell("background")),
+			RedirectMode: strings.TrimSpace(cell("redirect_mode")),
+			File:         strings.TrimSpace(cell("file")),
+		}}
+		if s := strings.TrimSpace(cell("smoothing")); s != "" {
+			smoothing, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				row.err = fmt.Errorf("%w: smoothing must be a number", ErrInvalidImportRow)
+			}
+			row.Smoothing = &smoothing
+		}
+		if s := strings.TrimSpace(cell("interstitial")); s != "" {
+			interstitial, err := strconv.ParseBool(s)
+			if err != nil {
+				row.err = fmt.Errorf("%w: interstitial must be true or false", ErrInvalidImportRow)
+			}
+			row.Interstitial = interstitial
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readLinksJSON(r io.Reader) ([]importRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+	}
+	if len(raw) > maxImportRows {
+		return nil, ErrImportTooLarge
+	}
+
+	rows := make([]importRow, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &rows[i].ImportLinkRow); err != nil {
+			rows[i].err = fmt.Errorf("%w: %w", ErrInvalidImportRow, err)
+		}
+	}
+	return rows, nil
+}
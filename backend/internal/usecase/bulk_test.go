@@ -0,0 +1,187 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"qrcodegen/internal/repository/postgres"
+	"qrcodegen/internal/usecase"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// racedRepo misses hashes at the check, as when another create takes the
+// hash between the check and the insert.
+type racedRepo struct {
+	*linkRepo
+}
+
+func (r racedRepo) IsLinkHashTaken(ctx context.Context, hash string) (bool, error) {
+	return false, nil
+}
+
+const importCSV = `name,original_url,hash
+first,https://example.org/1,first1
+second,https://example.org/2,taken1
+third,https://example.org/3,
+`
+
+func TestImportLinksHashTaken(t *testing.T) {
+	tests := []struct {
+		name string
+		repo func(*linkRepo) postgres.Repository
+	}{
+		// Taken by a live or trashed link before the import.
+		{"at the check", func(r *linkRepo) postgres.Repository { return r }},
+		// Taken after the check; the insert finds the conflict.
+		{"at the insert", func(r *linkRepo) postgres.Repository { return racedRepo{r} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newLinkRepo("taken1")
+			uc := newLinkTestUseCase(tt.repo(repo), &scriptedHashes{})
+
+			resp, err := uc.ImportLinks(context.Background(), 1, usecase.BulkFormatCSV, strings.NewReader(importCSV), false)
+			if err != nil {
+				t.Fatalf("ImportLinks() error = %v", err)
+			}
+			if resp.Imported != 2 || resp.Failed != 1 {
+				t.Errorf("imported %d, failed %d; want 2 and 1", resp.Imported, resp.Failed)
+			}
+			for i, row := range resp.Rows {
+				failed := i == 1
+				if (row.Error != "") != failed {
+					t.Errorf("row %d error = %q, want failed = %v", row.Row, row.Error, failed)
+				}
+				if (row.ID != nil) == failed {
+					t.Errorf("row %d id = %v, want created = %v", row.Row, row.ID, !failed)
+				}
+			}
+			if !strings.Contains(resp.Rows[1].Error, "already in use") {
+				t.Errorf("row 2 error = %q, want the hash reported as taken", resp.Rows[1].Error)
+			}
+		})
+	}
+}
+
+// exportRepo exports the given rows and records the links and QR codes an
+// import creates.
+type exportRepo struct {
+	*linkRepo
+	rows  []sqldb.GetLinksForExportRow
+	links []sqldb.CreateLinkParams
+	qrs   []sqldb.CreateQRCodeParams
+}
+
+func (r *exportRepo) WithTX(tx pgx.Tx) postgres.Repository { return r }
+
+func (r *exportRepo) GetLinksForExport(ctx context.Context, userID int64) ([]sqldb.GetLinksForExportRow, error) {
+	return r.rows, nil
+}
+
+func (r *exportRepo) CreateLink(ctx context.Context, arg sqldb.CreateLinkParams) (sqldb.Link, error) {
+	link, err := r.linkRepo.CreateLink(ctx, arg)
+	if err == nil {
+		r.links = append(r.links, arg)
+	}
+	return link, err
+}
+
+func (r *exportRepo) CreateQRCode(ctx context.Context, arg sqldb.CreateQRCodeParams) (sqldb.QrCode, error) {
+	r.qrs = append(r.qrs, arg)
+	return r.linkRepo.CreateQRCode(ctx, arg)
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	smoothing := 0.25
+	fileName := "menu.pdf"
+	exported := []sqldb.GetLinksForExportRow{
+		{
+			Name:         "plain, with a comma",
+			OriginalUrl:  "https://example.org/plain",
+			Hash:         "plain1",
+			Color:        "000000",
+			Background:   "FFFFFF",
+			Smoothing:    &smoothing,
+			RedirectMode: "302",
+		},
+		{
+			Name:         "careful",
+			OriginalUrl:  "https://example.org/careful",
+			Hash:         "careful1",
+			Color:        "112233",
+			Background:   "FFEEDD",
+			Interstitial: true,
+			RedirectMode: "meta_refresh",
+		},
+		{
+			Name:         "file",
+			Hash:         "file1",
+			Color:        "000000",
+			Background:   "FFFFFF",
+			RedirectMode: "302",
+			FileName:     &fileName,
+		},
+	}
+
+	for _, format := range []string{usecase.BulkFormatCSV, usecase.BulkFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			source := newLinkTestUseCase(&exportRepo{linkRepo: newLinkRepo(), rows: exported}, &scriptedHashes{})
+			if err := source.ExportLinks(context.Background(), 1, format, &buf); err != nil {
+				t.Fatalf("ExportLinks() error = %v", err)
+			}
+
+			repo := &exportRepo{linkRepo: newLinkRepo()}
+			uc := newLinkTestUseCase(repo, &scriptedHashes{})
+			resp, err := uc.ImportLinks(context.Background(), 1, format, &buf, false)
+			if err != nil {
+				t.Fatalf("ImportLinks() error = %v", err)
+			}
+
+			// The file link is reported, not imported without its file.
+			if resp.Imported != 2 || resp.Failed != 1 {
+				t.Fatalf("imported %d, failed %d; want 2 and 1", resp.Imported, resp.Failed)
+			}
+			if errMsg := resp.Rows[2].Error; !strings.Contains(errMsg, "file links cannot be imported") || !strings.Contains(errMsg, fileName) {
+				t.Errorf("file row error = %q, want it to name the file", errMsg)
+			}
+
+			for i, link := range repo.links {
+				want := exported[i]
+				if link.Name != want.Name || link.OriginalUrl != want.OriginalUrl || link.Hash != want.Hash ||
+					link.Interstitial != want.Interstitial || link.RedirectMode != want.RedirectMode {
+					t.Errorf("link %d = %+v, want it to match %+v", i, link, want)
+				}
+				qr := repo.qrs[i]
+				if qr.Color != want.Color || qr.Background != want.Background {
+					t.Errorf("qr code %d colors = %s on %s, want %s on %s", i, qr.Color, qr.Background, want.Color, want.Background)
+				}
+			}
+			if got := repo.qrs[0].Smoothing; got == nil || *got != smoothing {
+				t.Errorf("smoothing = %v, want %v", got, smoothing)
+			}
+		})
+	}
+}
+
+func TestImportLinksInvalidRedirectFields(t *testing.T) {
+	const csv = `name,original_url,interstitial,redirect_mode
+bad mode,https://example.org/1,,303
+bad flag,https://example.org/2,maybe,
+`
+	uc := newLinkTestUseCase(newLinkRepo(), &scriptedHashes{})
+	resp, err := uc.ImportLinks(context.Background(), 1, usecase.BulkFormatCSV, strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatalf("ImportLinks() error = %v", err)
+	}
+	for i, field := range []string{"redirect_mode", "interstitial"} {
+		if !strings.Contains(resp.Rows[i].Error, field) {
+			t.Errorf("row %d error = %q, want %s rejected", i+1, resp.Rows[i].Error, field)
+		}
+	}
+}
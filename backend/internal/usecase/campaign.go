@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+)
+
+// campaignStats adds up the transitions of every link in a folder or carrying
+// a tag. Exactly one of folderID and tagID is set.
+func campaignStats(ctx context.Context, repo postgres.Repository, userID int64, folderID, tagID *int64, rng dto.StatsRange) (*dto.CampaignStatsResponse, error) {
+	links, err := repo.GetCampaignLinkStats(ctx, sqldb.GetCampaignLinkStatsParams{
+		FromTime: rng.From,
+		ToTime:   rng.To,
+		UserID:   userID,
+		FolderID: folderID,
+		TagID:    tagID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign link stats: %w", err)
+	}
+
+	daily, err := repo.GetCampaignDailyTransitions(ctx, sqldb.GetCampaignDailyTransitionsParams{
+		UserID:   userID,
+		FolderID: folderID,
+		TagID:    tagID,
+		FromTime: rng.From,
+		ToTime:   rng.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign daily transitions: %w", err)
+	}
+
+	resp := &dto.CampaignStatsResponse{
+		From:       rng.From,
+		To:         rng.To,
+		LinksCount: len(links),
+		Daily:      make([]dto.DailyTransitions, len(daily)),
+		Links:      make([]dto.CampaignLinkStats, len(links)),
+	}
+	for i, l := range links {
+		resp.Transitions += l.TransitionsCount
+		resp.Links[i] = dto.CampaignLinkStats{
+			ID:               l.ID,
+			Name:             l.Name,
+			Hash:             l.Hash,
+			Transitions:      l.TransitionsCount,
+			LastTransitionAt: l.LastTransitionAt,
+		}
+	}
+	for i, d := range daily {
+		resp.Daily[i] = dto.DailyTransitions{Date: d.Day, Transitions: d.TransitionsCount}
+	}
+
+	return resp, nil
+}
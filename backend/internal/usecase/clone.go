@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CloneLink copies a link, its QR styling, folder, tags, schedule and hosted
+// file under a new hash. The copy starts without transitions and keeps a
+// reference to its source.
+func (uc *LinkUseCase) CloneLink(ctx context.Context, linkID, userID int64, req dto.CloneLinkRequest) (*dto.CreateLinkResponse, error) {
+	var overrideURL string
+	if req.OriginalURL != "" {
+		var err error
+		overrideURL, err = uc.policy.Check(ctx, req.OriginalURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	source, err := repoWithTx.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get link by id: %w", err)
+	}
+
+	linkParams := sqldb.CreateLinkParams{
+		OriginalUrl:  source.OriginalUrl,
+		UserID:       userID,
+		Name:         source.Name,
+		DomainID:     source.DomainID,
+		ClonedFrom:   &source.ID,
+		Interstitial: source.Interstitial,
+		RedirectMode: source.RedirectMode,
+	}
+	if overrideURL != "" {
+		linkParams.OriginalUrl = overrideURL
+	}
+	if req.Name != "" {
+		linkParams.Name = req.Name
+	}
+	if req.DomainID != nil {
+		linkParams.DomainID = req.DomainID
+	}
+	if err := checkLinkDomain(ctx, repoWithTx, linkParams.DomainID, userID); err != nil {
+		return nil, err
+	}
+
+	qrParams := sqldb.CreateQRCodeParams{
+		Color:      source.Color,
+		Background: source.Background,
+		Smoothing:  source.Smoothing,
+	}
+	if req.Color != "" {
+		qrParams.Color = req.Color
+	}
+	if req.Background != "" {
+		qrParams.Background = req.Background
+	}
+	if req.Smoothing != nil {
+		qrParams.Smoothing = req.Smoothing
+	}
+
+	createdLink, err := uc.insertLink(ctx, repoWithTx, linkParams, qrParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.FolderID != nil {
+		if _, err := repoWithTx.SetLinkFolder(ctx, sqldb.SetLinkFolderParams{FolderID: source.FolderID, ID: createdLink.ID, UserID: userID}); err != nil {
+			return nil, fmt.Errorf("failed to set link folder: %w", err)
+		}
+	}
+
+	tags, err := repoWithTx.GetTagsByLinkID(ctx, source.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link tags: %w", err)
+	}
+	if len(tags) > 0 {
+		tagIDs := make([]int64, len(tags))
+		for i, t := range tags {
+			tagIDs[i] = t.ID
+		}
+		if err := repoWithTx.AddLinkTags(ctx, sqldb.AddLinkTagsParams{LinkID: createdLink.ID, TagIds: tagIDs}); err != nil {
+			return nil, fmt.Errorf("failed to add link tags: %w", err)
+		}
+	}
+
+	if err := copyLinkSchedules(ctx, repoWithTx, source.ID, createdLink.ID); err != nil {
+		return nil, err
+	}
+
+	// A destination override turns a cloned file link into a URL link.
+	var copiedKey string
+	if source.FileID != nil && overrideURL == "" {
+		copiedKey, err = uc.copyLinkFile(ctx, repoWithTx, *source.FileID, createdLink)
+		if err != nil {
+			return nil, err
+		}
+	}
+	committed := false
+	defer func() {
+		if !committed && copiedKey != "" {
+			uc.removeStoredFile(copiedKey)
+		}
+	}()
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+	uc.cache.Invalidate(createdLink.Hash)
+	if createdLink.Interstitial && copiedKey == "" {
+		uc.previews.Refresh(createdLink.ID, createdLink.OriginalUrl)
+	}
+
+	return &dto.CreateLinkResponse{
+		ID:      createdLink.ID,
+		Message: "Link cloned successfully",
+	}, nil
+}
+
+// copyLinkFile stores a copy of a hosted file for link and attaches it. It
+// returns the new storage key, which the caller removes if it rolls back.
+func (uc *LinkUseCase) copyLinkFile(ctx context.Context, repoWithTx postgres.Repository, fileID int64, link sqldb.Link) (string, error) {
+	file, err := repoWithTx.GetLinkFile(ctx, fileID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get link file: %w", err)
+	}
+
+	body, err := uc.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to open stored file: %w", err)
+	}
+	defer body.Close()
+
+	key := newStorageKey(link.Hash)
+	if err := uc.storage.Put(ctx, key, body, file.Size, file.ContentType); err != nil {
+		return "", fmt.Errorf("failed to store file: %w", err)
+	}
+
+	fileParams := sqldb.CreateLinkFileParams{
+		LinkID:      link.ID,
+		StorageKey:  key,
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		Size:        file.Size,
+	}
+	copied, err := repoWithTx.CreateLinkFile(ctx, fileParams)
+	if err != nil {
+		uc.removeStoredFile(key)
+		return "", fmt.Errorf("failed to create link file: %w", err)
+	}
+
+	if err := repoWithTx.SetLinkFile(ctx, sqldb.SetLinkFileParams{FileID: &copied.ID, ID: link.ID}); err != nil {
+		uc.removeStoredFile(key)
+		return "", fmt.Errorf("failed to set link file: %w", err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	domainVerifyRecordPrefix = "_qrcodegen."
+	domainVerifyValuePrefix  = "qrcodegen-verification="
+	domainTokenBytes         = 16
+
+	pgUniqueViolation = "23505"
+)
+
+var (
+	ErrDomainNotFound           = errors.New("domain not found or access denied")
+	ErrDomainAlreadyExists      = errors.New("domain is already registered")
+	ErrDomainReserved           = errors.New("domain is reserved by the service")
+	ErrDomainNotVerified        = errors.New("domain ownership is not verified")
+	ErrDomainVerificationFailed = errors.New("verification TXT record not found")
+	ErrDomainInUse              = errors.New("domain is used by existing links")
+)
+
+// TXTResolver looks up DNS TXT records. *net.Resolver satisfies it; tests
+// can swap in a stub that returns canned records.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type DomainUseCase struct {
+	repo     postgres.Repository
+	cache    *RedirectCache
+	resolver TXTResolver
+	cfg      *config.Config
+}
+
+func NewDomainUseCase(repo postgres.Repository, cache *RedirectCache, resolver TXTResolver, cfg *config.Config) *DomainUseCase {
+	return &DomainUseCase{repo: repo, cache: cache, resolver: resolver, cfg: cfg}
+}
+
+func (uc *DomainUseCase) CreateDomain(ctx context.Context, req dto.CreateDomainRequest, userID int64) (*dto.CreateDomainResponse, error) {
+	host := normalizeHost(req.Host)
+	if host == appHost(uc.cfg) {
+		return nil, ErrDomainReserved
+	}
+
+	// Unverified claims by other users do not block this one: whoever
+	// publishes the TXT record first owns the host.
+	_, err := uc.repo.GetVerifiedDomainByHost(ctx, host)
+	if err == nil {
+		return nil, ErrDomainAlreadyExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check domain existence: %w", err)
+	}
+	_, err = uc.repo.GetDomainByUserAndHost(ctx, sqldb.GetDomainByUserAndHostParams{UserID: userID, Host: host})
+	if err == nil {
+		return nil, ErrDomainAlreadyExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check domain existence: %w", err)
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	domain, err := uc.repo.CreateDomain(ctx, sqldb.CreateDomainParams{
+		UserID:            userID,
+		Host:              host,
+		VerificationToken: token,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDomainAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to create domain: %w", err)
+	}
+
+	return &dto.CreateDomainResponse{
+		Domain:  toDomainInfo(domain),
+		Message: "Domain created, add the TXT record and verify it",
+	}, nil
+}
+
+func (uc *DomainUseCase) GetDomains(ctx context.Context, userID int64) (*dto.GetDomainsResponse, error) {
+	domains, err := uc.repo.GetDomainsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domains by user id: %w", err)
+	}
+
+	infos := make([]dto.DomainInfo, len(domains))
+	for i, d := range domains {
+		infos[i] = toDomainInfo(d)
+	}
+	return &dto.GetDomainsResponse{Domains: infos}, nil
+}
+
+// VerifyDomain checks that the TXT record announced on domain creation is
+// published and marks the domain as verified. When another user verified
+// the same host first, the claim fails with ErrDomainAlreadyExists.
+func (uc *DomainUseCase) VerifyDomain(ctx context.Context, domainID, userID int64) (*dto.VerifyDomainResponse, error) {
+	domain, err := uc.repo.GetDomainByID(ctx, sqldb.GetDomainByIDParams{ID: domainID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to get domain by id: %w", err)
+	}
+
+	if domain.VerifiedAt == nil {
+		records, err := uc.resolver.LookupTXT(ctx, domainVerifyRecordPrefix+domain.Host)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+				return nil, ErrDomainVerificationFailed
+			}
+			return nil, fmt.Errorf("failed to lookup TXT records: %w", err)
+		}
+		if !hasVerificationRecord(records, domain.VerificationToken) {
+			return nil, ErrDomainVerificationFailed
+		}
+
+		domain, err = uc.repo.MarkDomainVerified(ctx, sqldb.MarkDomainVerifiedParams{ID: domainID, UserID: userID})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return nil, ErrDomainAlreadyExists
+			}
+			return nil, fmt.Errorf("failed to mark domain verified: %w", err)
+		}
+		uc.domainChanged(ctx, domain.Host)
+	}
+
+	return &dto.VerifyDomainResponse{
+		Domain:  toDomainInfo(domain),
+		Message: "Domain verified successfully",
+	}, nil
+}
+
+func (uc *DomainUseCase) DeleteDomain(ctx context.Context, domainID, userID int64) error {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	domain, err := repoWithTx.GetDomainByID(ctx, sqldb.GetDomainByIDParams{ID: domainID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrDomainNotFound
+		}
+		return fmt.Errorf("failed to verify domain ownership: %w", err)
+	}
+
+	count, err := repoWithTx.CountLinksByDomain(ctx, &domainID)
+	if err != nil {
+		return fmt.Errorf("failed to count domain links: %w", err)
+	}
+	if count > 0 {
+		return ErrDomainInUse
+	}
+
+	rowsAffected, err := repoWithTx.DeleteDomain(ctx, sqldb.DeleteDomainParams{ID: domainID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete domain: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrDomainNotFound
+	}
+
+	if err := repoWithTx.NotifyDomainChanged(ctx, domain.Host); err != nil {
+		return fmt.Errorf("failed to notify domain change: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	uc.cache.InvalidateDomain(domain.Host)
+
+	return nil
+}
+
+// domainChanged drops host from the redirect caches of this and, through
+// the link changes channel, every other instance. The change is already
+// stored, so a failed notification only delays other instances until
+// their entry expires.
+func (uc *DomainUseCase) domainChanged(ctx context.Context, host string) {
+	uc.cache.InvalidateDomain(host)
+	if err := uc.repo.NotifyDomainChanged(ctx, host); err != nil {
+		log.Error().Err(err).Str("host", host).Msg("Failed to notify domain change")
+	}
+}
+
+func toDomainInfo(d sqldb.Domain) dto.DomainInfo {
+	return dto.DomainInfo{
+		ID:             d.ID,
+		Host:           d.Host,
+		Verified:       d.VerifiedAt != nil,
+		VerifiedAt:     d.VerifiedAt,
+		CreatedAt:      d.CreatedAt,
+		TXTRecordName:  domainVerifyRecordPrefix + d.Host,
+		TXTRecordValue: domainVerifyValuePrefix + d.VerificationToken,
+	}
+}
+
+func hasVerificationRecord(records []string, token string) bool {
+	expected := domainVerifyValuePrefix + token
+	for _, r := range records {
+		if strings.TrimSpace(r) == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// isUniqueViolation reports whether err comes from a UNIQUE constraint, as
+// when two requests claim the same host at once.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+func generateVerificationToken() (string, error) {
+	bytes := make([]byte, domainTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// normalizeHost lowercases a host and strips the port and trailing dot, so
+// values from the Host header compare equal to the stored domain.
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(strings.ToLower(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+func appHost(cfg *config.Config) string {
+	u, err := url.Parse(cfg.AppBaseURL)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Host)
+}
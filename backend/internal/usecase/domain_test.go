@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// domainRepo keeps domains in memory. Methods the tests do not need fall
+// through to the nil embedded Repository and panic.
+type domainRepo struct {
+	postgres.Repository
+	domains   []sqldb.Domain
+	createErr error
+	notified  []string
+}
+
+func (r *domainRepo) NotifyDomainChanged(ctx context.Context, host string) error {
+	r.notified = append(r.notified, host)
+	return nil
+}
+
+func (r *domainRepo) GetVerifiedDomainByHost(ctx context.Context, host string) (sqldb.Domain, error) {
+	for _, d := range r.domains {
+		if d.Host == host && d.VerifiedAt != nil {
+			return d, nil
+		}
+	}
+	return sqldb.Domain{}, pgx.ErrNoRows
+}
+
+func (r *domainRepo) GetDomainByUserAndHost(ctx context.Context, arg sqldb.GetDomainByUserAndHostParams) (sqldb.Domain, error) {
+	for _, d := range r.domains {
+		if d.UserID == arg.UserID && d.Host == arg.Host {
+			return d, nil
+		}
+	}
+	return sqldb.Domain{}, pgx.ErrNoRows
+}
+
+func (r *domainRepo) GetDomainByID(ctx context.Context, arg sqldb.GetDomainByIDParams) (sqldb.Domain, error) {
+	for _, d := range r.domains {
+		if d.ID == arg.ID && d.UserID == arg.UserID {
+			return d, nil
+		}
+	}
+	return sqldb.Domain{}, pgx.ErrNoRows
+}
+
+func (r *domainRepo) CreateDomain(ctx context.Context, arg sqldb.CreateDomainParams) (sqldb.Domain, error) {
+	if r.createErr != nil {
+		return sqldb.Domain{}, r.createErr
+	}
+	d := sqldb.Domain{
+		ID:                int64(len(r.domains) + 1),
+		UserID:            arg.UserID,
+		Host:              arg.Host,
+		VerificationToken: arg.VerificationToken,
+		CreatedAt:         time.Now(),
+	}
+	r.domains = append(r.domains, d)
+	return d, nil
+}
+
+func (r *domainRepo) MarkDomainVerified(ctx context.Context, arg sqldb.MarkDomainVerifiedParams) (sqldb.Domain, error) {
+	for i, d := range r.domains {
+		if d.ID == arg.ID && d.UserID == arg.UserID {
+			now := time.Now()
+			r.domains[i].VerifiedAt = &now
+			return r.domains[i], nil
+		}
+	}
+	return sqldb.Domain{}, pgx.ErrNoRows
+}
+
+// stubTXT answers every lookup with the same records or error.
+type stubTXT struct {
+	records []string
+	err     error
+	names   []string
+}
+
+func (s *stubTXT) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	s.names = append(s.names, name)
+	return s.records, s.err
+}
+
+func newDomainTestUseCase(repo *domainRepo, resolver TXTResolver) *DomainUseCase {
+	cfg := &config.Config{AppBaseURL: "https://qr.example.com", RedirectCacheSize: 10}
+	return NewDomainUseCase(repo, NewRedirectCache(cfg), resolver, cfg)
+}
+
+func TestVerifyDomain(t *testing.T) {
+	const token = "0123456789abcdef"
+
+	tests := []struct {
+		name     string
+		resolver *stubTXT
+		wantErr  error
+		verified bool
+	}{
+		{
+			name:     "matching token",
+			resolver: &stubTXT{records: []string{"v=spf1 -all", " qrcodegen-verification=" + token + " "}},
+			verified: true,
+		},
+		{
+			name:     "wrong token",
+			resolver: &stubTXT{records: []string{"qrcodegen-verification=fedcba9876543210"}},
+			wantErr:  ErrDomainVerificationFailed,
+		},
+		{
+			name:     "record missing",
+			resolver: &stubTXT{err: &net.DNSError{Err: "no such host", Name: "_qrcodegen.go.example.org", IsNotFound: true}},
+			wantErr:  ErrDomainVerificationFailed,
+		},
+		{
+			name:     "lookup failure",
+			resolver: &stubTXT{err: &net.DNSError{Err: "server misbehaving", Name: "_qrcodegen.go.example.org", IsTemporary: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &domainRepo{domains: []sqldb.Domain{{ID: 1, UserID: 7, Host: "go.example.org", VerificationToken: token}}}
+			uc := newDomainTestUseCase(repo, tt.resolver)
+
+			resp, err := uc.VerifyDomain(context.Background(), 1, 7)
+			switch {
+			case tt.verified:
+				if err != nil {
+					t.Fatalf("VerifyDomain() error = %v", err)
+				}
+				if !resp.Domain.Verified {
+					t.Error("domain not reported as verified")
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifyDomain() error = %v, want %v", err, tt.wantErr)
+				}
+			default:
+				if err == nil || errors.Is(err, ErrDomainVerificationFailed) {
+					t.Fatalf("VerifyDomain() error = %v, want a lookup error", err)
+				}
+			}
+
+			if got := repo.domains[0].VerifiedAt != nil; got != tt.verified {
+				t.Errorf("stored verified = %v, want %v", got, tt.verified)
+			}
+			if got := len(repo.notified) == 1 && repo.notified[0] == "go.example.org"; got != tt.verified {
+				t.Errorf("notified %v, want a notification only when verified", repo.notified)
+			}
+			if len(tt.resolver.names) != 1 || tt.resolver.names[0] != "_qrcodegen.go.example.org" {
+				t.Errorf("looked up %v, want [_qrcodegen.go.example.org]", tt.resolver.names)
+			}
+		})
+	}
+}
+
+func TestVerifyDomainAlreadyVerified(t *testing.T) {
+	now := time.Now()
+	repo := &domainRepo{domains: []sqldb.Domain{{ID: 1, UserID: 7, Host: "go.example.org", VerifiedAt: &now}}}
+	resolver := &stubTXT{err: errors.New("must not be called")}
+
+	if _, err := newDomainTestUseCase(repo, resolver).VerifyDomain(context.Background(), 1, 7); err != nil {
+		t.Fatalf("VerifyDomain() error = %v", err)
+	}
+	if len(resolver.names) != 0 {
+		t.Errorf("looked up %v for a verified domain", resolver.names)
+	}
+}
+
+func TestCreateDomain(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		domains   []sqldb.Domain
+		host      string
+		createErr error
+		wantErr   error
+	}{
+		{
+			name: "new host",
+			host: "Go.Example.org.",
+		},
+		{
+			name:    "unverified claim by another user",
+			domains: []sqldb.Domain{{ID: 1, UserID: 8, Host: "go.example.org"}},
+			host:    "go.example.org",
+		},
+		{
+			name:    "verified by another user",
+			domains: []sqldb.Domain{{ID: 1, UserID: 8, Host: "go.example.org", VerifiedAt: &now}},
+			host:    "go.example.org",
+			wantErr: ErrDomainAlreadyExists,
+		},
+		{
+			name:    "claimed twice by the same user",
+			domains: []sqldb.Domain{{ID: 1, UserID: 7, Host: "go.example.org"}},
+			host:    "go.example.org",
+			wantErr: ErrDomainAlreadyExists,
+		},
+		{
+			name:      "concurrent claim",
+			host:      "go.example.org",
+			createErr: &pgconn.PgError{Code: "23505", ConstraintName: "domains_user_id_host_key"},
+			wantErr:   ErrDomainAlreadyExists,
+		},
+		{
+			name:    "service host",
+			host:    "qr.example.com:443",
+			wantErr: ErrDomainReserved,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &domainRepo{domains: tt.domains, createErr: tt.createErr}
+			uc := newDomainTestUseCase(repo, &stubTXT{})
+
+			resp, err := uc.CreateDomain(context.Background(), dto.CreateDomainRequest{Host: tt.host}, 7)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateDomain() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateDomain() error = %v", err)
+			}
+			if resp.Domain.Host != "go.example.org" {
+				t.Errorf("host = %q, want go.example.org", resp.Domain.Host)
+			}
+			if resp.Domain.TXTRecordValue != domainVerifyValuePrefix+repo.domains[len(repo.domains)-1].VerificationToken {
+				t.Errorf("TXT value %q does not carry the stored token", resp.Domain.TXTRecordValue)
+			}
+		})
+	}
+}
+
+func TestVerifyDomainClearsCachedMiss(t *testing.T) {
+	const token = "0123456789abcdef"
+	repo := &domainRepo{domains: []sqldb.Domain{{ID: 1, UserID: 7, Host: "go.example.org", VerificationToken: token}}}
+	uc := newDomainTestUseCase(repo, &stubTXT{records: []string{domainVerifyValuePrefix + token}})
+
+	// A scan before verification caches the host as no custom domain.
+	uc.cache.SetDomain("go.example.org", nil, uc.cache.Generation())
+
+	if _, err := uc.VerifyDomain(context.Background(), 1, 7); err != nil {
+		t.Fatalf("VerifyDomain() error = %v", err)
+	}
+	if _, _, ok := uc.cache.GetDomain("go.example.org"); ok {
+		t.Error("cached miss survived verification")
+	}
+}
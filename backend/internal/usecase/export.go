@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/pkg/xlsx"
+	"qrcodegen/internal/repository/postgres"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+var ErrInvalidExportQuery = errors.New("invalid export query")
+
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:    "text/csv; charset=utf-8",
+	ExportFormatNDJSON: "application/x-ndjson",
+	ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// transitionExportColumns lists the exportable columns in their default
+// order.
+var transitionExportColumns = []string{
+	"id", "link_id", "link_hash", "link_name", "created_at", "country", "region", "city", "geo_source",
+	"referer", "user_agent", "browser", "os", "via_preview", "revision_id", "schedule_id", "visitor_hash",
+}
+
+// TransitionExport is a checked export request, ready to be streamed.
+type TransitionExport struct {
+	repo    postgres.Repository
+	params  postgres.StreamTransitionsParams
+	format  string
+	columns []string
+}
+
+// rowWriter is implemented by the writers of every export format.
+type rowWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// ExportTransitions checks an export of raw transitions, so that errors can
+// be reported before any output is written.
+func (uc *LinkUseCase) ExportTransitions(ctx context.Context, userID int64, query dto.TransitionExportQuery) (*TransitionExport, error) {
+	if query.Format == "" {
+		query.Format = ExportFormatCSV
+	}
+	if _, ok := exportContentTypes[query.Format]; !ok {
+		return nil, fmt.Errorf("%w: format must be csv, ndjson or xlsx", ErrInvalidExportQuery)
+	}
+
+	columns := transitionExportColumns
+	if len(query.Columns) > 0 {
+		columns = query.Columns
+		for _, c := range columns {
+			if !slices.Contains(transitionExportColumns, c) {
+				return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidExportQuery, c)
+			}
+		}
+	}
+
+	if query.LinkID != nil {
+		if _, err := ownedLinkHash(ctx, uc.repo, *query.LinkID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TransitionExport{
+		repo: uc.repo,
+		params: postgres.StreamTransitionsParams{
+			UserID:   userID,
+			LinkID:   query.LinkID,
+			FromTime: query.Range.From,
+			ToTime:   query.Range.To,
+		},
+		format:  query.Format,
+		columns: columns,
+	}, nil
+}
+
+func (e *TransitionExport) ContentType() string {
+	return exportContentTypes[e.format]
+}
+
+func (e *TransitionExport) Filename() string {
+	if e.params.LinkID != nil {
+		return fmt.Sprintf("transitions-%d.%s", *e.params.LinkID, e.format)
+	}
+	return "transitions." + e.format
+}
+
+// Write streams the transitions to w one row at a time, so memory use does
+// not depend on their number.
+func (e *TransitionExport) Write(ctx context.Context, w io.Writer) error {
+	var rw rowWriter
+	switch e.format {
+	case ExportFormatNDJSON:
+		rw = newNDJSONRowWriter(w, e.columns)
+	case ExportFormatXLSX:
+		rw = xlsx.NewWriter(w, "Transitions", e.columns)
+	default:
+		cw, err := newCSVRowWriter(w, e.columns)
+		if err != nil {
+			return err
+		}
+		rw = cw
+	}
+
+	values := make([]any, len(e.columns))
+	err := e.repo.StreamTransitions(ctx, e.params, func(r postgres.StreamTransitionsRow) error {
+		for i, c := range e.columns {
+			values[i] = transitionExportValue(r, c)
+		}
+		return rw.WriteRow(values)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export transitions: %w", err)
+	}
+	return rw.Close()
+}
+
+func transitionExportValue(r postgres.StreamTransitionsRow, column string) any {
+	switch column {
+	case "id":
+		return r.ID
+	case "link_id":
+		return r.LinkID
+	case "link_hash":
+		return r.LinkHash
+	case "link_name":
+		return r.LinkName
+	case "created_at":
+		return r.CreatedAt
+	case "country":
+		return valueOrNil(r.Country)
+	case "region":
+		return valueOrNil(r.Region)
+	case "city":
+		return valueOrNil(r.City)
+	case "geo_source":
+		return valueOrNil(r.GeoSource)
+	case "referer":
+		return valueOrNil(r.Referer)
+	case "user_agent":
+		return valueOrNil(r.UserAgent)
+	case "browser":
+		return valueOrNil(r.Browser)
+	case "os":
+		return valueOrNil(r.Os)
+	case "via_preview":
+		return r.ViaPreview
+	case "revision_id":
+		return valueOrNil(r.RevisionID)
+	case "schedule_id":
+		return valueOrNil(r.ScheduleID)
+	case "visitor_hash":
+		return valueOrNil(r.VisitorHash)
+	}
+	return nil
+}
+
+// valueOrNil turns a nil pointer into an untyped nil, so writers can tell
+// missing values apart.
+func valueOrNil[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+type csvRowWriter struct {
+	cw     *csv.Writer
+	record []string
+}
+
+func newCSVRowWriter(w io.Writer, columns []string) (*csvRowWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{cw: cw, record: make([]string, len(columns))}, nil
+}
+
+func (w *csvRowWriter) WriteRow(values []any) error {
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			w.record[i] = ""
+		case string:
+			w.record[i] = csvSafeText(v)
+		case int64:
+			w.record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			w.record[i] = strconv.FormatBool(v)
+		case time.Time:
+			w.record[i] = v.UTC().Format(time.RFC3339)
+		default:
+			w.record[i] = fmt.Sprint(v)
+		}
+	}
+	return w.cw.Write(w.record)
+}
+
+func (w *csvRowWriter) Close() error {
+	w.cw.Flush()
+	return w.cw.Error()
+}
+
+// csvSafeText keeps spreadsheet apps from evaluating scanner-controlled text
+// such as referers as a formula.
+func csvSafeText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type ndjsonRowWriter struct {
+	bw   *bufio.Writer
+	keys [][]byte
+}
+
+func newNDJSONRowWriter(w io.Writer, columns []string) *ndjsonRowWriter {
+	keys := make([][]byte, len(columns))
+	for i, c := range columns {
+		keys[i], _ = json.Marshal(c)
+	}
+	return &ndjsonRowWriter{bw: bufio.NewWriter(w), keys: keys}
+}
+
+// WriteRow writes one object per line with the keys in column order.
+func (w *ndjsonRowWriter) WriteRow(values []any) error {
+	w.bw.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			w.bw.WriteByte(',')
+		}
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC()
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.bw.Write(w.keys[i])
+		w.bw.WriteByte(':')
+		w.bw.Write(b)
+	}
+	_, err := w.bw.WriteString("}\n")
+	return err
+}
+
+func (w *ndjsonRowWriter) Close() error {
+	return w.bw.Flush()
+}
@@ -0,0 +1,4 @@
+package usecase
+
+// MaxHashAttempts exposes the retry limit of insertLink to external tests.
+const MaxHashAttempts = maxHashAttempts
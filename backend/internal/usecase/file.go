@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	sniffLength       = 512
+	maxFilenameLength = 255
+)
+
+var (
+	ErrFileEmpty           = errors.New("file is empty")
+	ErrFileTooLarge        = errors.New("file exceeds the maximum allowed size")
+	ErrFileTypeNotAllowed  = errors.New("file type is not allowed")
+	ErrStoredFileNotFound  = errors.New("stored file not found")
+	ErrOriginalURLRequired = errors.New("original_url is required for links without a hosted file")
+	ErrRevisionFileGone    = errors.New("revision points at a hosted file that is no longer attached")
+)
+
+// FileStorage keeps the content of hosted files. Keys are slash-separated
+// paths chosen by the use case.
+type FileStorage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// FileUpload is a file received from a client. Its content type is sniffed
+// from Body rather than taken from the request.
+type FileUpload struct {
+	Filename string
+	Size     int64
+	Body     io.Reader
+}
+
+// CreateFileLink creates a link whose scans serve the uploaded file instead
+// of redirecting.
+func (uc *LinkUseCase) CreateFileLink(ctx context.Context, req dto.CreateFileLinkRequest, upload FileUpload, userID int64) (*dto.CreateLinkResponse, error) {
+	body, contentType, err := uc.inspectUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	if err := checkLinkDomain(ctx, repoWithTx, req.DomainID, userID); err != nil {
+		return nil, err
+	}
+
+	linkParams := sqldb.CreateLinkParams{
+		UserID:   userID,
+		Name:     req.Name,
+		DomainID: req.DomainID,
+	}
+	createdLink, err := uc.insertLink(ctx, repoWithTx, linkParams, defaultQRCodeParams())
+	if err != nil {
+		return nil, err
+	}
+
+	key := newStorageKey(createdLink.Hash)
+	if err := uc.storage.Put(ctx, key, body, upload.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			uc.removeStoredFile(key)
+		}
+	}()
+
+	fileParams := sqldb.CreateLinkFileParams{
+		LinkID:      createdLink.ID,
+		StorageKey:  key,
+		Filename:    cleanFilename(upload.Filename),
+		ContentType: contentType,
+		Size:        upload.Size,
+	}
+	file, err := repoWithTx.CreateLinkFile(ctx, fileParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link file: %w", err)
+	}
+
+	if err := repoWithTx.SetLinkFile(ctx, sqldb.SetLinkFileParams{FileID: &file.ID, ID: createdLink.ID}); err != nil {
+		return nil, fmt.Errorf("failed to set link file: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+	uc.cache.Invalidate(createdLink.Hash)
+
+	return &dto.CreateLinkResponse{
+		ID:      createdLink.ID,
+		Message: "Link created successfully",
+	}, nil
+}
+
+// ReplaceLinkFile uploads a new file for the link, keeping its hash. A link
+// that redirected to a URL starts serving the file instead.
+func (uc *LinkUseCase) ReplaceLinkFile(ctx context.Context, linkID, userID int64, upload FileUpload) (*dto.UploadLinkFileResponse, error) {
+	body, contentType, err := uc.inspectUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	link, err := repoWithTx.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get link by id: %w", err)
+	}
+
+	key := newStorageKey(link.Hash)
+	if err := uc.storage.Put(ctx, key, body, upload.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			uc.removeStoredFile(key)
+		}
+	}()
+
+	filename := cleanFilename(upload.Filename)
+	var replacedKey string
+	if link.FileID != nil {
+		current, err := repoWithTx.GetLinkFile(ctx, *link.FileID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get link file: %w", err)
+		}
+		replacedKey = current.StorageKey
+
+		updateParams := sqldb.UpdateLinkFileParams{
+			StorageKey:  key,
+			Filename:    filename,
+			ContentType: contentType,
+			Size:        upload.Size,
+			ID:          current.ID,
+		}
+		if err := repoWithTx.UpdateLinkFile(ctx, updateParams); err != nil {
+			return nil, fmt.Errorf("failed to update link file: %w", err)
+		}
+	} else {
+		fileParams := sqldb.CreateLinkFileParams{
+			LinkID:      link.ID,
+			StorageKey:  key,
+			Filename:    filename,
+			ContentType: contentType,
+			Size:        upload.Size,
+		}
+		file, err := repoWithTx.CreateLinkFile(ctx, fileParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create link file: %w", err)
+		}
+
+		if err := repoWithTx.SetLinkFile(ctx, sqldb.SetLinkFileParams{FileID: &file.ID, ID: link.ID}); err != nil {
+			return nil, fmt.Errorf("failed to set link file: %w", err)
+		}
+
+		state := sqldb.CreateLinkRevisionParams{
+			LinkID:      link.ID,
+			UserID:      userID,
+			Action:      RevisionActionEdit,
+			OriginalUrl: "",
+			Color:       link.Color,
+			Background:  link.Background,
+			Smoothing:   link.Smoothing,
+			DomainID:    link.DomainID,
+		}
+		if _, err := uc.updateLink(ctx, repoWithTx, state); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+	uc.cache.Invalidate(link.Hash)
+
+	if replacedKey != "" {
+		uc.removeStoredFile(replacedKey)
+	}
+
+	return &dto.UploadLinkFileResponse{
+		Message: "File uploaded successfully",
+		ID:      link.ID,
+		File: dto.LinkFileInfo{
+			Filename:    filename,
+			ContentType: contentType,
+			Size:        upload.Size,
+		},
+	}, nil
+}
+
+func (uc *LinkUseCase) getLinkFile(ctx context.Context, fileID *int64) (*dto.LinkFileInfo, error) {
+	if fileID == nil {
+		return nil, nil
+	}
+
+	file, err := uc.repo.GetLinkFile(ctx, *fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LinkFileInfo{
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		Size:        file.Size,
+		UpdatedAt:   file.UpdatedAt,
+	}, nil
+}
+
+// detachLinkFile turns a file link back into a plain link. It returns the
+// storage key of the detached file, which the caller removes once the
+// transaction has committed.
+func detachLinkFile(ctx context.Context, repoWithTx postgres.Repository, linkID int64, fileID *int64) (string, error) {
+	if fileID == nil {
+		return "", nil
+	}
+
+	file, err := repoWithTx.GetLinkFile(ctx, *fileID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get link file: %w", err)
+	}
+
+	if err := repoWithTx.SetLinkFile(ctx, sqldb.SetLinkFileParams{FileID: nil, ID: linkID}); err != nil {
+		return "", fmt.Errorf("failed to unset link file: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkFile(ctx, file.ID); err != nil {
+		return "", fmt.Errorf("failed to delete link file: %w", err)
+	}
+
+	return file.StorageKey, nil
+}
+
+// inspectUpload enforces the size and type limits. The returned reader
+// yields the complete file, including the bytes read for sniffing.
+func (uc *LinkUseCase) inspectUpload(upload FileUpload) (io.Reader, string, error) {
+	if upload.Size <= 0 {
+		return nil, "", ErrFileEmpty
+	}
+	if upload.Size > uc.fileMaxSize {
+		return nil, "", ErrFileTooLarge
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(upload.Body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil || !slices.Contains(uc.fileTypes, contentType) {
+		return nil, "", ErrFileTypeNotAllowed
+	}
+
+	return io.MultiReader(bytes.NewReader(head), upload.Body), contentType, nil
+}
+
+// removeStoredFile deletes a file that is no longer referenced. Failures only
+// leave an orphaned object behind, so they are logged and not returned.
+func (uc *LinkUseCase) removeStoredFile(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := uc.storage.Delete(ctx, key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to delete stored file")
+	}
+}
+
+// newStorageKey returns a fresh key for every upload, so a replacement never
+// overwrites the file that is still being served.
+func newStorageKey(hash string) string {
+	return fmt.Sprintf("links/%s/%s", hash, strings.ToLower(rand.Text()))
+}
+
+func cleanFilename(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	if len(name) > maxFilenameLength {
+		name = strings.ToValidUTF8(name[:maxFilenameLength], "")
+	}
+	return name
+}
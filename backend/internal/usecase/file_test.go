@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// The default FILE_ALLOWED_TYPES.
+var testFileTypes = []string{"application/pdf", "image/png", "image/jpeg", "image/gif", "image/webp"}
+
+func TestInspectUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		wantType string
+		wantErr  error
+	}{
+		{"png", "a.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png", nil},
+		{"jpeg", "a.jpg", "\xff\xd8\xff\xe0\x00\x10JFIF", "image/jpeg", nil},
+		{"gif", "a.gif", "GIF89a\x01\x00\x01\x00", "image/gif", nil},
+		{"webp", "a.webp", "RIFF\x24\x00\x00\x00WEBPVP8 ", "image/webp", nil},
+		{"pdf", "a.pdf", "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n", "application/pdf", nil},
+		// The filename and the client's content type are never trusted.
+		{"png named html", "a.html", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png", nil},
+		{"html named png", "a.png", "<!DOCTYPE html><script>alert(1)</script>", "", ErrFileTypeNotAllowed},
+		{"svg", "a.svg", `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`, "", ErrFileTypeNotAllowed},
+		{"plain text", "a.txt", "just some text", "", ErrFileTypeNotAllowed},
+		{"zip", "a.pdf", "PK\x03\x04\x14\x00\x00\x00", "", ErrFileTypeNotAllowed},
+		{"executable", "a.png", "MZ\x90\x00\x03\x00\x00\x00", "", ErrFileTypeNotAllowed},
+	}
+
+	uc := &LinkUseCase{fileMaxSize: 1 << 20, fileTypes: testFileTypes}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Padding past the sniffed prefix checks that the returned reader
+			// still yields the whole file.
+			content := tt.content + strings.Repeat("\x00", 2*sniffLength)
+			upload := FileUpload{Filename: tt.filename, Size: int64(len(content)), Body: strings.NewReader(content)}
+
+			body, contentType, err := uc.inspectUpload(upload)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("inspectUpload() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("inspectUpload() error = %v", err)
+			}
+			if contentType != tt.wantType {
+				t.Errorf("content type = %q, want %q", contentType, tt.wantType)
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if !bytes.Equal(got, []byte(content)) {
+				t.Errorf("body has %d bytes, want the %d uploaded", len(got), len(content))
+			}
+		})
+	}
+}
+
+func TestInspectUploadAllowlist(t *testing.T) {
+	// Sniffed parameters such as the charset do not affect the match.
+	uc := &LinkUseCase{fileMaxSize: 1 << 20, fileTypes: []string{"text/plain"}}
+	const content = "short text"
+
+	body, contentType, err := uc.inspectUpload(FileUpload{Size: int64(len(content)), Body: strings.NewReader(content)})
+	if err != nil {
+		t.Fatalf("inspectUpload() error = %v", err)
+	}
+	if contentType != "text/plain" {
+		t.Errorf("content type = %q, want text/plain", contentType)
+	}
+	if got, _ := io.ReadAll(body); string(got) != content {
+		t.Errorf("body = %q, want %q", got, content)
+	}
+
+	const png = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	if _, _, err := uc.inspectUpload(FileUpload{Size: int64(len(png)), Body: strings.NewReader(png)}); !errors.Is(err, ErrFileTypeNotAllowed) {
+		t.Errorf("inspectUpload() error = %v, want %v for a type missing from the list", err, ErrFileTypeNotAllowed)
+	}
+}
+
+func TestInspectUploadSize(t *testing.T) {
+	uc := &LinkUseCase{fileMaxSize: 100, fileTypes: testFileTypes}
+
+	tests := []struct {
+		name    string
+		size    int64
+		wantErr error
+	}{
+		{"empty", 0, ErrFileEmpty},
+		{"at the limit", 100, nil},
+		{"over the limit", 101, ErrFileTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "%PDF-1.7\n" + strings.Repeat("x", max(int(tt.size)-9, 0))
+			_, _, err := uc.inspectUpload(FileUpload{Size: tt.size, Body: strings.NewReader(content)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("inspectUpload() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCleanFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"  report.pdf  ", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\me\report.pdf`, "report.pdf"},
+		{"dir/", "dir"},
+		{"", "file"},
+		{".", "file"},
+		{"/", "file"},
+		{strings.Repeat("a", 300), strings.Repeat("a", maxFilenameLength)},
+	}
+
+	for _, tt := range tests {
+		if got := cleanFilename(tt.name); got != tt.want {
+			t.Errorf("cleanFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
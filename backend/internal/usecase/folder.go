@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrFolderNotFound      = errors.New("folder not found or access denied")
+	ErrFolderAlreadyExists = errors.New("folder with this name already exists")
+)
+
+type FolderUseCase struct {
+	repo postgres.Repository
+}
+
+func NewFolderUseCase(repo postgres.Repository) *FolderUseCase {
+	return &FolderUseCase{repo: repo}
+}
+
+func (uc *FolderUseCase) CreateFolder(ctx context.Context, req dto.FolderRequest, userID int64) (*dto.FolderInfo, error) {
+	if err := uc.checkNameFree(ctx, userID, req.Name); err != nil {
+		return nil, err
+	}
+
+	folder, err := uc.repo.CreateFolder(ctx, sqldb.CreateFolderParams{UserID: userID, Name: req.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	return &dto.FolderInfo{ID: folder.ID, Name: folder.Name, CreatedAt: folder.CreatedAt}, nil
+}
+
+func (uc *FolderUseCase) GetFolders(ctx context.Context, userID int64) (*dto.GetFoldersResponse, error) {
+	rows, err := uc.repo.GetFoldersByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folders by user id: %w", err)
+	}
+
+	folders := make([]dto.FolderInfo, len(rows))
+	for i, r := range rows {
+		folders[i] = dto.FolderInfo{ID: r.ID, Name: r.Name, LinksCount: r.LinksCount, CreatedAt: r.CreatedAt}
+	}
+	return &dto.GetFoldersResponse{Folders: folders}, nil
+}
+
+func (uc *FolderUseCase) RenameFolder(ctx context.Context, folderID, userID int64, req dto.FolderRequest) (*dto.FolderInfo, error) {
+	if err := uc.checkNameFree(ctx, userID, req.Name); err != nil {
+		return nil, err
+	}
+
+	folder, err := uc.repo.RenameFolder(ctx, sqldb.RenameFolderParams{Name: req.Name, ID: folderID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, fmt.Errorf("failed to rename folder: %w", err)
+	}
+
+	return &dto.FolderInfo{ID: folder.ID, Name: folder.Name, CreatedAt: folder.CreatedAt}, nil
+}
+
+// DeleteFolder removes the folder. Its links are kept and become unfiled.
+func (uc *FolderUseCase) DeleteFolder(ctx context.Context, folderID, userID int64) error {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	if _, err := repoWithTx.GetFolderByID(ctx, sqldb.GetFolderByIDParams{ID: folderID, UserID: userID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrFolderNotFound
+		}
+		return fmt.Errorf("failed to verify folder ownership: %w", err)
+	}
+
+	if err := repoWithTx.ClearFolderFromLinks(ctx, &folderID); err != nil {
+		return fmt.Errorf("failed to unfile folder links: %w", err)
+	}
+
+	rowsAffected, err := repoWithTx.DeleteFolder(ctx, sqldb.DeleteFolderParams{ID: folderID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrFolderNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetLinkFolder moves a link into a folder, or out of any folder when
+// req.FolderID is nil.
+func (uc *FolderUseCase) SetLinkFolder(ctx context.Context, linkID, userID int64, req dto.SetLinkFolderRequest) error {
+	if req.FolderID != nil {
+		if _, err := uc.repo.GetFolderByID(ctx, sqldb.GetFolderByIDParams{ID: *req.FolderID, UserID: userID}); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrFolderNotFound
+			}
+			return fmt.Errorf("failed to get folder by id: %w", err)
+		}
+	}
+
+	rowsAffected, err := uc.repo.SetLinkFolder(ctx, sqldb.SetLinkFolderParams{FolderID: req.FolderID, ID: linkID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to set link folder: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+func (uc *FolderUseCase) GetFolderStats(ctx context.Context, folderID, userID int64, rng dto.StatsRange) (*dto.CampaignStatsResponse, error) {
+	if _, err := uc.repo.GetFolderByID(ctx, sqldb.GetFolderByIDParams{ID: folderID, UserID: userID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, fmt.Errorf("failed to get folder by id: %w", err)
+	}
+
+	return campaignStats(ctx, uc.repo, userID, &folderID, nil, rng)
+}
+
+func (uc *FolderUseCase) checkNameFree(ctx context.Context, userID int64, name string) error {
+	_, err := uc.repo.GetFolderByName(ctx, sqldb.GetFolderByNameParams{UserID: userID, Name: name})
+	if err == nil {
+		return ErrFolderAlreadyExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to check folder name: %w", err)
+	}
+	return nil
+}
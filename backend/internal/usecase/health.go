@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	healthCheckPollInterval = time.Minute
+	healthCheckBatchSize    = 500
+	maxHealthErrorLength    = 500
+)
+
+type ProbeResult struct {
+	StatusCode    int
+	RedirectChain []string
+	Err           error
+}
+
+func (r ProbeResult) Healthy() bool {
+	return r.Err == nil && r.StatusCode > 0 && r.StatusCode < 400
+}
+
+// DestinationProber requests a destination URL, following redirects.
+type DestinationProber interface {
+	Probe(ctx context.Context, url string) ProbeResult
+}
+
+// HealthChecker periodically probes every link destination and records the
+// outcome in link_health. A link counts as broken, and is alerted on, after
+// alertAfter failed checks in a row.
+type HealthChecker struct {
+	repo        postgres.Repository
+	prober      DestinationProber
+	interval    time.Duration
+	concurrency int
+	alertAfter  int64
+}
+
+func NewHealthChecker(repo postgres.Repository, prober DestinationProber, cfg *config.Config) *HealthChecker {
+	return &HealthChecker{
+		repo:        repo,
+		prober:      prober,
+		interval:    cfg.HealthCheckInterval,
+		concurrency: max(cfg.HealthCheckConcurrency, 1),
+		alertAfter:  int64(cfg.HealthCheckAlertAfter),
+	}
+}
+
+// Run blocks until ctx is done. A non-positive interval disables checking.
+func (hc *HealthChecker) Run(ctx context.Context) {
+	if hc.interval <= 0 {
+		log.Info().Msg("Destination health checks disabled")
+		return
+	}
+
+	ticker := time.NewTicker(healthCheckPollInterval)
+	defer ticker.Stop()
+
+	for {
+		hc.checkDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (hc *HealthChecker) checkDue(ctx context.Context) {
+	links, err := hc.repo.GetLinksDueForHealthCheck(ctx, sqldb.GetLinksDueForHealthCheckParams{
+		CheckedAt: time.Now().Add(-hc.interval),
+		Limit:     healthCheckBatchSize,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to get links due for health check")
+		}
+		return
+	}
+
+	jobs := make(chan sqldb.GetLinksDueForHealthCheckRow)
+	var wg sync.WaitGroup
+	for range hc.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				hc.check(ctx, link.ID, link.OriginalUrl)
+			}
+		}()
+	}
+
+	for _, link := range links {
+		select {
+		case jobs <- link:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (hc *HealthChecker) check(ctx context.Context, linkID int64, url string) {
+	result := hc.prober.Probe(ctx, url)
+	if ctx.Err() != nil {
+		return
+	}
+
+	params := sqldb.UpsertLinkHealthParams{
+		LinkID:        linkID,
+		RedirectChain: result.RedirectChain,
+		Healthy:       result.Healthy(),
+		BrokenAfter:   hc.alertAfter,
+	}
+	if params.RedirectChain == nil {
+		params.RedirectChain = []string{}
+	}
+	if result.StatusCode > 0 {
+		code := int64(result.StatusCode)
+		params.StatusCode = &code
+	}
+	if result.Err != nil {
+		msg := result.Err.Error()
+		if len(msg) > maxHealthErrorLength {
+			msg = msg[:maxHealthErrorLength]
+		}
+		params.Error = &msg
+	}
+
+	health, err := hc.repo.UpsertLinkHealth(ctx, params)
+	if err != nil {
+		// The link may have been deleted while it was being probed.
+		log.Debug().Err(err).Int64("link_id", linkID).Msg("Failed to record link health")
+		return
+	}
+
+	if health.ConsecutiveFailures == hc.alertAfter {
+		event := log.Warn().Int64("link_id", linkID).Str("url", url).Int64("failures", health.ConsecutiveFailures)
+		if health.StatusCode != nil {
+			event = event.Int64("status", *health.StatusCode)
+		}
+		if health.Error != nil {
+			event = event.Str("error", *health.Error)
+		}
+		event.Msg("Link destination is broken")
+	}
+}
@@ -2,58 +2,133 @@ package usecase
 
 import (
 	"context"
-	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"sort"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
-	"github.com/rs/zerolog/log"
-
+	"qrcodegen/config"
 	"qrcodegen/internal/dto"
 	"qrcodegen/internal/repository/postgres"
 	sqldb "qrcodegen/sqlc/generated"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/ua-parser/uap-go/uaparser"
 )
 
 const (
 	defaultQRColor      = "000000"
 	defaultQRBackground = "FFFFFF"
-	hashLength          = 7
+	maxHashAttempts     = 5
 )
 
 var (
 	defaultQRSmoothing = 0.0
 	ErrLinkNotFound    = errors.New("link not found or access denied")
+	ErrHashTaken       = errors.New("hash is already in use")
 )
 
-type GeoResolver interface {
-	Resolve(ip string) (string, string, bool)
+// HashGenerator produces candidate short hashes for new links. Candidates
+// need not be unique; collisions are retried on insert.
+type HashGenerator interface {
+	Generate(ctx context.Context) (string, error)
 }
 
 type LinkUseCase struct {
-	repo     postgres.Repository
-	uaParser *uaparser.Parser
-	geo      GeoResolver
+	repo        postgres.Repository
+	cache       *RedirectCache
+	policy      *URLPolicy
+	storage     FileStorage
+	hashes      HashGenerator
+	previews    *PreviewRefresher
+	scans       ScanBroker
+	transitions *TransitionQueue
+	appHost     string
+	fileMaxSize int64
+	fileTypes   []string
+	retention   time.Duration
+	previewKey  []byte
 }
 
-func NewLinkUseCase(repo postgres.Repository, geo GeoResolver) *LinkUseCase {
-	parser := uaparser.NewFromSaved()
-	return &LinkUseCase{repo: repo, uaParser: parser, geo: geo}
+func NewLinkUseCase(repo postgres.Repository, cache *RedirectCache, policy *URLPolicy, storage FileStorage, hashes HashGenerator, previews *PreviewRefresher, scans ScanBroker, transitions *TransitionQueue, cfg *config.Config) *LinkUseCase {
+	return &LinkUseCase{
+		repo:        repo,
+		cache:       cache,
+		policy:      policy,
+		storage:     storage,
+		hashes:      hashes,
+		previews:    previews,
+		scans:       scans,
+		transitions: transitions,
+		appHost:     appHost(cfg),
+		fileMaxSize: int64(cfg.FileMaxSize),
+		fileTypes:   cfg.FileAllowedTypes,
+		retention:   cfg.TrashRetention,
+		previewKey:  []byte(cfg.JWTSecret),
+	}
 }
 
-func generateHash(length int) (string, error) {
-	bytes := make([]byte, length)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+// RedirectMode is how a scan is sent on to an external URL: an HTTP status
+// code, or an HTML page for in-app browsers that mishandle HTTP redirects.
+type RedirectMode string
+
+const (
+	RedirectMovedPermanently RedirectMode = "301"
+	RedirectFound            RedirectMode = "302"
+	RedirectTemporary        RedirectMode = "307"
+	RedirectPermanent        RedirectMode = "308"
+	RedirectMetaRefresh      RedirectMode = "meta_refresh"
+	RedirectJavaScript       RedirectMode = "javascript"
+)
+
+func (m RedirectMode) valid() bool {
+	switch m {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporary, RedirectPermanent, RedirectMetaRefresh, RedirectJavaScript:
+		return true
 	}
-	return base64.URLEncoding.EncodeToString(bytes)[:length], nil
+	return false
+}
+
+// RedirectTarget is where a scan leads: an external URL, the hosted file
+// opened for streaming, or the interstitial preview page.
+type RedirectTarget struct {
+	URL     string
+	Mode    RedirectMode
+	File    *sqldb.LinkFile
+	Body    io.ReadCloser
+	Preview *dto.LinkPreview
+	// PreviewToken goes into the continue link of the preview page.
+	PreviewToken string
+}
+
+type RedirectRequest struct {
+	Hash      string
+	Host      string
+	Referer   string
+	UserAgent string
+	IP        string
+	// Preview asks for the interstitial page even if the link does not use it.
+	Preview bool
+	// PreviewToken is the token of the preview page's continue link.
+	PreviewToken string
+	// ViaPreview marks a click-through from the interstitial page. It is
+	// set from a valid PreviewToken, not by callers.
+	ViaPreview bool
+	// Headers hold the configured location headers, and only those, of
+	// requests that came through a trusted proxy, whose location headers
+	// can be believed.
+	Headers http.Header
 }
 
 func (uc *LinkUseCase) CreateLink(ctx context.Context, req dto.CreateLinkRequest, userID int64) (*dto.CreateLinkResponse, error) {
+	originalURL, err := uc.policy.Check(ctx, req.OriginalURL)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := uc.repo.BeginTx(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -62,49 +137,30 @@ func (uc *LinkUseCase) CreateLink(ctx context.Context, req dto.CreateLinkRequest
 
 	repoWithTx := uc.repo.WithTX(tx)
 
-	var linkHash string
-	for i := 0; i < 5; i++ {
-		hash, err := generateHash(hashLength)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate hash: %w", err)
-		}
-		_, err = repoWithTx.GetLinkByHash(ctx, hash)
-		if errors.Is(err, pgx.ErrNoRows) {
-			linkHash = hash
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to check hash uniqueness: %w", err)
-		}
-	}
-	if linkHash == "" {
-		return nil, errors.New("could not generate a unique hash")
+	if err := checkLinkDomain(ctx, repoWithTx, req.DomainID, userID); err != nil {
+		return nil, err
 	}
 
 	linkParams := sqldb.CreateLinkParams{
-		OriginalUrl: req.OriginalURL,
-		Hash:        linkHash,
-		UserID:      userID,
-		Name:        req.Name,
-	}
-	createdLink, err := repoWithTx.CreateLink(ctx, linkParams)
+		OriginalUrl:  originalURL,
+		UserID:       userID,
+		Name:         req.Name,
+		DomainID:     req.DomainID,
+		Interstitial: req.Interstitial,
+		RedirectMode: req.RedirectMode,
+	}
+	createdLink, err := uc.insertLink(ctx, repoWithTx, linkParams, defaultQRCodeParams())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create link: %w", err)
-	}
-
-	qrParams := sqldb.CreateQRCodeParams{
-		LinkID:     createdLink.ID,
-		Color:      defaultQRColor,
-		Background: defaultQRBackground,
-		Smoothing:  &defaultQRSmoothing,
-	}
-	if _, err = repoWithTx.CreateQRCode(ctx, qrParams); err != nil {
-		return nil, fmt.Errorf("failed to create qr code: %w", err)
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	uc.cache.Invalidate(createdLink.Hash)
+	if createdLink.Interstitial {
+		uc.previews.Refresh(createdLink.ID, createdLink.OriginalUrl)
+	}
 
 	return &dto.CreateLinkResponse{
 		ID:      createdLink.ID,
@@ -112,6 +168,83 @@ func (uc *LinkUseCase) CreateLink(ctx context.Context, req dto.CreateLinkRequest
 	}, nil
 }
 
+func defaultQRCodeParams() sqldb.CreateQRCodeParams {
+	return sqldb.CreateQRCodeParams{
+		Color:      defaultQRColor,
+		Background: defaultQRBackground,
+		Smoothing:  &defaultQRSmoothing,
+	}
+}
+
+// insertLink creates a link together with its QR code and first revision.
+// A fresh hash is generated unless linkParams already carries one; the
+// unique constraint on hash decides collisions, so concurrent creates never
+// share a hash.
+func (uc *LinkUseCase) insertLink(ctx context.Context, repoWithTx postgres.Repository, linkParams sqldb.CreateLinkParams, qrParams sqldb.CreateQRCodeParams) (sqldb.Link, error) {
+	explicitHash := linkParams.Hash != ""
+	if linkParams.RedirectMode == "" {
+		linkParams.RedirectMode = string(RedirectFound)
+	}
+
+	var createdLink sqldb.Link
+	for attempt := 1; ; attempt++ {
+		if !explicitHash {
+			hash, err := uc.hashes.Generate(ctx)
+			if err != nil {
+				return sqldb.Link{}, fmt.Errorf("failed to generate hash: %w", err)
+			}
+			linkParams.Hash = hash
+		}
+
+		var err error
+		createdLink, err = repoWithTx.CreateLink(ctx, linkParams)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return sqldb.Link{}, fmt.Errorf("failed to create link: %w", err)
+		}
+		// No row means the hash is taken.
+		if explicitHash {
+			return sqldb.Link{}, ErrHashTaken
+		}
+		if attempt == maxHashAttempts {
+			return sqldb.Link{}, errors.New("could not generate a unique hash")
+		}
+	}
+	linkHash := createdLink.Hash
+
+	qrParams.LinkID = createdLink.ID
+	if _, err := repoWithTx.CreateQRCode(ctx, qrParams); err != nil {
+		return sqldb.Link{}, fmt.Errorf("failed to create qr code: %w", err)
+	}
+
+	revision := sqldb.CreateLinkRevisionParams{
+		LinkID:      createdLink.ID,
+		UserID:      createdLink.UserID,
+		Action:      RevisionActionCreate,
+		OriginalUrl: createdLink.OriginalUrl,
+		Color:       qrParams.Color,
+		Background:  qrParams.Background,
+		Smoothing:   qrParams.Smoothing,
+		DomainID:    createdLink.DomainID,
+	}
+	if err := recordRevision(ctx, repoWithTx, revision); err != nil {
+		return sqldb.Link{}, err
+	}
+
+	// Clears negative cache entries left by scans of the hash before it existed.
+	if err := repoWithTx.NotifyLinkChanged(ctx, linkHash); err != nil {
+		return sqldb.Link{}, fmt.Errorf("failed to notify link change: %w", err)
+	}
+
+	if err := enqueueWebhookEvent(ctx, repoWithTx, createdLink.UserID, WebhookEventLinkCreated, webhookLinkData(createdLink)); err != nil {
+		return sqldb.Link{}, err
+	}
+
+	return createdLink, nil
+}
+
 func (uc *LinkUseCase) GetLinkByID(ctx context.Context, linkID int64, userID int64) (*dto.GetLinkResponse, error) {
 	params := sqldb.GetLinkAndQRCodeByIDParams{
 		ID:     linkID,
@@ -135,54 +268,63 @@ func (uc *LinkUseCase) GetLinkByID(ctx context.Context, linkID int64, userID int
 		Color:       linkData.Color,
 		Background:  linkData.Background,
 		Smoothing:   linkData.Smoothing,
+		DomainID:    linkData.DomainID,
+		Domain:      linkData.DomainHost,
+		RevisionID:  linkData.RevisionID,
+		FolderID:    linkData.FolderID,
+		ClonedFrom:  linkData.ClonedFrom,
+
+		Interstitial: linkData.Interstitial,
+		RedirectMode: linkData.RedirectMode,
 	}
 
-	return response, nil
-}
+	response.Health, err = uc.getLinkHealth(ctx, linkData.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link health: %w", err)
+	}
 
-func (uc *LinkUseCase) GetAllLinks(ctx context.Context, userID int64) (*dto.GetAllLinksResponse, error) {
-	links, err := uc.repo.GetLinksSummaryByUser(ctx, userID)
+	response.File, err = uc.getLinkFile(ctx, linkData.FileID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.GetAllLinksResponse{
-				Links:   []dto.LinkInfo{},
-				Message: "Success get all links by user",
-			}, nil
-		}
-		return nil, fmt.Errorf("failed to get links by user id: %w", err)
+		return nil, fmt.Errorf("failed to get link file: %w", err)
 	}
 
-	linkInfos := make([]dto.LinkInfo, len(links))
-	for i, link := range links {
-		transitionsCount, _ := link.TransitionsCount.(int64)
-		linkInfos[i] = dto.LinkInfo{ID: link.ID, OriginalURL: link.OriginalUrl, Name: link.Name, CreatedAt: link.CreatedAt, Transitions: transitionsCount}
+	previewStats, err := uc.repo.GetLinkPreviewStats(ctx, linkData.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link preview stats: %w", err)
 	}
+	response.PreviewViews = previewStats.PreviewViews
+	response.PreviewClickThroughs = previewStats.ClickThroughs
 
-	return &dto.GetAllLinksResponse{
-		Links:   linkInfos,
-		Message: "Success get all links by user",
-	}, nil
+	tags, err := uc.repo.GetTagsByLinkID(ctx, linkData.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link tags: %w", err)
+	}
+	response.Tags = make([]dto.TagInfo, len(tags))
+	for i, t := range tags {
+		response.Tags[i] = dto.TagInfo{ID: t.ID, Name: t.Name, CreatedAt: t.CreatedAt}
+	}
+
+	return response, nil
 }
 
-func (uc *LinkUseCase) SearchLinksByName(ctx context.Context, userID int64, search string) (*dto.GetAllLinksResponse, error) {
-	params := sqldb.SearchLinksSummaryByNameParams{
-		UserID:  userID,
-		Column2: &search,
-	}
-	rows, err := uc.repo.SearchLinksSummaryByName(ctx, params)
+func (uc *LinkUseCase) getLinkHealth(ctx context.Context, linkID int64) (*dto.LinkHealthInfo, error) {
+	health, err := uc.repo.GetLinkHealth(ctx, linkID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.GetAllLinksResponse{Links: []dto.LinkInfo{}, Message: "Success get all links by user"}, nil
+			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to search links by name: %w", err)
+		return nil, err
 	}
 
-	linkInfos := make([]dto.LinkInfo, len(rows))
-	for i, link := range rows {
-		transitionsCount, _ := link.TransitionsCount.(int64)
-		linkInfos[i] = dto.LinkInfo{ID: link.ID, OriginalURL: link.OriginalUrl, Name: link.Name, CreatedAt: link.CreatedAt, Transitions: transitionsCount}
-	}
-	return &dto.GetAllLinksResponse{Links: linkInfos, Message: "Success get all links by user"}, nil
+	return &dto.LinkHealthInfo{
+		Healthy:             health.Healthy,
+		StatusCode:          health.StatusCode,
+		RedirectChain:       health.RedirectChain,
+		Error:               health.Error,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		Broken:              health.Broken,
+		CheckedAt:           health.CheckedAt,
+	}, nil
 }
 
 type LinkSortBy string
@@ -190,6 +332,8 @@ type LinkSortBy string
 const (
 	SortByCreatedAt   LinkSortBy = "created_at"
 	SortByTransitions LinkSortBy = "transitions"
+	SortByName        LinkSortBy = "name"
+	SortByLastScan    LinkSortBy = "last_scan"
 )
 
 type SortOrder string
@@ -199,31 +343,160 @@ const (
 	SortDesc SortOrder = "desc"
 )
 
-func (uc *LinkUseCase) SortLinks(items []dto.LinkInfo, by LinkSortBy, order SortOrder) []dto.LinkInfo {
-	sorter := func(i, j int) bool { return items[i].CreatedAt.After(items[j].CreatedAt) }
+const (
+	defaultLinkPageSize = 50
+	maxLinkPageSize     = 200
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort_by or order")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// linkCursor is the sort key of the last link on a page. It is bound to the
+// sort it was issued for, so it cannot be replayed against another ordering.
+type linkCursor struct {
+	SortBy LinkSortBy `json:"b"`
+	Order  SortOrder  `json:"o"`
+	Time   time.Time  `json:"t"`
+	Num    int64      `json:"n"`
+	Text   string     `json:"s"`
+	ID     int64      `json:"i"`
+}
+
+func (c linkCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeLinkCursor(s string, by LinkSortBy, order SortOrder) (*linkCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c linkCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.SortBy != by || c.Order != order {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// GetAllLinks returns one page of the user's links, filtered and sorted in
+// the database. Pass NextCursor from the response to fetch the next page.
+func (uc *LinkUseCase) GetAllLinks(ctx context.Context, userID int64, filter dto.LinkFilter, page dto.LinkPage) (*dto.GetAllLinksResponse, error) {
+	by := LinkSortBy(page.SortBy)
+	if by == "" {
+		by = SortByCreatedAt
+	}
+	order := SortOrder(page.Order)
+	if order == "" {
+		order = SortDesc
+	}
 	switch by {
-	case SortByTransitions:
-		sorter = func(i, j int) bool {
-			if order == SortAsc {
-				return items[i].Transitions < items[j].Transitions
-			}
-			return items[i].Transitions > items[j].Transitions
-		}
-	case SortByCreatedAt:
-		fallthrough
+	case SortByCreatedAt, SortByTransitions, SortByName, SortByLastScan:
 	default:
-		sorter = func(i, j int) bool {
-			if order == SortAsc {
-				return items[i].CreatedAt.Before(items[j].CreatedAt)
-			}
-			return items[i].CreatedAt.After(items[j].CreatedAt)
+		return nil, ErrInvalidSort
+	}
+	if order != SortAsc && order != SortDesc {
+		return nil, ErrInvalidSort
+	}
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = defaultLinkPageSize
+	}
+	limit = min(limit, maxLinkPageSize)
+
+	filterParams := sqldb.CountLinksByUserParams{
+		UserID:      userID,
+		FolderID:    filter.FolderID,
+		TagID:       filter.TagID,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		HasScans:    filter.HasScans,
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		filterParams.Search = &search
+	}
+	if domain := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(filter.Domain)), "www."); domain != "" {
+		filterParams.Domain = &domain
+	}
+
+	params := sqldb.GetLinksPageByUserParams{
+		UserID:      filterParams.UserID,
+		Search:      filterParams.Search,
+		FolderID:    filterParams.FolderID,
+		TagID:       filterParams.TagID,
+		CreatedFrom: filterParams.CreatedFrom,
+		CreatedTo:   filterParams.CreatedTo,
+		Domain:      filterParams.Domain,
+		HasScans:    filterParams.HasScans,
+		SortBy:      string(by),
+		SortOrder:   string(order),
+		PageSize:    int64(limit + 1),
+	}
+	if page.Cursor != "" {
+		cursor, err := decodeLinkCursor(page.Cursor, by, order)
+		if err != nil {
+			return nil, err
 		}
+		params.CursorTime = &cursor.Time
+		params.CursorNum = &cursor.Num
+		params.CursorText = &cursor.Text
+		params.CursorID = &cursor.ID
 	}
-	sort.Slice(items, sorter)
-	return items
+
+	total, err := uc.repo.CountLinksByUser(ctx, filterParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count links by user id: %w", err)
+	}
+
+	rows, err := uc.repo.GetLinksPageByUser(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links by user id: %w", err)
+	}
+
+	resp := &dto.GetAllLinksResponse{
+		Links:   make([]dto.LinkInfo, 0, min(len(rows), limit)),
+		Total:   total,
+		Message: "Success get all links by user",
+	}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		resp.HasMore = true
+		resp.NextCursor = linkCursor{SortBy: by, Order: order, Time: last.SortTime, Num: last.SortNum, Text: last.SortText, ID: last.ID}.encode()
+	}
+	for _, link := range rows {
+		resp.Links = append(resp.Links, dto.LinkInfo{
+			ID:               link.ID,
+			OriginalURL:      link.OriginalUrl,
+			Name:             link.Name,
+			CreatedAt:        link.CreatedAt,
+			Transitions:      link.TransitionsCount,
+			UniqueVisitors:   link.UniqueVisitors,
+			LastTransitionAt: link.LastTransitionAt,
+			Broken:           link.Broken,
+			FolderID:         link.FolderID,
+			TagIDs:           link.TagIds,
+		})
+	}
+
+	return resp, nil
 }
 
+// EditLink updates the destination and QR styling. File links may omit the
+// URL to keep serving their file; giving one detaches and removes the file.
 func (uc *LinkUseCase) EditLink(ctx context.Context, linkID int64, userID int64, req dto.EditLinkRequest) (*dto.EditLinkResponse, error) {
+	var originalURL string
+	if req.OriginalURL != "" {
+		var err error
+		originalURL, err = uc.policy.Check(ctx, req.OriginalURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tx, err := uc.repo.BeginTx(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -232,33 +505,86 @@ func (uc *LinkUseCase) EditLink(ctx context.Context, linkID int64, userID int64,
 
 	repoWithTx := uc.repo.WithTX(tx)
 
-	updateLinkParams := sqldb.UpdateLinkURLParams{
-		OriginalUrl: req.OriginalURL,
-		ID:          linkID,
-		UserID:      userID,
-	}
-	tag, err := repoWithTx.UpdateLinkURL(ctx, updateLinkParams)
+	link, err := repoWithTx.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update link: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get link by id: %w", err)
 	}
-	if tag == 0 {
-		return nil, ErrLinkNotFound
+
+	var detachedKey string
+	if originalURL == "" {
+		if link.FileID == nil {
+			return nil, ErrOriginalURLRequired
+		}
+	} else {
+		detachedKey, err = detachLinkFile(ctx, repoWithTx, linkID, link.FileID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	updateQRParams := sqldb.UpdateQRCodeParamsParams{
-		Color:      req.Color,
-		Background: req.Background,
-		Smoothing:  &req.Smoothing,
-		LinkID:     linkID,
+	domainID := link.DomainID
+	if req.DomainID != nil {
+		domainID = req.DomainID
+	} else if req.ClearDomain {
+		domainID = nil
 	}
-	err = repoWithTx.UpdateQRCodeParams(ctx, updateQRParams)
+
+	state := sqldb.CreateLinkRevisionParams{
+		LinkID:      linkID,
+		UserID:      userID,
+		Action:      RevisionActionEdit,
+		OriginalUrl: originalURL,
+		Color:       req.Color,
+		Background:  req.Background,
+		Smoothing:   &req.Smoothing,
+		DomainID:    domainID,
+	}
+	hash, err := uc.updateLink(ctx, repoWithTx, state)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update qr code params: %w", err)
+		return nil, err
+	}
+
+	if req.Interstitial != nil {
+		params := sqldb.SetLinkInterstitialParams{
+			Interstitial: *req.Interstitial,
+			ID:           linkID,
+			UserID:       userID,
+		}
+		if _, err := repoWithTx.SetLinkInterstitial(ctx, params); err != nil {
+			return nil, fmt.Errorf("failed to set link interstitial: %w", err)
+		}
+	}
+
+	if req.RedirectMode != "" {
+		params := sqldb.SetLinkRedirectModeParams{
+			RedirectMode: req.RedirectMode,
+			ID:           linkID,
+			UserID:       userID,
+		}
+		if _, err := repoWithTx.SetLinkRedirectMode(ctx, params); err != nil {
+			return nil, fmt.Errorf("failed to set link redirect mode: %w", err)
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	uc.cache.Invalidate(hash)
+
+	interstitial := link.Interstitial
+	if req.Interstitial != nil {
+		interstitial = *req.Interstitial
+	}
+	if interstitial && originalURL != "" {
+		uc.previews.Refresh(linkID, originalURL)
+	}
+
+	if detachedKey != "" {
+		uc.removeStoredFile(detachedKey)
+	}
 
 	return &dto.EditLinkResponse{
 		Message: "Link updated successfully",
@@ -266,80 +592,230 @@ func (uc *LinkUseCase) EditLink(ctx context.Context, linkID int64, userID int64,
 	}, nil
 }
 
-func (uc *LinkUseCase) Redirect(ctx context.Context, hash, referer, userAgent, ip string) (string, error) {
-	link, err := uc.repo.GetLinkByHash(ctx, hash)
+// updateLink applies state to the link and its QR code and records it as a
+// new revision. It returns the link hash.
+func (uc *LinkUseCase) updateLink(ctx context.Context, repoWithTx postgres.Repository, state sqldb.CreateLinkRevisionParams) (string, error) {
+	if err := checkLinkDomain(ctx, repoWithTx, state.DomainID, state.UserID); err != nil {
+		return "", err
+	}
+
+	updateLinkParams := sqldb.UpdateLinkURLParams{
+		OriginalUrl: state.OriginalUrl,
+		DomainID:    state.DomainID,
+		ID:          state.LinkID,
+		UserID:      state.UserID,
+	}
+	hash, err := repoWithTx.UpdateLinkURL(ctx, updateLinkParams)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return "", ErrLinkNotFound
 		}
-		return "", fmt.Errorf("failed to get link by hash: %w", err)
+		return "", fmt.Errorf("failed to update link: %w", err)
+	}
+
+	updateQRParams := sqldb.UpdateQRCodeParamsParams{
+		Color:      state.Color,
+		Background: state.Background,
+		Smoothing:  state.Smoothing,
+		LinkID:     state.LinkID,
+	}
+	err = repoWithTx.UpdateQRCodeParams(ctx, updateQRParams)
+	if err != nil {
+		return "", fmt.Errorf("failed to update qr code params: %w", err)
+	}
+
+	// The recorded health belongs to the old destination; dropping it puts the
+	// link at the front of the next health check round.
+	if err := repoWithTx.DeleteLinkHealthByLinkID(ctx, state.LinkID); err != nil {
+		return "", fmt.Errorf("failed to reset link health: %w", err)
+	}
+	if err := repoWithTx.DeleteLinkPreviewByLinkID(ctx, state.LinkID); err != nil {
+		return "", fmt.Errorf("failed to reset link preview: %w", err)
+	}
+
+	if err := recordRevision(ctx, repoWithTx, state); err != nil {
+		return "", err
+	}
+
+	if err := repoWithTx.NotifyLinkChanged(ctx, hash); err != nil {
+		return "", fmt.Errorf("failed to notify link change: %w", err)
+	}
+
+	data := dto.WebhookLinkData{ID: state.LinkID, Hash: hash, OriginalURL: state.OriginalUrl, DomainID: state.DomainID}
+	if err := enqueueWebhookEvent(ctx, repoWithTx, state.UserID, WebhookEventLinkUpdated, data); err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (uc *LinkUseCase) Redirect(ctx context.Context, req RedirectRequest) (*RedirectTarget, error) {
+	return uc.redirect(ctx, req, false)
+}
+
+// RedirectCustomDomain serves the bare /{hash} form, which only exists on
+// verified custom domains.
+func (uc *LinkUseCase) RedirectCustomDomain(ctx context.Context, req RedirectRequest) (*RedirectTarget, error) {
+	return uc.redirect(ctx, req, true)
+}
+
+func (uc *LinkUseCase) redirect(ctx context.Context, req RedirectRequest, customOnly bool) (*RedirectTarget, error) {
+	domain, err := uc.customDomainByHost(ctx, req.Host)
+	if err != nil {
+		return nil, err
+	}
+	if customOnly && domain == nil {
+		return nil, ErrLinkNotFound
+	}
+
+	scheduled, err := uc.linkByHash(ctx, req.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	link := scheduled.Link
+	var scheduleID *int64
+	if entry := activeSchedule(scheduled.Schedules, time.Now()); entry != nil {
+		// The active entry replaces the destination, hosted files included.
+		link.OriginalUrl = entry.OriginalUrl
+		link.FileID = nil
+		scheduleID = &entry.ID
+	}
+	if domain != nil && (link.DomainID == nil || *link.DomainID != domain.ID) {
+		return nil, ErrLinkNotFound
+	}
+	if link.DeletedAt != nil {
+		return nil, ErrLinkGone
+	}
+
+	req.ViaPreview = req.PreviewToken != "" && uc.validPreviewToken(link.Hash, req.PreviewToken, time.Now())
+	if (req.Preview || link.Interstitial) && !req.ViaPreview {
+		return uc.showPreview(ctx, link)
+	}
+
+	target := &RedirectTarget{URL: link.OriginalUrl, Mode: RedirectMode(link.RedirectMode)}
+	if link.FileID != nil {
+		target, err = uc.openLinkFile(ctx, *link.FileID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	go func() {
-		ctxBg, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		uc.createTransition(ctxBg, link.ID, referer, userAgent, ip)
-	}()
+	uc.transitions.Push(link, scheduleID, req)
 
-	return link.OriginalUrl, nil
+	return target, nil
 }
 
-func (uc *LinkUseCase) createTransition(ctx context.Context, linkID int64, referer, userAgent, ip string) {
-	var refPtr, uaPtr *string
-	if referer != "" {
-		refPtr = &referer
+// openLinkFile opens a hosted file before the scan is counted, so a missing
+// file does not show up as a transition.
+func (uc *LinkUseCase) openLinkFile(ctx context.Context, fileID int64) (*RedirectTarget, error) {
+	file, err := uc.repo.GetLinkFile(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get link file: %w", err)
 	}
-	if userAgent != "" {
-		uaPtr = &userAgent
+
+	body, err := uc.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		if errors.Is(err, ErrStoredFileNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to open stored file: %w", err)
 	}
 
-	client := uc.uaParser.Parse(userAgent)
-	var brPtr, osPtr *string
-	if client.UserAgent.Family != "" {
-		brPtr = &client.UserAgent.Family
+	return &RedirectTarget{File: &file, Body: body}, nil
+}
+
+func (uc *LinkUseCase) linkByHash(ctx context.Context, hash string) (RedirectLink, error) {
+	if link, found, ok := uc.cache.Get(hash); ok {
+		if !found {
+			return RedirectLink{}, ErrLinkNotFound
+		}
+		return link, nil
 	}
-	if client.Os.Family != "" {
-		osPtr = &client.Os.Family
+
+	generation := uc.cache.Generation()
+	link, err := uc.repo.GetLinkByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			uc.cache.SetMissing(hash, generation)
+			return RedirectLink{}, ErrLinkNotFound
+		}
+		return RedirectLink{}, fmt.Errorf("failed to get link by hash: %w", err)
 	}
 
-	var countryPtr, cityPtr *string
-	if uc.geo != nil && ip != "" {
-		if country, city, ok := uc.geo.Resolve(ip); ok {
-			if country != "" {
-				countryPtr = &country
-			}
-			if city != "" {
-				cityPtr = &city
-			}
+	schedules, err := uc.repo.GetLinkSchedulesByLinkID(ctx, link.ID)
+	if err != nil {
+		return RedirectLink{}, fmt.Errorf("failed to get link schedules: %w", err)
+	}
+
+	resolved := RedirectLink{Link: link, Schedules: schedules}
+	uc.cache.Set(resolved, generation)
+	return resolved, nil
+}
+
+// customDomainByHost returns the verified custom domain serving host, or nil
+// when the request came in on the service's own host.
+func (uc *LinkUseCase) customDomainByHost(ctx context.Context, host string) (*sqldb.Domain, error) {
+	host = normalizeHost(host)
+	if host == "" || host == uc.appHost {
+		return nil, nil
+	}
+
+	if domain, found, ok := uc.cache.GetDomain(host); ok {
+		if !found {
+			return nil, nil
 		}
+		return &domain, nil
 	}
 
-	params := sqldb.CreateTransitionParams{
-		LinkID:    linkID,
-		Country:   countryPtr,
-		City:      cityPtr,
-		Referer:   refPtr,
-		UserAgent: uaPtr,
-		Browser:   brPtr,
-		Os:        osPtr,
+	generation := uc.cache.Generation()
+	domain, err := uc.repo.GetVerifiedDomainByHost(ctx, host)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			uc.cache.SetDomain(host, nil, generation)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get domain by host: %w", err)
 	}
+	uc.cache.SetDomain(host, &domain, generation)
+	return &domain, nil
+}
 
-	err := uc.repo.CreateTransition(ctx, params)
+func checkLinkDomain(ctx context.Context, repo postgres.Repository, domainID *int64, userID int64) error {
+	if domainID == nil {
+		return nil
+	}
+
+	domain, err := repo.GetDomainByID(ctx, sqldb.GetDomainByIDParams{ID: *domainID, UserID: userID})
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create transition")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrDomainNotFound
+		}
+		return fmt.Errorf("failed to get domain by id: %w", err)
+	}
+	if domain.VerifiedAt == nil {
+		return ErrDomainNotVerified
 	}
+	return nil
 }
 
 func (uc *LinkUseCase) GetTransitions(ctx context.Context, linkID, userID int64) (*dto.GetTransitionsResponse, error) {
 	type row = struct {
-		ID        int64
-		Country   *string
-		City      *string
-		Referer   *string
-		UserAgent *string
-		Browser   *string
-		Os        *string
-		CreatedAt time.Time
+		ID         int64
+		Country    *string
+		Region     *string
+		City       *string
+		Referer    *string
+		UserAgent  *string
+		Browser    *string
+		Os         *string
+		CreatedAt  time.Time
+		RevisionID *int64
+		ScheduleID *int64
+		GeoSource  *string
 	}
 
 	rows, err := uc.repo.GetTransitionsByLinkID(
@@ -356,20 +832,26 @@ func (uc *LinkUseCase) GetTransitions(ctx context.Context, linkID, userID int64)
 	items := make([]dto.TransitionItem, 0, len(rows))
 	for _, r := range rows {
 		items = append(items, dto.TransitionItem{
-			ID:        r.ID,
-			Country:   r.Country,
-			City:      r.City,
-			Referer:   r.Referer,
-			UserAgent: r.UserAgent,
-			Browser:   r.Browser,
-			OS:        r.Os,
-			CreatedAt: r.CreatedAt,
+			ID:         r.ID,
+			Country:    r.Country,
+			Region:     r.Region,
+			City:       r.City,
+			Referer:    r.Referer,
+			UserAgent:  r.UserAgent,
+			Browser:    r.Browser,
+			OS:         r.Os,
+			CreatedAt:  r.CreatedAt,
+			RevisionID: r.RevisionID,
+			ScheduleID: r.ScheduleID,
+			GeoSource:  r.GeoSource,
 		})
 	}
 
 	return &dto.GetTransitionsResponse{Transitions: items}, nil
 }
 
+// DeleteLink moves a link to the trash. It stops redirecting but keeps its
+// analytics until it is restored or purged.
 func (uc *LinkUseCase) DeleteLink(ctx context.Context, linkID int64, userID int64) error {
 	tx, err := uc.repo.BeginTx(ctx)
 	if err != nil {
@@ -379,33 +861,27 @@ func (uc *LinkUseCase) DeleteLink(ctx context.Context, linkID int64, userID int6
 
 	repoWithTx := uc.repo.WithTX(tx)
 
-	_, err = repoWithTx.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID})
+	hash, err := repoWithTx.TrashLink(ctx, sqldb.TrashLinkParams{ID: linkID, UserID: userID})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ErrLinkNotFound
 		}
-		return fmt.Errorf("failed to verify link ownership: %w", err)
+		return fmt.Errorf("failed to trash link: %w", err)
 	}
 
-	if err := repoWithTx.DeleteTransitionsByLinkID(ctx, linkID); err != nil {
-		return fmt.Errorf("failed to delete transitions: %w", err)
+	if err := repoWithTx.NotifyLinkChanged(ctx, hash); err != nil {
+		return fmt.Errorf("failed to notify link change: %w", err)
 	}
 
-	if err := repoWithTx.DeleteQRCodeByLinkID(ctx, linkID); err != nil {
-		return fmt.Errorf("failed to delete qr code: %w", err)
-	}
-
-	rowsAffected, err := repoWithTx.DeleteLink(ctx, sqldb.DeleteLinkParams{ID: linkID, UserID: userID})
-	if err != nil {
-		return fmt.Errorf("failed to delete link: %w", err)
-	}
-	if rowsAffected == 0 {
-		return ErrLinkNotFound
+	data := dto.WebhookLinkData{ID: linkID, Hash: hash}
+	if err := enqueueWebhookEvent(ctx, repoWithTx, userID, WebhookEventLinkDeleted, data); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	uc.cache.Invalidate(hash)
 
 	return nil
 }
@@ -0,0 +1,209 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/pkg/hashid"
+	"qrcodegen/internal/repository/postgres"
+	"qrcodegen/internal/usecase"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// linkRepo stores links in memory and, like the ON CONFLICT DO NOTHING
+// insert, returns pgx.ErrNoRows for a hash that is already taken.
+// Transactions share the store; nothing is rolled back.
+type linkRepo struct {
+	postgres.Repository
+
+	mu       sync.Mutex
+	hashes   map[string]bool
+	inserts  int
+	sequence atomic.Int64
+}
+
+func newLinkRepo(taken ...string) *linkRepo {
+	r := &linkRepo{hashes: make(map[string]bool)}
+	for _, h := range taken {
+		r.hashes[h] = true
+	}
+	return r
+}
+
+func (r *linkRepo) BeginTx(ctx context.Context) (pgx.Tx, error) { return nopTx{}, nil }
+func (r *linkRepo) WithTX(tx pgx.Tx) postgres.Repository        { return r }
+
+func (r *linkRepo) GetVerifiedDomainByHost(ctx context.Context, host string) (sqldb.Domain, error) {
+	return sqldb.Domain{}, pgx.ErrNoRows
+}
+
+func (r *linkRepo) IsAnyDomainBlocked(ctx context.Context, hosts []string) (bool, error) {
+	return false, nil
+}
+
+func (r *linkRepo) NextLinkHashSequence(ctx context.Context) (int64, error) {
+	return r.sequence.Add(1), nil
+}
+
+func (r *linkRepo) IsLinkHashTaken(ctx context.Context, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hashes[hash], nil
+}
+
+func (r *linkRepo) CreateLink(ctx context.Context, arg sqldb.CreateLinkParams) (sqldb.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inserts++
+	if r.hashes[arg.Hash] {
+		return sqldb.Link{}, pgx.ErrNoRows
+	}
+	r.hashes[arg.Hash] = true
+	return sqldb.Link{
+		ID:           int64(len(r.hashes)),
+		OriginalUrl:  arg.OriginalUrl,
+		Hash:         arg.Hash,
+		UserID:       arg.UserID,
+		Name:         arg.Name,
+		RedirectMode: arg.RedirectMode,
+	}, nil
+}
+
+func (r *linkRepo) CreateQRCode(ctx context.Context, arg sqldb.CreateQRCodeParams) (sqldb.QrCode, error) {
+	return sqldb.QrCode{LinkID: arg.LinkID}, nil
+}
+
+func (r *linkRepo) CreateLinkRevision(ctx context.Context, arg sqldb.CreateLinkRevisionParams) (sqldb.LinkRevision, error) {
+	return sqldb.LinkRevision{LinkID: arg.LinkID}, nil
+}
+
+func (r *linkRepo) SetLinkRevision(ctx context.Context, arg sqldb.SetLinkRevisionParams) error {
+	return nil
+}
+
+func (r *linkRepo) NotifyLinkChanged(ctx context.Context, hash string) error {
+	return nil
+}
+
+func (r *linkRepo) EnqueueWebhookEvent(ctx context.Context, arg sqldb.EnqueueWebhookEventParams) error {
+	return nil
+}
+
+// nopTx commits and rolls back nothing; the other methods are unused.
+type nopTx struct{ pgx.Tx }
+
+func (nopTx) Commit(ctx context.Context) error   { return nil }
+func (nopTx) Rollback(ctx context.Context) error { return nil }
+
+// scriptedHashes returns the given hashes in order, then fresh ones.
+type scriptedHashes struct {
+	hashes []string
+	calls  int
+}
+
+func (g *scriptedHashes) Generate(context.Context) (string, error) {
+	g.calls++
+	if g.calls <= len(g.hashes) {
+		return g.hashes[g.calls-1], nil
+	}
+	return fmt.Sprintf("fresh%d", g.calls), nil
+}
+
+func newLinkTestUseCase(repo postgres.Repository, hashes usecase.HashGenerator) *usecase.LinkUseCase {
+	cfg := &config.Config{AppBaseURL: "https://qr.example.com", RedirectCacheSize: 100}
+	previews := usecase.NewPreviewRefresher(repo, nil)
+	return usecase.NewLinkUseCase(repo, usecase.NewRedirectCache(cfg), usecase.NewURLPolicy(repo, cfg), nil, hashes, previews, nil, nil, cfg)
+}
+
+func createTestLink(uc *usecase.LinkUseCase, i int) (*dto.CreateLinkResponse, error) {
+	return uc.CreateLink(context.Background(), dto.CreateLinkRequest{
+		OriginalURL: fmt.Sprintf("https://example.org/%d", i),
+		Name:        fmt.Sprintf("link %d", i),
+	}, 1)
+}
+
+func TestCreateLinkConcurrentHashes(t *testing.T) {
+	const creates = 500
+
+	strategies := []config.Config{
+		{HashStrategy: "random", HashLength: 7},
+		{HashStrategy: "sqids", HashLength: 7},
+		// Two words leave the smallest space, so some creates collide and
+		// retry.
+		{HashStrategy: "words", HashWordCount: 2},
+	}
+
+	for _, cfg := range strategies {
+		t.Run(cfg.HashStrategy, func(t *testing.T) {
+			repo := newLinkRepo()
+			hashes, err := hashid.NewHashGenerator(&cfg, repo)
+			if err != nil {
+				t.Fatalf("NewHashGenerator() error = %v", err)
+			}
+			uc := newLinkTestUseCase(repo, hashes)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, creates)
+			for i := range creates {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := createTestLink(uc, i); err != nil {
+						errs <- err
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				t.Errorf("CreateLink() error = %v", err)
+			}
+			if len(repo.hashes) != creates {
+				t.Errorf("stored %d distinct hashes, want %d", len(repo.hashes), creates)
+			}
+			t.Logf("%d inserts for %d links", repo.inserts, creates)
+		})
+	}
+}
+
+func TestCreateLinkHashCollisions(t *testing.T) {
+	taken := make([]string, usecase.MaxHashAttempts)
+	for i := range taken {
+		taken[i] = fmt.Sprintf("taken%d", i)
+	}
+
+	t.Run("succeeds on the last attempt", func(t *testing.T) {
+		repo := newLinkRepo(taken[:usecase.MaxHashAttempts-1]...)
+		hashes := &scriptedHashes{hashes: taken[:usecase.MaxHashAttempts-1]}
+
+		if _, err := createTestLink(newLinkTestUseCase(repo, hashes), 0); err != nil {
+			t.Fatalf("CreateLink() error = %v", err)
+		}
+		if repo.inserts != usecase.MaxHashAttempts {
+			t.Errorf("inserts = %d, want %d", repo.inserts, usecase.MaxHashAttempts)
+		}
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		repo := newLinkRepo(taken...)
+		hashes := &scriptedHashes{hashes: taken}
+
+		if _, err := createTestLink(newLinkTestUseCase(repo, hashes), 0); err == nil {
+			t.Fatal("CreateLink() succeeded with every hash taken")
+		}
+		if repo.inserts != usecase.MaxHashAttempts {
+			t.Errorf("inserts = %d, want %d", repo.inserts, usecase.MaxHashAttempts)
+		}
+		if len(repo.hashes) != len(taken) {
+			t.Errorf("stored %d hashes, want %d", len(repo.hashes), len(taken))
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	previewMetaTTL      = 24 * time.Hour
+	previewFetchTimeout = 3 * time.Second
+	previewTokenTTL     = time.Hour
+	previewQueueSize    = 1000
+	previewWorkers      = 4
+)
+
+// PageMeta is the title, description and open-graph image of a destination
+// page.
+type PageMeta struct {
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+type PageMetaFetcher interface {
+	Fetch(ctx context.Context, url string) (PageMeta, error)
+}
+
+// showPreview builds the interstitial page for link and counts the view.
+// It shows the metadata stored for the destination and never fetches it:
+// missing or stale metadata is refreshed in the background for later views.
+func (uc *LinkUseCase) showPreview(ctx context.Context, link sqldb.Link) (*RedirectTarget, error) {
+	preview := &dto.LinkPreview{
+		Name:        link.Name,
+		Destination: link.OriginalUrl,
+	}
+
+	if link.FileID != nil {
+		file, err := uc.repo.GetLinkFile(ctx, *link.FileID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrLinkNotFound
+			}
+			return nil, fmt.Errorf("failed to get link file: %w", err)
+		}
+		preview.FileName = file.Filename
+		preview.Destination = ""
+	} else {
+		if u, err := url.Parse(link.OriginalUrl); err == nil {
+			preview.Host = u.Hostname()
+		}
+		meta, err := uc.pageMeta(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+		preview.Title = meta.Title
+		preview.Description = meta.Description
+		preview.ImageURL = meta.ImageURL
+	}
+
+	uc.transitions.PushPreviewView(link)
+
+	return &RedirectTarget{Preview: preview, PreviewToken: uc.previewToken(link.Hash, time.Now())}, nil
+}
+
+// pageMeta returns the stored metadata of the link's destination. Metadata
+// older than previewMetaTTL is still shown while it is refreshed; metadata
+// of another destination is not.
+func (uc *LinkUseCase) pageMeta(ctx context.Context, link sqldb.Link) (PageMeta, error) {
+	stored, err := uc.repo.GetLinkPreview(ctx, link.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return PageMeta{}, fmt.Errorf("failed to get link preview: %w", err)
+	}
+	if err != nil || stored.OriginalUrl != link.OriginalUrl {
+		uc.previews.Refresh(link.ID, link.OriginalUrl)
+		return PageMeta{}, nil
+	}
+	if time.Since(stored.FetchedAt) >= previewMetaTTL {
+		uc.previews.Refresh(link.ID, link.OriginalUrl)
+	}
+	return PageMeta{
+		Title:       valueOf(stored.Title),
+		Description: valueOf(stored.Description),
+		ImageURL:    valueOf(stored.ImageUrl),
+	}, nil
+}
+
+// previewToken signs the continue link of a preview page, so that only
+// visitors who saw the page count as click-throughs. Tokens name the link
+// hash and expire after previewTokenTTL.
+func (uc *LinkUseCase) previewToken(hash string, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(previewTokenTTL).Unix(), 10)
+	return expires + "." + uc.signPreview(hash, expires)
+}
+
+// validPreviewToken reports whether token came from a preview page of hash
+// and has not expired.
+func (uc *LinkUseCase) validPreviewToken(hash, token string, now time.Time) bool {
+	expires, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(uc.signPreview(hash, expires)))
+}
+
+func (uc *LinkUseCase) signPreview(hash, expires string) string {
+	mac := hmac.New(sha256.New, uc.previewKey)
+	mac.Write([]byte("preview\x00"))
+	mac.Write([]byte(hash))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// PreviewRefresher fetches the metadata shown on preview pages off the
+// request path, so neither saving a link nor viewing its preview waits for
+// the destination. Requests that find the queue full are dropped; the next
+// view of the preview asks again.
+type PreviewRefresher struct {
+	repo  postgres.Repository
+	pages PageMetaFetcher
+	queue chan previewRequest
+
+	mu      sync.Mutex
+	pending map[int64]bool
+}
+
+type previewRequest struct {
+	linkID int64
+	url    string
+}
+
+func NewPreviewRefresher(repo postgres.Repository, pages PageMetaFetcher) *PreviewRefresher {
+	return &PreviewRefresher{
+		repo:    repo,
+		pages:   pages,
+		queue:   make(chan previewRequest, previewQueueSize),
+		pending: make(map[int64]bool),
+	}
+}
+
+// Refresh asks for the metadata of url, the destination of the link. A link
+// already waiting for its refresh is not queued twice.
+func (r *PreviewRefresher) Refresh(linkID int64, url string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending[linkID] {
+		return
+	}
+	select {
+	case r.queue <- previewRequest{linkID: linkID, url: strings.Clone(url)}:
+		r.pending[linkID] = true
+	default:
+		log.Debug().Int64("link_id", linkID).Msg("Preview refresh queue full, request dropped")
+	}
+}
+
+// Run fetches queued requests until ctx is done.
+func (r *PreviewRefresher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range previewWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case req := <-r.queue:
+					r.fetch(ctx, req)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fetch stores the metadata of req's destination unless fresh metadata is
+// already stored. Failed fetches are stored as empty metadata, so a broken
+// destination is not fetched again before previewMetaTTL passes.
+func (r *PreviewRefresher) fetch(ctx context.Context, req previewRequest) {
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, req.linkID)
+		r.mu.Unlock()
+	}()
+
+	stored, err := r.repo.GetLinkPreview(ctx, req.linkID)
+	if err == nil && stored.OriginalUrl == req.url && time.Since(stored.FetchedAt) < previewMetaTTL {
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, previewFetchTimeout)
+	meta, err := r.pages.Fetch(fetchCtx, req.url)
+	cancel()
+	if err != nil {
+		log.Debug().Err(err).Int64("link_id", req.linkID).Msg("Failed to fetch preview metadata")
+	}
+
+	params := sqldb.UpsertLinkPreviewParams{
+		LinkID:      req.linkID,
+		Title:       nonEmpty(meta.Title),
+		Description: nonEmpty(meta.Description),
+		ImageUrl:    nonEmpty(meta.ImageURL),
+		OriginalUrl: req.url,
+	}
+	if err := r.repo.UpsertLinkPreview(ctx, params); err != nil {
+		log.Error().Err(err).Int64("link_id", req.linkID).Msg("Failed to store link preview")
+	}
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
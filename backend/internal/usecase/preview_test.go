@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreviewToken(t *testing.T) {
+	uc := &LinkUseCase{previewKey: []byte("secret")}
+	now := time.Unix(1700000000, 0)
+	token := uc.previewToken("abc123", now)
+
+	tests := []struct {
+		name  string
+		hash  string
+		token string
+		at    time.Time
+		want  bool
+	}{
+		{"same link", "abc123", token, now.Add(time.Minute), true},
+		{"other link", "xyz789", token, now, false},
+		{"expired", "abc123", token, now.Add(previewTokenTTL + time.Second), false},
+		{"legacy value", "abc123", "preview", now, false},
+		{"empty", "abc123", "", now, false},
+		{"forged expiry", "abc123", "9999999999" + token[len("1700003600"):], now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uc.validPreviewToken(tt.hash, tt.token, tt.at); got != tt.want {
+				t.Errorf("validPreviewToken(%q, %q) = %v, want %v", tt.hash, tt.token, got, tt.want)
+			}
+		})
+	}
+
+	other := &LinkUseCase{previewKey: []byte("other secret")}
+	if other.validPreviewToken("abc123", token, now) {
+		t.Error("token accepted under another key")
+	}
+}
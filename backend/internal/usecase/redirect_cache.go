@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/pkg/lru"
+	sqldb "qrcodegen/sqlc/generated"
+)
+
+// RedirectLink is a link with the schedule entries evaluated at scan time.
+type RedirectLink struct {
+	sqldb.Link
+	Schedules []sqldb.LinkSchedule
+}
+
+// RedirectCache keeps hash → link and host → custom domain lookups for the
+// redirect hot path. Unknown hashes and hosts are cached too (with a shorter
+// TTL) so scans of mistyped or deleted codes don't reach the database
+// either.
+type RedirectCache struct {
+	links       *lru.Cache[string, cachedLink]
+	domains     *lru.Cache[string, cachedDomain]
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	// mu makes checking the generation and storing an entry one step, so an
+	// invalidation cannot slip in between.
+	mu         sync.Mutex
+	generation atomic.Uint64
+}
+
+type cachedLink struct {
+	link  RedirectLink
+	found bool
+}
+
+type cachedDomain struct {
+	domain sqldb.Domain
+	found  bool
+}
+
+// domainChangePrefix marks change notifications about a custom domain
+// rather than a link. Hashes never contain a colon.
+const domainChangePrefix = "domain:"
+
+func NewRedirectCache(cfg *config.Config) *RedirectCache {
+	return &RedirectCache{
+		links:       lru.New[string, cachedLink](cfg.RedirectCacheSize),
+		domains:     lru.New[string, cachedDomain](cfg.RedirectCacheSize),
+		ttl:         cfg.RedirectCacheTTL,
+		negativeTTL: cfg.RedirectCacheNegativeTTL,
+	}
+}
+
+// Get returns the cached link for hash. ok reports a cache hit; found is
+// false for a cached miss.
+func (c *RedirectCache) Get(hash string) (link RedirectLink, found bool, ok bool) {
+	cached, ok := c.links.Get(hash)
+	if !ok {
+		return RedirectLink{}, false, false
+	}
+	return cached.link, cached.found, true
+}
+
+// GetDomain returns the cached verified domain for host, like Get.
+func (c *RedirectCache) GetDomain(host string) (domain sqldb.Domain, found bool, ok bool) {
+	cached, ok := c.domains.Get(host)
+	if !ok {
+		return sqldb.Domain{}, false, false
+	}
+	return cached.domain, cached.found, true
+}
+
+// Generation must be read before loading from the database and passed to
+// the Set methods, so a load that raced with an invalidation is dropped
+// instead of caching the stale row.
+func (c *RedirectCache) Generation() uint64 {
+	return c.generation.Load()
+}
+
+func (c *RedirectCache) Set(link RedirectLink, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation.Load() == generation {
+		c.links.Set(link.Hash, cachedLink{link: link, found: true}, c.ttl)
+	}
+}
+
+func (c *RedirectCache) SetMissing(hash string, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation.Load() == generation {
+		c.links.Set(hash, cachedLink{}, c.negativeTTL)
+	}
+}
+
+// SetDomain caches the verified domain serving host, or its absence when
+// domain is nil.
+func (c *RedirectCache) SetDomain(host string, domain *sqldb.Domain, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation.Load() != generation {
+		return
+	}
+	if domain == nil {
+		c.domains.Set(host, cachedDomain{}, c.negativeTTL)
+		return
+	}
+	c.domains.Set(host, cachedDomain{domain: *domain, found: true}, c.ttl)
+}
+
+func (c *RedirectCache) Invalidate(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation.Add(1)
+	c.links.Delete(hash)
+}
+
+func (c *RedirectCache) InvalidateDomain(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation.Add(1)
+	c.domains.Delete(host)
+}
+
+// Changed handles a payload of the link changes channel: a link hash, or a
+// host behind domainChangePrefix.
+func (c *RedirectCache) Changed(payload string) {
+	if host, ok := strings.CutPrefix(payload, domainChangePrefix); ok {
+		c.InvalidateDomain(host)
+		return
+	}
+	c.Invalidate(payload)
+}
+
+func (c *RedirectCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation.Add(1)
+	c.links.Purge()
+	c.domains.Purge()
+}
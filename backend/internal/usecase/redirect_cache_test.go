@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+	sqldb "qrcodegen/sqlc/generated"
+)
+
+func newTestRedirectCache() *RedirectCache {
+	return NewRedirectCache(&config.Config{
+		RedirectCacheSize:        100,
+		RedirectCacheTTL:         time.Hour,
+		RedirectCacheNegativeTTL: time.Hour,
+	})
+}
+
+func TestRedirectCacheStaleLoad(t *testing.T) {
+	c := newTestRedirectCache()
+
+	generation := c.Generation()
+	c.Invalidate("abc123")
+	c.Set(RedirectLink{Link: sqldb.Link{Hash: "abc123"}}, generation)
+	c.SetMissing("def456", generation)
+	c.SetDomain("go.example.org", nil, generation)
+
+	if _, _, ok := c.Get("abc123"); ok {
+		t.Error("link loaded before an invalidation was cached")
+	}
+	if _, _, ok := c.Get("def456"); ok {
+		t.Error("miss loaded before an invalidation was cached")
+	}
+	if _, _, ok := c.GetDomain("go.example.org"); ok {
+		t.Error("domain loaded before an invalidation was cached")
+	}
+}
+
+func TestRedirectCacheChanged(t *testing.T) {
+	c := newTestRedirectCache()
+	c.Set(RedirectLink{Link: sqldb.Link{Hash: "abc123"}}, c.Generation())
+	c.SetDomain("go.example.org", &sqldb.Domain{ID: 1, Host: "go.example.org"}, c.Generation())
+
+	c.Changed("domain:go.example.org")
+	if _, _, ok := c.GetDomain("go.example.org"); ok {
+		t.Error("domain still cached after its change")
+	}
+	if _, found, ok := c.Get("abc123"); !ok || !found {
+		t.Error("link dropped by a domain change")
+	}
+
+	c.Changed("abc123")
+	if _, _, ok := c.Get("abc123"); ok {
+		t.Error("link still cached after its change")
+	}
+}
+
+// TestRedirectCacheInvalidateRace loads a link while it keeps changing. Once
+// the last change is invalidated, the cache must not hold an older version.
+func TestRedirectCacheInvalidateRace(t *testing.T) {
+	const rounds = 2000
+	c := newTestRedirectCache()
+
+	var stored atomic.Int64 // the link's revision in the "database"
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				if _, _, ok := c.Get("abc123"); ok {
+					continue
+				}
+				generation := c.Generation()
+				revision := stored.Load()
+				c.Set(RedirectLink{Link: sqldb.Link{Hash: "abc123", RevisionID: &revision}}, generation)
+			}
+		}()
+	}
+
+	for i := int64(1); i <= rounds; i++ {
+		stored.Store(i)
+		c.Invalidate("abc123")
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	if link, _, ok := c.Get("abc123"); ok && *link.RevisionID != rounds {
+		t.Errorf("cached revision %d, want %d or nothing", *link.RevisionID, rounds)
+	}
+}
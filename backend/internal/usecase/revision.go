@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	RevisionActionCreate   = "create"
+	RevisionActionEdit     = "edit"
+	RevisionActionRollback = "rollback"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found or access denied")
+
+// recordRevision appends state to the link history and makes it the link's
+// current revision, so new transitions are attributed to it.
+func recordRevision(ctx context.Context, repoWithTx postgres.Repository, state sqldb.CreateLinkRevisionParams) error {
+	revision, err := repoWithTx.CreateLinkRevision(ctx, state)
+	if err != nil {
+		return fmt.Errorf("failed to create link revision: %w", err)
+	}
+
+	if err := repoWithTx.SetLinkRevision(ctx, sqldb.SetLinkRevisionParams{RevisionID: &revision.ID, ID: state.LinkID}); err != nil {
+		return fmt.Errorf("failed to set link revision: %w", err)
+	}
+	return nil
+}
+
+func (uc *LinkUseCase) GetRevisions(ctx context.Context, linkID, userID int64) (*dto.GetLinkRevisionsResponse, error) {
+	link, err := uc.repo.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get link by id: %w", err)
+	}
+
+	rows, err := uc.repo.GetLinkRevisions(ctx, sqldb.GetLinkRevisionsParams{LinkID: linkID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link revisions: %w", err)
+	}
+
+	items := make([]dto.LinkRevisionItem, len(rows))
+	for i, r := range rows {
+		items[i] = dto.LinkRevisionItem{
+			ID:             r.ID,
+			UserID:         r.UserID,
+			Action:         r.Action,
+			OriginalURL:    r.OriginalUrl,
+			Color:          r.Color,
+			Background:     r.Background,
+			Smoothing:      r.Smoothing,
+			DomainID:       r.DomainID,
+			RolledBackFrom: r.RolledBackFrom,
+			CreatedAt:      r.CreatedAt,
+			Transitions:    r.TransitionsCount,
+			Current:        link.RevisionID != nil && *link.RevisionID == r.ID,
+		}
+	}
+
+	return &dto.GetLinkRevisionsResponse{Revisions: items}, nil
+}
+
+// RollbackLink restores the destination and QR styling of a past revision.
+// The rollback itself is recorded as a new revision. Rolling a file link back
+// to a URL revision removes its file.
+func (uc *LinkUseCase) RollbackLink(ctx context.Context, linkID, revisionID, userID int64) (*dto.RollbackLinkResponse, error) {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	target, err := repoWithTx.GetLinkRevision(ctx, sqldb.GetLinkRevisionParams{ID: revisionID, LinkID: linkID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to get link revision: %w", err)
+	}
+
+	link, err := repoWithTx.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link by id: %w", err)
+	}
+
+	// Revisions of a file link have no URL. Only the current file is kept, so
+	// they can be restored only while a file is still attached.
+	var originalURL, detachedKey string
+	if target.OriginalUrl == "" {
+		if link.FileID == nil {
+			return nil, ErrRevisionFileGone
+		}
+	} else {
+		// The old destination may have been blocklisted since.
+		originalURL, err = uc.policy.Check(ctx, target.OriginalUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		detachedKey, err = detachLinkFile(ctx, repoWithTx, linkID, link.FileID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	state := sqldb.CreateLinkRevisionParams{
+		LinkID:         linkID,
+		UserID:         userID,
+		Action:         RevisionActionRollback,
+		OriginalUrl:    originalURL,
+		Color:          target.Color,
+		Background:     target.Background,
+		Smoothing:      target.Smoothing,
+		DomainID:       target.DomainID,
+		RolledBackFrom: &target.ID,
+	}
+	hash, err := uc.updateLink(ctx, repoWithTx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	uc.cache.Invalidate(hash)
+
+	if detachedKey != "" {
+		uc.removeStoredFile(detachedKey)
+	}
+
+	return &dto.RollbackLinkResponse{
+		Message: "Link rolled back successfully",
+		ID:      linkID,
+	}, nil
+}
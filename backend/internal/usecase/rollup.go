@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/rs/zerolog/log"
+)
+
+const rollupWatermark = "transitions"
+
+// RollupAggregator keeps the hourly and daily transition rollups up to date.
+// It adds new transitions to the rollups in id order and records the last id
+// it handled in rollup_state; the row is locked while a batch runs, so
+// several instances can run the aggregator side by side and every batch is
+// added exactly once.
+//
+// Ids are handed out before their transactions commit, so a transition with
+// a lower id can become visible after a higher one. The aggregator therefore
+// only goes as far as the settled id: the last id handed out as of a
+// previous run, once every transaction that was running then has ended.
+// That relies on the transition writer taking its transaction id before it
+// takes transition ids, which locking the links of a batch does.
+type RollupAggregator struct {
+	repo      postgres.Repository
+	interval  time.Duration
+	batchSize int64
+}
+
+func NewRollupAggregator(repo postgres.Repository, cfg *config.Config) *RollupAggregator {
+	return &RollupAggregator{
+		repo:      repo,
+		interval:  cfg.RollupInterval,
+		batchSize: int64(max(cfg.RollupBatchSize, 1)),
+	}
+}
+
+// Run blocks until ctx is done. A non-positive interval disables the
+// aggregator, leaving link counts and stats at their last rolled up values.
+// On the first start after the rollups were added it also backfills the
+// transitions recorded before them.
+func (a *RollupAggregator) Run(ctx context.Context) {
+	if a.interval <= 0 {
+		log.Info().Msg("Transition rollups disabled")
+		return
+	}
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	backfilled := false
+	for {
+		if !backfilled {
+			if err := a.backfillOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Error().Err(err).Msg("Failed to backfill transition rollups")
+			} else {
+				backfilled = true
+			}
+		}
+		if err := a.catchUp(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to roll up transitions")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *RollupAggregator) catchUp(ctx context.Context) error {
+	settled, err := a.settle(ctx)
+	if err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		more, err := a.advance(ctx, settled)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// settle returns the settled id, first promoting the pending id when it has
+// settled, and records a new pending id whenever there is none waiting.
+func (a *RollupAggregator) settle(ctx context.Context) (int64, error) {
+	tx, err := a.repo.BeginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := a.repo.WithTX(tx)
+
+	state, err := repoWithTx.GetRollupSettling(ctx, rollupWatermark)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rollup settling: %w", err)
+	}
+	settled := state.SettledTransitionID
+	if state.PendingSettled {
+		settled = max(settled, state.PendingTransitionID)
+	} else if state.PendingTransitionID > settled {
+		// Still waiting for the transactions of the pending id.
+		return settled, nil
+	}
+
+	last, err := repoWithTx.GetLastTransitionID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last transition id: %w", err)
+	}
+	if err := repoWithTx.SetRollupPending(ctx, sqldb.SetRollupPendingParams{
+		SettledTransitionID: settled,
+		PendingTransitionID: last,
+		Name:                rollupWatermark,
+	}); err != nil {
+		return 0, fmt.Errorf("failed to set pending rollup id: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return settled, nil
+}
+
+// advance rolls up the next batch after the watermark, up to the settled
+// id, and moves the watermark past it. It reports whether a batch was
+// rolled up.
+func (a *RollupAggregator) advance(ctx context.Context, settled int64) (bool, error) {
+	tx, err := a.repo.BeginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := a.repo.WithTX(tx)
+
+	watermark, err := repoWithTx.GetRollupWatermark(ctx, rollupWatermark)
+	if err != nil {
+		return false, fmt.Errorf("failed to get rollup watermark: %w", err)
+	}
+
+	upTo, err := repoWithTx.GetRollupUpperBound(ctx, sqldb.GetRollupUpperBoundParams{
+		AfterID:   watermark,
+		SettledID: settled,
+		BatchSize: a.batchSize,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get rollup upper bound: %w", err)
+	}
+	if upTo <= watermark {
+		return false, nil
+	}
+
+	if err := rollUp(ctx, repoWithTx, watermark, upTo); err != nil {
+		return false, err
+	}
+
+	if err := repoWithTx.SetRollupWatermark(ctx, sqldb.SetRollupWatermarkParams{
+		Name:             rollupWatermark,
+		LastTransitionID: upTo,
+	}); err != nil {
+		return false, fmt.Errorf("failed to set rollup watermark: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// Backfill recounts the buckets of every transition up to the watermark,
+// i.e. those that existed before the aggregator was deployed, and returns
+// the last id it handled. Recounting is idempotent, so it can be rerun to
+// rebuild the rollups; the watermark and newer transitions are left to Run.
+func (a *RollupAggregator) Backfill(ctx context.Context) (int64, error) {
+	var after int64
+	for {
+		upTo, err := a.backfillBatch(ctx, after)
+		if err != nil {
+			return after, err
+		}
+		if upTo <= after {
+			return after, nil
+		}
+		log.Info().Int64("last_transition_id", upTo).Msg("Rolled up transitions")
+		after = upTo
+	}
+}
+
+// backfillOnce runs Backfill unless it has completed before.
+func (a *RollupAggregator) backfillOnce(ctx context.Context) error {
+	backfilled, err := a.repo.GetRollupBackfilled(ctx, rollupWatermark)
+	if err != nil {
+		return fmt.Errorf("failed to get rollup backfill state: %w", err)
+	}
+	if backfilled {
+		return nil
+	}
+
+	log.Info().Msg("Backfilling transition rollups")
+	last, err := a.Backfill(ctx)
+	if err != nil {
+		return err
+	}
+	if err := a.repo.SetRollupBackfilled(ctx, rollupWatermark); err != nil {
+		return fmt.Errorf("failed to set rollup backfill state: %w", err)
+	}
+	log.Info().Int64("last_transition_id", last).Msg("Transition rollups backfilled")
+	return nil
+}
+
+func (a *RollupAggregator) backfillBatch(ctx context.Context, after int64) (int64, error) {
+	tx, err := a.repo.BeginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := a.repo.WithTX(tx)
+
+	// Holding the watermark lock keeps Run from writing the same buckets
+	// concurrently.
+	watermark, err := repoWithTx.GetRollupWatermark(ctx, rollupWatermark)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rollup watermark: %w", err)
+	}
+
+	upTo, err := repoWithTx.GetRollupUpperBound(ctx, sqldb.GetRollupUpperBoundParams{
+		AfterID:   after,
+		SettledID: watermark,
+		BatchSize: a.batchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rollup upper bound: %w", err)
+	}
+	if upTo <= after {
+		return after, nil
+	}
+
+	if err := recount(ctx, repoWithTx, after, upTo, watermark); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return upTo, nil
+}
+
+// rollUp adds transitions with ids in (after, upTo] to the hourly and daily
+// buckets and the per-link last transition time.
+func rollUp(ctx context.Context, repo postgres.Repository, after, upTo int64) error {
+	if err := repo.RollupTransitionsHourly(ctx, sqldb.RollupTransitionsHourlyParams{AfterID: after, UpToID: upTo}); err != nil {
+		return fmt.Errorf("failed to roll up hourly transitions: %w", err)
+	}
+	if err := repo.RollupTransitionsDaily(ctx, sqldb.RollupTransitionsDailyParams{AfterID: after, UpToID: upTo}); err != nil {
+		return fmt.Errorf("failed to roll up daily transitions: %w", err)
+	}
+	if err := repo.RollupLastTransitions(ctx, sqldb.RollupLastTransitionsParams{AfterID: after, UpToID: upTo}); err != nil {
+		return fmt.Errorf("failed to roll up last transitions: %w", err)
+	}
+	return nil
+}
+
+// recount rebuilds the hourly and daily buckets touched by transitions with
+// ids in (after, upTo] from every transition up to the watermark.
+func recount(ctx context.Context, repo postgres.Repository, after, upTo, watermark int64) error {
+	hourly := sqldb.RecountTransitionsHourlyParams{AfterID: after, UpToID: upTo, Watermark: watermark}
+	if err := repo.RecountTransitionsHourly(ctx, hourly); err != nil {
+		return fmt.Errorf("failed to recount hourly transitions: %w", err)
+	}
+	daily := sqldb.RecountTransitionsDailyParams{AfterID: after, UpToID: upTo, Watermark: watermark}
+	if err := repo.RecountTransitionsDaily(ctx, daily); err != nil {
+		return fmt.Errorf("failed to recount daily transitions: %w", err)
+	}
+	if err := repo.RollupLastTransitions(ctx, sqldb.RollupLastTransitionsParams{AfterID: after, UpToID: upTo}); err != nil {
+		return fmt.Errorf("failed to roll up last transitions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// rollupRepo serves a fixed rollup state and records what the aggregator
+// writes back. The upper bound query finds no transitions.
+type rollupRepo struct {
+	postgres.Repository
+	state   sqldb.GetRollupSettlingRow
+	last    int64
+	pending *sqldb.SetRollupPendingParams
+	bound   *sqldb.GetRollupUpperBoundParams
+}
+
+func (r *rollupRepo) BeginTx(ctx context.Context) (pgx.Tx, error) { return commitTx{}, nil }
+func (r *rollupRepo) WithTX(tx pgx.Tx) postgres.Repository        { return r }
+
+func (r *rollupRepo) GetRollupSettling(ctx context.Context, name string) (sqldb.GetRollupSettlingRow, error) {
+	return r.state, nil
+}
+
+func (r *rollupRepo) GetLastTransitionID(ctx context.Context) (int64, error) {
+	return r.last, nil
+}
+
+func (r *rollupRepo) SetRollupPending(ctx context.Context, arg sqldb.SetRollupPendingParams) error {
+	r.pending = &arg
+	return nil
+}
+
+func (r *rollupRepo) GetRollupWatermark(ctx context.Context, name string) (int64, error) {
+	return 5, nil
+}
+
+func (r *rollupRepo) GetRollupUpperBound(ctx context.Context, arg sqldb.GetRollupUpperBoundParams) (int64, error) {
+	r.bound = &arg
+	return arg.AfterID, nil
+}
+
+func TestRollupAggregatorSettle(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       sqldb.GetRollupSettlingRow
+		wantSettled int64
+		// wantPending is the newly recorded pending id, 0 for none.
+		wantPending int64
+	}{
+		{"first run", sqldb.GetRollupSettlingRow{SettledTransitionID: 10}, 10, 30},
+		{"pending settled", sqldb.GetRollupSettlingRow{SettledTransitionID: 10, PendingTransitionID: 20, PendingSettled: true}, 20, 30},
+		{"pending still running", sqldb.GetRollupSettlingRow{SettledTransitionID: 10, PendingTransitionID: 20}, 10, 0},
+		{"nothing new", sqldb.GetRollupSettlingRow{SettledTransitionID: 20, PendingTransitionID: 20}, 20, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &rollupRepo{state: tt.state, last: 30}
+			a := NewRollupAggregator(repo, &config.Config{})
+
+			if err := a.catchUp(context.Background()); err != nil {
+				t.Fatalf("catchUp() error = %v", err)
+			}
+
+			if repo.bound == nil || repo.bound.SettledID != tt.wantSettled {
+				t.Errorf("upper bound params = %+v, want settled id %d", repo.bound, tt.wantSettled)
+			}
+			switch {
+			case tt.wantPending == 0 && repo.pending != nil:
+				t.Errorf("recorded pending %+v while the last one is still running", *repo.pending)
+			case tt.wantPending != 0 && repo.pending == nil:
+				t.Errorf("recorded no pending id, want %d", tt.wantPending)
+			case tt.wantPending != 0 && (repo.pending.PendingTransitionID != tt.wantPending || repo.pending.SettledTransitionID != tt.wantSettled):
+				t.Errorf("recorded %+v, want pending %d and settled %d", *repo.pending, tt.wantPending, tt.wantSettled)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+
+	"qrcodegen/internal/dto"
+)
+
+const scanSubscriptionBuffer = 64
+
+// ScanBroker fans recorded scans out to live feeds. The in-process broker
+// only reaches feeds served by the same instance; one that relays events
+// through LISTEN/NOTIFY can take its place when several instances run.
+type ScanBroker interface {
+	Publish(event dto.ScanEvent)
+	// Subscribe returns the scans of the user's links, or of one link when
+	// linkID is set.
+	Subscribe(userID int64, linkID *int64) *ScanSubscription
+	// Close ends every subscription.
+	Close()
+}
+
+// ScanSubscription delivers scans on Events until it is closed, by Close or
+// by the broker shutting down. Scans are dropped rather than queued while a
+// subscriber is more than scanSubscriptionBuffer events behind, so a slow
+// feed never holds up redirects.
+type ScanSubscription struct {
+	Events <-chan dto.ScanEvent
+
+	events chan dto.ScanEvent
+	userID int64
+	linkID *int64
+	close  func()
+}
+
+func (s *ScanSubscription) Close() {
+	s.close()
+}
+
+type localScanBroker struct {
+	mu     sync.RWMutex
+	subs   map[int64]map[*ScanSubscription]struct{}
+	closed bool
+}
+
+func NewScanBroker() ScanBroker {
+	return &localScanBroker{subs: make(map[int64]map[*ScanSubscription]struct{})}
+}
+
+func (b *localScanBroker) Publish(event dto.ScanEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs[event.UserID] {
+		if sub.linkID != nil && *sub.linkID != event.LinkID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+func (b *localScanBroker) Subscribe(userID int64, linkID *int64) *ScanSubscription {
+	events := make(chan dto.ScanEvent, scanSubscriptionBuffer)
+	sub := &ScanSubscription{Events: events, events: events, userID: userID, linkID: linkID}
+	sub.close = sync.OnceFunc(func() { b.unsubscribe(sub) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(events)
+		return sub
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*ScanSubscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+func (b *localScanBroker) unsubscribe(sub *ScanSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subs[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+	close(sub.events)
+}
+
+func (b *localScanBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			close(sub.events)
+		}
+	}
+	clear(b.subs)
+}
+
+// SubscribeScans opens a live feed of the user's scans, limited to one link
+// when linkID is set.
+func (uc *LinkUseCase) SubscribeScans(ctx context.Context, userID int64, linkID *int64) (*ScanSubscription, error) {
+	if linkID != nil {
+		if _, err := ownedLinkHash(ctx, uc.repo, *linkID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return uc.scans.Subscribe(userID, linkID), nil
+}
@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ScheduleOnce   = "once"
+	ScheduleWeekly = "weekly"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrInvalidSchedule  = errors.New("invalid schedule")
+)
+
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// scheduleLocations caches loaded time zones; loading one reads the zone
+// database, which is too slow for every scan.
+var scheduleLocations sync.Map
+
+func (uc *LinkUseCase) GetLinkSchedules(ctx context.Context, linkID, userID int64) (*dto.GetLinkSchedulesResponse, error) {
+	if _, err := ownedLinkHash(ctx, uc.repo, linkID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.repo.GetLinkSchedulesByLinkID(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link schedules: %w", err)
+	}
+
+	active := activeSchedule(rows, time.Now())
+	schedules := make([]dto.LinkScheduleInfo, len(rows))
+	for i, r := range rows {
+		schedules[i] = scheduleInfo(r)
+		schedules[i].Active = active != nil && active.ID == r.ID
+	}
+	return &dto.GetLinkSchedulesResponse{Schedules: schedules}, nil
+}
+
+func (uc *LinkUseCase) CreateLinkSchedule(ctx context.Context, linkID, userID int64, req dto.CreateLinkScheduleRequest) (*dto.LinkScheduleInfo, error) {
+	params, err := scheduleParams(req)
+	if err != nil {
+		return nil, err
+	}
+	params.LinkID = linkID
+	params.OriginalUrl, err = uc.policy.Check(ctx, req.OriginalURL)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	hash, err := ownedLinkHash(ctx, repoWithTx, linkID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := repoWithTx.CreateLinkSchedule(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link schedule: %w", err)
+	}
+
+	if err := repoWithTx.NotifyLinkChanged(ctx, hash); err != nil {
+		return nil, fmt.Errorf("failed to notify link change: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	uc.cache.Invalidate(hash)
+
+	info := scheduleInfo(schedule)
+	info.Active = scheduleActive(schedule, time.Now())
+	return &info, nil
+}
+
+func (uc *LinkUseCase) DeleteLinkSchedule(ctx context.Context, linkID, scheduleID, userID int64) error {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	hash, err := ownedLinkHash(ctx, repoWithTx, linkID, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := repoWithTx.DeleteLinkSchedule(ctx, sqldb.DeleteLinkScheduleParams{ID: scheduleID, LinkID: linkID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrScheduleNotFound
+		}
+		return fmt.Errorf("failed to delete link schedule: %w", err)
+	}
+
+	if err := repoWithTx.NotifyLinkChanged(ctx, hash); err != nil {
+		return fmt.Errorf("failed to notify link change: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	uc.cache.Invalidate(hash)
+
+	return nil
+}
+
+func ownedLinkHash(ctx context.Context, repo postgres.Repository, linkID, userID int64) (string, error) {
+	link, err := repo.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrLinkNotFound
+		}
+		return "", fmt.Errorf("failed to get link by id: %w", err)
+	}
+	return link.Hash, nil
+}
+
+func scheduleParams(req dto.CreateLinkScheduleRequest) (sqldb.CreateLinkScheduleParams, error) {
+	params := sqldb.CreateLinkScheduleParams{
+		Kind:     req.Kind,
+		TimeZone: req.TimeZone,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	}
+	if params.TimeZone == "" {
+		params.TimeZone = "UTC"
+	}
+	if _, err := parseTimeZone(params.TimeZone); err != nil {
+		return params, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return params, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSchedule)
+	}
+
+	switch req.Kind {
+	case ScheduleOnce:
+		if req.StartsAt == nil {
+			return params, fmt.Errorf("%w: starts_at is required", ErrInvalidSchedule)
+		}
+		if len(req.Weekdays) > 0 || req.StartTime != "" || req.EndTime != "" {
+			return params, fmt.Errorf("%w: weekdays and times only apply to weekly schedules", ErrInvalidSchedule)
+		}
+	case ScheduleWeekly:
+		if len(req.Weekdays) == 0 {
+			return params, fmt.Errorf("%w: weekdays are required", ErrInvalidSchedule)
+		}
+		for _, day := range req.Weekdays {
+			params.Weekdays |= 1 << slices.Index(weekdayNames[:], day)
+		}
+		var err error
+		if params.StartMinute, err = parseClock(req.StartTime); err != nil {
+			return params, fmt.Errorf("%w: start_time must be HH:MM", ErrInvalidSchedule)
+		}
+		if params.EndMinute, err = parseClock(req.EndTime); err != nil {
+			return params, fmt.Errorf("%w: end_time must be HH:MM", ErrInvalidSchedule)
+		}
+		if params.StartMinute == params.EndMinute {
+			return params, fmt.Errorf("%w: start_time and end_time must differ", ErrInvalidSchedule)
+		}
+	default:
+		return params, fmt.Errorf("%w: unknown kind %q", ErrInvalidSchedule, req.Kind)
+	}
+	return params, nil
+}
+
+func parseClock(s string) (int64, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return int64(t.Hour()*60 + t.Minute()), nil
+}
+
+func formatClock(minute int64) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func scheduleInfo(s sqldb.LinkSchedule) dto.LinkScheduleInfo {
+	info := dto.LinkScheduleInfo{
+		ID:          s.ID,
+		Kind:        s.Kind,
+		OriginalURL: s.OriginalUrl,
+		TimeZone:    s.TimeZone,
+		StartsAt:    s.StartsAt,
+		EndsAt:      s.EndsAt,
+		CreatedAt:   s.CreatedAt,
+	}
+	if s.Kind == ScheduleWeekly {
+		for i, name := range weekdayNames {
+			if s.Weekdays&(1<<i) != 0 {
+				info.Weekdays = append(info.Weekdays, name)
+			}
+		}
+		info.StartTime = formatClock(s.StartMinute)
+		info.EndTime = formatClock(s.EndMinute)
+	}
+	return info
+}
+
+// activeSchedule returns the entry in effect at now, or nil when scans go to
+// the link's own destination. Weekly windows take precedence over one-off
+// switches; among windows the oldest entry wins, among switches the one that
+// started last.
+func activeSchedule(schedules []sqldb.LinkSchedule, now time.Time) *sqldb.LinkSchedule {
+	var switched *sqldb.LinkSchedule
+	for i := range schedules {
+		s := &schedules[i]
+		if !scheduleActive(*s, now) {
+			continue
+		}
+		if s.Kind == ScheduleWeekly {
+			return s
+		}
+		if switched == nil || s.StartsAt.After(*switched.StartsAt) {
+			switched = s
+		}
+	}
+	return switched
+}
+
+func scheduleActive(s sqldb.LinkSchedule, now time.Time) bool {
+	if s.StartsAt != nil && now.Before(*s.StartsAt) {
+		return false
+	}
+	if s.EndsAt != nil && !now.Before(*s.EndsAt) {
+		return false
+	}
+	if s.Kind != ScheduleWeekly {
+		return s.Kind == ScheduleOnce && s.StartsAt != nil
+	}
+
+	local := now.In(scheduleLocation(s.TimeZone))
+	day := int(local.Weekday())
+	minute := int64(local.Hour()*60 + local.Minute())
+	onDay := func(d int) bool { return s.Weekdays&(1<<d) != 0 }
+
+	if s.StartMinute < s.EndMinute {
+		return onDay(day) && minute >= s.StartMinute && minute < s.EndMinute
+	}
+	// The window runs past midnight into the next day.
+	return (onDay(day) && minute >= s.StartMinute) || (onDay((day+6)%7) && minute < s.EndMinute)
+}
+
+func scheduleLocation(name string) *time.Location {
+	if loc, ok := scheduleLocations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	scheduleLocations.Store(name, loc)
+	return loc
+}
+
+// copyLinkSchedules gives a cloned link the schedule of its source.
+func copyLinkSchedules(ctx context.Context, repoWithTx postgres.Repository, fromLinkID, toLinkID int64) error {
+	schedules, err := repoWithTx.GetLinkSchedulesByLinkID(ctx, fromLinkID)
+	if err != nil {
+		return fmt.Errorf("failed to get link schedules: %w", err)
+	}
+	for _, s := range schedules {
+		params := sqldb.CreateLinkScheduleParams{
+			LinkID:      toLinkID,
+			Kind:        s.Kind,
+			OriginalUrl: s.OriginalUrl,
+			TimeZone:    s.TimeZone,
+			StartsAt:    s.StartsAt,
+			EndsAt:      s.EndsAt,
+			Weekdays:    s.Weekdays,
+			StartMinute: s.StartMinute,
+			EndMinute:   s.EndMinute,
+		}
+		if _, err := repoWithTx.CreateLinkSchedule(ctx, params); err != nil {
+			return fmt.Errorf("failed to copy link schedule: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	sqldb "qrcodegen/sqlc/generated"
+)
+
+func weeklySchedule(id int64, tz string, start, end string, days ...time.Weekday) sqldb.LinkSchedule {
+	s := sqldb.LinkSchedule{ID: id, Kind: ScheduleWeekly, TimeZone: tz, OriginalUrl: "https://example.org/weekly"}
+	for _, d := range days {
+		s.Weekdays |= 1 << d
+	}
+	s.StartMinute, _ = parseClock(start)
+	s.EndMinute, _ = parseClock(end)
+	return s
+}
+
+func onceSchedule(id int64, starts string, ends string) sqldb.LinkSchedule {
+	s := sqldb.LinkSchedule{ID: id, Kind: ScheduleOnce, TimeZone: "UTC", OriginalUrl: "https://example.org/once"}
+	startsAt := mustTime(starts)
+	s.StartsAt = &startsAt
+	if ends != "" {
+		endsAt := mustTime(ends)
+		s.EndsAt = &endsAt
+	}
+	return s
+}
+
+func mustTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestScheduleActive(t *testing.T) {
+	// 2026-03-02 is a Monday.
+	overnight := weeklySchedule(1, "UTC", "22:00", "02:00", time.Friday)
+	berlinMonday := weeklySchedule(1, "Europe/Berlin", "00:00", "01:00", time.Monday)
+	newYorkMorning := weeklySchedule(1, "America/New_York", "08:00", "09:00", time.Monday)
+	// Berlin skips 02:00-03:00 on 2026-03-29 and repeats it on 2026-10-25.
+	berlinSunday := weeklySchedule(1, "Europe/Berlin", "02:00", "02:30", time.Sunday)
+
+	bounded := weeklySchedule(1, "UTC", "09:00", "17:00", time.Monday)
+	boundedFrom, boundedUntil := mustTime("2026-03-01T00:00:00Z"), mustTime("2026-03-09T12:00:00Z")
+	bounded.StartsAt, bounded.EndsAt = &boundedFrom, &boundedUntil
+
+	tests := []struct {
+		name     string
+		schedule sqldb.LinkSchedule
+		now      string
+		want     bool
+	}{
+		{"overnight before start", overnight, "2026-03-06T21:59:00Z", false},
+		{"overnight on the day", overnight, "2026-03-06T23:30:00Z", true},
+		{"overnight after midnight", overnight, "2026-03-07T01:59:00Z", true},
+		{"overnight at the end", overnight, "2026-03-07T02:00:00Z", false},
+		{"overnight from the wrong day", overnight, "2026-03-06T01:00:00Z", false},
+
+		// Monday 00:30 in Berlin is still Sunday in UTC.
+		{"zone weekday ahead of UTC", berlinMonday, "2026-03-01T23:30:00Z", true},
+		{"UTC weekday is not the zone's", berlinMonday, "2026-03-02T00:30:00Z", false},
+
+		// The window stays at 08:00 local as the offset changes on 2026-03-08.
+		{"standard time", newYorkMorning, "2026-03-02T13:30:00Z", true},
+		{"daylight time", newYorkMorning, "2026-03-09T12:30:00Z", true},
+		{"daylight time, old offset", newYorkMorning, "2026-03-09T13:30:00Z", false},
+		{"skipped hour before", berlinSunday, "2026-03-29T00:59:00Z", false},
+		{"skipped hour after", berlinSunday, "2026-03-29T01:00:00Z", false},
+		{"repeated hour, first", berlinSunday, "2026-10-25T00:15:00Z", true},
+		{"repeated hour, second", berlinSunday, "2026-10-25T01:15:00Z", true},
+
+		{"weekly before starts_at", bounded, "2026-02-23T10:00:00Z", false},
+		{"weekly within bounds", bounded, "2026-03-02T10:00:00Z", true},
+		{"weekly at ends_at", bounded, "2026-03-09T12:00:00Z", false},
+
+		{"once before start", onceSchedule(1, "2026-03-02T10:00:00Z", ""), "2026-03-02T09:59:59Z", false},
+		{"once open ended", onceSchedule(1, "2026-03-02T10:00:00Z", ""), "2027-01-01T00:00:00Z", true},
+		{"once at end", onceSchedule(1, "2026-03-02T10:00:00Z", "2026-03-02T11:00:00Z"), "2026-03-02T11:00:00Z", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduleActive(tt.schedule, mustTime(tt.now)); got != tt.want {
+				t.Errorf("scheduleActive() at %s = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveSchedule(t *testing.T) {
+	now := mustTime("2026-03-02T10:00:00Z")
+	earlySwitch := onceSchedule(1, "2026-03-01T00:00:00Z", "")
+	lateSwitch := onceSchedule(2, "2026-03-02T09:00:00Z", "")
+	endedSwitch := onceSchedule(3, "2026-03-02T09:30:00Z", "2026-03-02T09:45:00Z")
+	window := weeklySchedule(4, "UTC", "09:00", "17:00", time.Monday)
+	laterWindow := weeklySchedule(5, "UTC", "08:00", "12:00", time.Monday)
+	closedWindow := weeklySchedule(6, "UTC", "09:00", "17:00", time.Tuesday)
+
+	tests := []struct {
+		name      string
+		schedules []sqldb.LinkSchedule
+		want      int64
+	}{
+		{"nothing scheduled", nil, 0},
+		{"nothing active", []sqldb.LinkSchedule{endedSwitch, closedWindow}, 0},
+		{"latest switch wins", []sqldb.LinkSchedule{earlySwitch, lateSwitch, endedSwitch}, 2},
+		{"window overrides switch", []sqldb.LinkSchedule{lateSwitch, window}, 4},
+		{"oldest window wins", []sqldb.LinkSchedule{earlySwitch, window, laterWindow}, 4},
+		{"switch when window closed", []sqldb.LinkSchedule{closedWindow, earlySwitch}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int64
+			if s := activeSchedule(tt.schedules, now); s != nil {
+				got = s.ID
+			}
+			if got != tt.want {
+				t.Errorf("activeSchedule() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
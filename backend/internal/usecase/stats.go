@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"qrcodegen/internal/dto"
+	sqldb "qrcodegen/sqlc/generated"
+)
+
+const (
+	defaultStatsTop = 10
+	maxStatsTop     = 100
+	maxStatsBuckets = 10000
+)
+
+var ErrInvalidStatsQuery = errors.New("invalid stats query")
+
+var statsGranularities = []string{"hour", "day", "week", "month"}
+
+// GetLinkStats aggregates a link's transitions into a time series and top
+// values per dimension. Buckets follow date_trunc in the requested time
+// zone, so weeks start on Monday; buckets without scans are filled with
+// zeros. Counts come from the rollups kept by RollupAggregator, which count
+// unique visitors per hour and per day only; longer buckets and the total
+// are sums of daily uniques and are labelled as such.
+func (uc *LinkUseCase) GetLinkStats(ctx context.Context, linkID, userID int64, query dto.LinkStatsQuery) (*dto.LinkStatsResponse, error) {
+	if query.Granularity == "" {
+		query.Granularity = "day"
+	}
+	if !slices.Contains(statsGranularities, query.Granularity) {
+		return nil, fmt.Errorf("%w: granularity must be hour, day, week or month", ErrInvalidStatsQuery)
+	}
+	if query.TimeZone == "" {
+		query.TimeZone = "UTC"
+	}
+	loc, err := parseTimeZone(query.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidStatsQuery, err)
+	}
+	if query.Top <= 0 {
+		query.Top = defaultStatsTop
+	}
+	query.Top = min(query.Top, maxStatsTop)
+
+	if _, err := ownedLinkHash(ctx, uc.repo, linkID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.repo.GetLinkStatsSeries(ctx, sqldb.GetLinkStatsSeriesParams{
+		Granularity: query.Granularity,
+		TimeZone:    query.TimeZone,
+		LinkID:      linkID,
+		FromTime:    query.Range.From,
+		ToTime:      query.Range.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link stats series: %w", err)
+	}
+
+	series, err := fillStatsSeries(rows, query.Granularity, loc, query.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdowns, err := uc.repo.GetLinkStatsBreakdowns(ctx, sqldb.GetLinkStatsBreakdownsParams{
+		LinkID:   linkID,
+		FromTime: query.Range.From,
+		ToTime:   query.Range.To,
+		TopN:     int64(query.Top),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link stats breakdowns: %w", err)
+	}
+
+	resp := &dto.LinkStatsResponse{
+		LinkID:                  linkID,
+		From:                    query.Range.From,
+		To:                      query.Range.To,
+		Granularity:             query.Granularity,
+		TimeZone:                query.TimeZone,
+		UniqueVisitorsPer:       "day",
+		Series:                  series,
+		SeriesUniqueVisitorsPer: uniqueVisitorsWindow(query.Granularity),
+		Breakdowns: dto.LinkStatsBreakdowns{
+			Countries: []dto.BreakdownItem{},
+			Cities:    []dto.BreakdownItem{},
+			Browsers:  []dto.BreakdownItem{},
+			OS:        []dto.BreakdownItem{},
+			Referers:  []dto.BreakdownItem{},
+		},
+	}
+	totals, err := uc.repo.GetLinkStatsTotals(ctx, sqldb.GetLinkStatsTotalsParams{
+		LinkID:   linkID,
+		FromTime: query.Range.From,
+		ToTime:   query.Range.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link stats totals: %w", err)
+	}
+	resp.Transitions = totals.TransitionsCount
+	resp.UniqueVisitors = totals.UniqueVisitors
+
+	for _, b := range breakdowns {
+		item := dto.BreakdownItem{Value: b.Value, Transitions: b.TransitionsCount}
+		switch b.Dimension {
+		case "country":
+			resp.Breakdowns.Countries = append(resp.Breakdowns.Countries, item)
+		case "city":
+			resp.Breakdowns.Cities = append(resp.Breakdowns.Cities, item)
+		case "browser":
+			resp.Breakdowns.Browsers = append(resp.Breakdowns.Browsers, item)
+		case "os":
+			resp.Breakdowns.OS = append(resp.Breakdowns.OS, item)
+		case "referer":
+			resp.Breakdowns.Referers = append(resp.Breakdowns.Referers, item)
+		}
+	}
+
+	return resp, nil
+}
+
+// uniqueVisitorsWindow is the window the series counts unique visitors in:
+// hourly buckets read the hourly rollups, all others add up the daily ones.
+func uniqueVisitorsWindow(granularity string) string {
+	if granularity == "hour" {
+		return "hour"
+	}
+	return "day"
+}
+
+// fillStatsSeries returns a bucket for every period between the start of the
+// range (or the first scan) and its end (or now).
+func fillStatsSeries(rows []sqldb.GetLinkStatsSeriesRow, granularity string, loc *time.Location, rng dto.StatsRange) ([]dto.StatsBucket, error) {
+	counts := make(map[int64]sqldb.GetLinkStatsSeriesRow, len(rows))
+	for _, r := range rows {
+		counts[r.Bucket.Unix()] = r
+	}
+
+	var start time.Time
+	switch {
+	case rng.From != nil:
+		start = truncateBucket(*rng.From, granularity, loc)
+	case len(rows) > 0:
+		start = rows[0].Bucket
+	default:
+		return []dto.StatsBucket{}, nil
+	}
+	end := time.Now()
+	if rng.To != nil {
+		end = *rng.To
+	}
+
+	series := []dto.StatsBucket{}
+	for t := start; t.Before(end); t = nextBucket(t, granularity, loc) {
+		if len(series) == maxStatsBuckets {
+			return nil, fmt.Errorf("%w: range has more than %d %s buckets", ErrInvalidStatsQuery, maxStatsBuckets, granularity)
+		}
+		r := counts[t.Unix()]
+		series = append(series, dto.StatsBucket{Time: t, Transitions: r.TransitionsCount, UniqueVisitors: r.UniqueVisitors})
+		delete(counts, t.Unix())
+	}
+
+	// Buckets the walk above did not produce, e.g. around DST changes, are
+	// kept as the database returned them.
+	for _, r := range rows {
+		if _, ok := counts[r.Bucket.Unix()]; ok {
+			series = append(series, dto.StatsBucket{Time: r.Bucket, Transitions: r.TransitionsCount, UniqueVisitors: r.UniqueVisitors})
+		}
+	}
+	slices.SortFunc(series, func(a, b dto.StatsBucket) int { return a.Time.Compare(b.Time) })
+	return series, nil
+}
+
+// truncateBucket mirrors date_trunc(granularity, t, zone).
+func truncateBucket(t time.Time, granularity string, loc *time.Location) time.Time {
+	l := t.In(loc)
+	switch granularity {
+	case "hour":
+		return time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), 0, 0, 0, loc)
+	case "week":
+		offset := (int(l.Weekday()) + 6) % 7
+		return time.Date(l.Year(), l.Month(), l.Day()-offset, 0, 0, 0, 0, loc)
+	case "month":
+		return time.Date(l.Year(), l.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(l.Year(), l.Month(), l.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+func nextBucket(t time.Time, granularity string, loc *time.Location) time.Time {
+	l := t.In(loc)
+	switch granularity {
+	case "hour":
+		// Step in absolute time; a repeated local hour truncates back to t.
+		for n := t.Add(time.Hour); ; n = n.Add(time.Hour) {
+			if next := truncateBucket(n, granularity, loc); next.After(t) {
+				return next
+			}
+		}
+	case "week":
+		return time.Date(l.Year(), l.Month(), l.Day()+7, 0, 0, 0, 0, loc)
+	case "month":
+		return time.Date(l.Year(), l.Month()+1, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(l.Year(), l.Month(), l.Day()+1, 0, 0, 0, 0, loc)
+	}
+}
+
+// parseTimeZone loads an IANA time zone. "Local" is refused since it
+// depends on the server.
+func parseTimeZone(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
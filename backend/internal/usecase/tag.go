@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrTagNotFound      = errors.New("tag not found or access denied")
+	ErrTagAlreadyExists = errors.New("tag with this name already exists")
+)
+
+type TagUseCase struct {
+	repo postgres.Repository
+}
+
+func NewTagUseCase(repo postgres.Repository) *TagUseCase {
+	return &TagUseCase{repo: repo}
+}
+
+func (uc *TagUseCase) CreateTag(ctx context.Context, req dto.TagRequest, userID int64) (*dto.TagInfo, error) {
+	if err := uc.checkNameFree(ctx, userID, req.Name); err != nil {
+		return nil, err
+	}
+
+	tag, err := uc.repo.CreateTag(ctx, sqldb.CreateTagParams{UserID: userID, Name: req.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return &dto.TagInfo{ID: tag.ID, Name: tag.Name, CreatedAt: tag.CreatedAt}, nil
+}
+
+func (uc *TagUseCase) GetTags(ctx context.Context, userID int64) (*dto.GetTagsResponse, error) {
+	rows, err := uc.repo.GetTagsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags by user id: %w", err)
+	}
+
+	tags := make([]dto.TagInfo, len(rows))
+	for i, r := range rows {
+		tags[i] = dto.TagInfo{ID: r.ID, Name: r.Name, LinksCount: r.LinksCount, CreatedAt: r.CreatedAt}
+	}
+	return &dto.GetTagsResponse{Tags: tags}, nil
+}
+
+func (uc *TagUseCase) RenameTag(ctx context.Context, tagID, userID int64, req dto.TagRequest) (*dto.TagInfo, error) {
+	if err := uc.checkNameFree(ctx, userID, req.Name); err != nil {
+		return nil, err
+	}
+
+	tag, err := uc.repo.RenameTag(ctx, sqldb.RenameTagParams{Name: req.Name, ID: tagID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+
+	return &dto.TagInfo{ID: tag.ID, Name: tag.Name, CreatedAt: tag.CreatedAt}, nil
+}
+
+// DeleteTag removes the tag from all links and deletes it.
+func (uc *TagUseCase) DeleteTag(ctx context.Context, tagID, userID int64) error {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	if _, err := repoWithTx.GetTagByID(ctx, sqldb.GetTagByIDParams{ID: tagID, UserID: userID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTagNotFound
+		}
+		return fmt.Errorf("failed to verify tag ownership: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkTagsByTagID(ctx, tagID); err != nil {
+		return fmt.Errorf("failed to untag links: %w", err)
+	}
+
+	rowsAffected, err := repoWithTx.DeleteTag(ctx, sqldb.DeleteTagParams{ID: tagID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrTagNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetLinkTags replaces the tags of a link with req.TagIDs.
+func (uc *TagUseCase) SetLinkTags(ctx context.Context, linkID, userID int64, req dto.SetLinkTagsRequest) error {
+	tagIDs := slices.Clone(req.TagIDs)
+	slices.Sort(tagIDs)
+	tagIDs = slices.Compact(tagIDs)
+
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	if _, err := repoWithTx.GetLinkAndQRCodeByID(ctx, sqldb.GetLinkAndQRCodeByIDParams{ID: linkID, UserID: userID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrLinkNotFound
+		}
+		return fmt.Errorf("failed to verify link ownership: %w", err)
+	}
+
+	count, err := repoWithTx.CountTagsByIDs(ctx, sqldb.CountTagsByIDsParams{UserID: userID, Ids: tagIDs})
+	if err != nil {
+		return fmt.Errorf("failed to check tags: %w", err)
+	}
+	if count != int64(len(tagIDs)) {
+		return ErrTagNotFound
+	}
+
+	if err := repoWithTx.DeleteLinkTagsByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to clear link tags: %w", err)
+	}
+
+	if len(tagIDs) > 0 {
+		if err := repoWithTx.AddLinkTags(ctx, sqldb.AddLinkTagsParams{LinkID: linkID, TagIds: tagIDs}); err != nil {
+			return fmt.Errorf("failed to add link tags: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *TagUseCase) GetTagStats(ctx context.Context, tagID, userID int64, rng dto.StatsRange) (*dto.CampaignStatsResponse, error) {
+	if _, err := uc.repo.GetTagByID(ctx, sqldb.GetTagByIDParams{ID: tagID, UserID: userID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+		return nil, fmt.Errorf("failed to get tag by id: %w", err)
+	}
+
+	return campaignStats(ctx, uc.repo, userID, nil, &tagID, rng)
+}
+
+func (uc *TagUseCase) checkNameFree(ctx context.Context, userID int64, name string) error {
+	_, err := uc.repo.GetTagByName(ctx, sqldb.GetTagByNameParams{UserID: userID, Name: name})
+	if err == nil {
+		return ErrTagAlreadyExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to check tag name: %w", err)
+	}
+	return nil
+}
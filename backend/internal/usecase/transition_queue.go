@@ -0,0 +1,595 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+	"github.com/ua-parser/uap-go/uaparser"
+)
+
+const (
+	// transitionWriteTimeout bounds the visitor hashing of one scan and each
+	// attempt to write a batch.
+	transitionWriteTimeout = 10 * time.Second
+	// A batch whose write fails for a transient reason is written again up
+	// to transitionWriteAttempts times in all, waiting transitionRetryBase,
+	// then twice as long, in between.
+	transitionWriteAttempts = 3
+	transitionRetryBase     = 250 * time.Millisecond
+	// geoResolveTimeout bounds resolving the location of one scan, across
+	// all the resolver's sources.
+	geoResolveTimeout = 5 * time.Second
+)
+
+// GeoLocation is where a scan came from. Fields a resolver has no data for
+// are empty.
+type GeoLocation struct {
+	Country string
+	Region  string
+	City    string
+	// Source names the resolver that answered, such as header, mmdb or
+	// ipinfo.
+	Source string
+}
+
+// GeoQuery is what a scan's location is resolved from.
+type GeoQuery struct {
+	IP      string
+	Headers http.Header
+}
+
+type GeoResolver interface {
+	Resolve(ctx context.Context, q GeoQuery) (GeoLocation, bool)
+}
+
+// TransitionQueueStats are the queue's counters since startup.
+type TransitionQueueStats struct {
+	Queued   int
+	Accepted int64
+	Dropped  int64
+	Written  int64
+	Failed   int64
+	// Orphaned counts scans of links purged before the scans were written.
+	Orphaned int64
+}
+
+// TransitionQueue records scans off the redirect path. Scans wait in a
+// bounded queue. Enrich workers add the client, location and visitor hash,
+// many scans at a time so a slow geo resolver does not hold up the rest;
+// write workers only collect the enriched scans into batches and write them
+// with COPY together with their link.scanned webhook events, then publish
+// them to live feeds. When the queue is full a redirect waits briefly for
+// room and then drops its scan, so a burst slows redirects down a little
+// instead of exhausting the database pool. Views of preview pages take the
+// same way.
+type TransitionQueue struct {
+	repo     postgres.Repository
+	uaParser *uaparser.Parser
+	geo      GeoResolver
+	visitors *VisitorHasher
+	scans    ScanBroker
+
+	queue         chan pendingScan
+	enriched      chan enrichedScan
+	workers       int
+	enrichers     int
+	batchSize     int
+	flushInterval time.Duration
+	enqueueWait   time.Duration
+
+	mu       sync.RWMutex
+	closed   bool
+	enrichWG sync.WaitGroup
+	wg       sync.WaitGroup
+
+	accepted      atomic.Int64
+	dropped       atomic.Int64
+	written       atomic.Int64
+	failed        atomic.Int64
+	orphaned      atomic.Int64
+	reportedDrops atomic.Int64
+}
+
+type pendingScan struct {
+	link       sqldb.Link
+	scheduleID *int64
+	req        RedirectRequest
+	at         time.Time
+	// previewView marks a view of the preview page rather than a scan.
+	previewView bool
+}
+
+// enrichedScan is a scan ready to be written. A preview view carries only
+// the link ID.
+type enrichedScan struct {
+	params      sqldb.CreateTransitionsParams
+	event       dto.ScanEvent
+	previewView bool
+}
+
+// transitionBatch is what a worker writes at once.
+type transitionBatch struct {
+	params       []sqldb.CreateTransitionsParams
+	events       []dto.ScanEvent
+	previewViews []int64
+}
+
+func (b *transitionBatch) len() int {
+	return len(b.params) + len(b.previewViews)
+}
+
+func (b *transitionBatch) reset() {
+	b.params, b.events, b.previewViews = b.params[:0], b.events[:0], b.previewViews[:0]
+}
+
+func NewTransitionQueue(repo postgres.Repository, geo GeoResolver, visitors *VisitorHasher, scans ScanBroker, cfg *config.Config) *TransitionQueue {
+	return &TransitionQueue{
+		repo:          repo,
+		uaParser:      uaparser.NewFromSaved(),
+		geo:           geo,
+		visitors:      visitors,
+		scans:         scans,
+		queue:         make(chan pendingScan, max(cfg.TransitionQueueSize, 1)),
+		enriched:      make(chan enrichedScan, max(cfg.TransitionBatchSize, 1)),
+		workers:       max(cfg.TransitionWorkers, 1),
+		enrichers:     max(cfg.TransitionEnrichWorkers, 1),
+		batchSize:     max(cfg.TransitionBatchSize, 1),
+		flushInterval: max(cfg.TransitionFlushInterval, 10*time.Millisecond),
+		enqueueWait:   cfg.TransitionEnqueueWait,
+	}
+}
+
+// Start launches the workers.
+func (q *TransitionQueue) Start() {
+	for range q.enrichers {
+		q.enrichWG.Add(1)
+		go q.enrichScans()
+	}
+	go func() {
+		q.enrichWG.Wait()
+		close(q.enriched)
+	}()
+
+	for range q.workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Push queues a scan of link, which carries the destination the scan was
+// sent to. It never fails; a scan that finds no room is counted as dropped.
+func (q *TransitionQueue) Push(link sqldb.Link, scheduleID *int64, req RedirectRequest) {
+	q.push(pendingScan{link: link, scheduleID: scheduleID, req: detachRequest(req), at: time.Now()})
+}
+
+// PushPreviewView queues a view of link's preview page.
+func (q *TransitionQueue) PushPreviewView(link sqldb.Link) {
+	q.push(pendingScan{link: link, at: time.Now(), previewView: true})
+}
+
+func (q *TransitionQueue) push(scan pendingScan) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		q.dropped.Add(1)
+		return
+	}
+
+	select {
+	case q.queue <- scan:
+		q.accepted.Add(1)
+		return
+	default:
+	}
+
+	timer := time.NewTimer(q.enqueueWait)
+	defer timer.Stop()
+	select {
+	case q.queue <- scan:
+		q.accepted.Add(1)
+	case <-timer.C:
+		q.dropped.Add(1)
+	}
+}
+
+// Close stops accepting scans and waits until the workers have written
+// everything queued, or until ctx is done.
+func (q *TransitionQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.queue)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		stats := q.Stats()
+		log.Info().Int64("accepted", stats.Accepted).Int64("written", stats.Written).
+			Int64("dropped", stats.Dropped).Int64("failed", stats.Failed).Int64("orphaned", stats.Orphaned).
+			Msg("Transition queue flushed")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("transition queue not flushed, %d scans left: %w", len(q.queue)+len(q.enriched), ctx.Err())
+	}
+}
+
+func (q *TransitionQueue) Stats() TransitionQueueStats {
+	return TransitionQueueStats{
+		Queued:   len(q.queue) + len(q.enriched),
+		Accepted: q.accepted.Load(),
+		Dropped:  q.dropped.Load(),
+		Written:  q.written.Load(),
+		Failed:   q.failed.Load(),
+		Orphaned: q.orphaned.Load(),
+	}
+}
+
+// enrichScans enriches queued scans for the write workers until the queue
+// is closed and drained.
+func (q *TransitionQueue) enrichScans() {
+	defer q.enrichWG.Done()
+
+	for scan := range q.queue {
+		if scan.previewView {
+			q.enriched <- enrichedScan{params: sqldb.CreateTransitionsParams{LinkID: scan.link.ID}, previewView: true}
+			continue
+		}
+		params, event := q.enrich(scan)
+		q.enriched <- enrichedScan{params: params, event: event}
+	}
+}
+
+// work collects enriched scans into a batch that is written when it is full,
+// when the flush interval passes, and once more when the queue is closed.
+func (q *TransitionQueue) work() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+
+	batch := &transitionBatch{
+		params: make([]sqldb.CreateTransitionsParams, 0, q.batchSize),
+		events: make([]dto.ScanEvent, 0, q.batchSize),
+	}
+	flush := func() {
+		if batch.len() > 0 {
+			q.write(batch)
+			batch.reset()
+		}
+	}
+
+	for {
+		select {
+		case scan, ok := <-q.enriched:
+			if !ok {
+				flush()
+				return
+			}
+			if scan.previewView {
+				batch.previewViews = append(batch.previewViews, scan.params.LinkID)
+			} else {
+				batch.params, batch.events = append(batch.params, scan.params), append(batch.events, scan.event)
+			}
+			if batch.len() == q.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			q.reportDrops()
+		}
+	}
+}
+
+// write inserts a batch, retrying transient failures, and publishes its
+// scans once they are stored. When the database rejects the batch itself,
+// such as a row breaking a constraint, the scans are written one by one so
+// only the offending ones are lost. Scans that still fail are dropped and
+// counted as failed.
+func (q *TransitionQueue) write(batch *transitionBatch) {
+	err := q.insertWithRetry(batch)
+	if err == nil {
+		q.written.Add(int64(batch.len()))
+		for _, event := range batch.events {
+			q.scans.Publish(event)
+		}
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if batch.len() > 1 && errors.As(err, &pgErr) && !isRetryableWriteError(err) {
+		log.Warn().Err(err).Int("scans", len(batch.params)).Int("preview_views", len(batch.previewViews)).
+			Msg("Failed to write transitions, writing them one by one")
+		for i := range batch.params {
+			q.write(&transitionBatch{params: batch.params[i : i+1], events: batch.events[i : i+1]})
+		}
+		for i := range batch.previewViews {
+			q.write(&transitionBatch{previewViews: batch.previewViews[i : i+1]})
+		}
+		return
+	}
+
+	q.failed.Add(int64(batch.len()))
+	log.Error().Err(err).Int("scans", len(batch.params)).Int("preview_views", len(batch.previewViews)).
+		Msg("Failed to write transitions")
+}
+
+// insertWithRetry inserts a batch, trying again after transient failures.
+func (q *TransitionQueue) insertWithRetry(batch *transitionBatch) error {
+	delay := transitionRetryBase
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), transitionWriteTimeout)
+		err := q.insert(ctx, batch)
+		cancel()
+		if err == nil || attempt == transitionWriteAttempts || !isRetryableWriteError(err) {
+			return err
+		}
+		log.Warn().Err(err).Int("attempt", attempt).Dur("retry_in", delay).Msg("Failed to write transitions, retrying")
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// enrich adds the client, location and visitor hash to a scan.
+func (q *TransitionQueue) enrich(scan pendingScan) (sqldb.CreateTransitionsParams, dto.ScanEvent) {
+	referer, userAgent, ip := scan.req.Referer, scan.req.UserAgent, scan.req.IP
+
+	var refPtr, uaPtr *string
+	if referer != "" {
+		refPtr = &referer
+	}
+	if userAgent != "" {
+		uaPtr = &userAgent
+	}
+
+	client := q.uaParser.Parse(userAgent)
+	var brPtr, osPtr *string
+	if client.UserAgent.Family != "" {
+		brPtr = &client.UserAgent.Family
+	}
+	if client.Os.Family != "" {
+		osPtr = &client.Os.Family
+	}
+
+	var countryPtr, regionPtr, cityPtr, geoSourcePtr *string
+	if q.geo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), geoResolveTimeout)
+		loc, ok := q.geo.Resolve(ctx, GeoQuery{IP: ip, Headers: scan.req.Headers})
+		cancel()
+		if ok {
+			geoSourcePtr = &loc.Source
+			if loc.Country != "" {
+				countryPtr = &loc.Country
+			}
+			if loc.Region != "" {
+				regionPtr = &loc.Region
+			}
+			if loc.City != "" {
+				cityPtr = &loc.City
+			}
+		}
+	}
+
+	var visitorPtr *string
+	if ip != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), transitionWriteTimeout)
+		visitor, err := q.visitors.Hash(ctx, ip, userAgent)
+		cancel()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to hash visitor")
+		} else {
+			visitorPtr = &visitor
+		}
+	}
+
+	link := scan.link
+	params := sqldb.CreateTransitionsParams{
+		LinkID:      link.ID,
+		Country:     countryPtr,
+		Region:      regionPtr,
+		City:        cityPtr,
+		Referer:     refPtr,
+		UserAgent:   uaPtr,
+		Browser:     brPtr,
+		Os:          osPtr,
+		RevisionID:  link.RevisionID,
+		ViaPreview:  scan.req.ViaPreview,
+		ScheduleID:  scan.scheduleID,
+		VisitorHash: visitorPtr,
+		GeoSource:   geoSourcePtr,
+		CreatedAt:   scan.at,
+	}
+
+	event := dto.ScanEvent{
+		UserID:      link.UserID,
+		LinkID:      link.ID,
+		Hash:        link.Hash,
+		Name:        link.Name,
+		Destination: link.OriginalUrl,
+		Country:     countryPtr,
+		Region:      regionPtr,
+		City:        cityPtr,
+		GeoSource:   geoSourcePtr,
+		Referer:     refPtr,
+		Browser:     brPtr,
+		OS:          osPtr,
+		ViaPreview:  scan.req.ViaPreview,
+		ScheduleID:  scan.scheduleID,
+		CreatedAt:   scan.at,
+	}
+	return params, event
+}
+
+// insert writes a batch of transitions together with their link.scanned
+// webhook events, and the preview views of the batch. Scans of links purged
+// since they were queued are left out of the batch.
+func (q *TransitionQueue) insert(ctx context.Context, batch *transitionBatch) error {
+	tx, err := q.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := q.repo.WithTX(tx)
+
+	if err := q.dropPurged(ctx, repoWithTx, batch); err != nil {
+		return err
+	}
+
+	events := batch.events
+	webhookParams := sqldb.EnqueueWebhookEventsParams{
+		EventType: WebhookEventLinkScanned,
+		UserIds:   make([]int64, len(events)),
+		EventIds:  make([]string, len(events)),
+		Payloads:  make([]string, len(events)),
+	}
+	for i, event := range events {
+		webhookEvent, err := newWebhookEvent(WebhookEventLinkScanned, event)
+		if err != nil {
+			return err
+		}
+		payload, err := json.Marshal(webhookEvent)
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook event: %w", err)
+		}
+		webhookParams.UserIds[i] = event.UserID
+		webhookParams.EventIds[i] = webhookEvent.ID
+		webhookParams.Payloads[i] = string(payload)
+	}
+
+	if len(batch.params) > 0 {
+		if _, err := repoWithTx.CreateTransitions(ctx, batch.params); err != nil {
+			return fmt.Errorf("failed to create transitions: %w", err)
+		}
+		if err := repoWithTx.EnqueueWebhookEvents(ctx, webhookParams); err != nil {
+			return fmt.Errorf("failed to enqueue webhook events: %w", err)
+		}
+	}
+	if len(batch.previewViews) > 0 {
+		if err := repoWithTx.CreateLinkPreviewViews(ctx, batch.previewViews); err != nil {
+			return fmt.Errorf("failed to create preview views: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// dropPurged removes the scans and preview views of links that no longer
+// exist from the batch, counting them as orphaned. The remaining links
+// stay locked against purging until the batch is written. Locking them
+// also gives the transaction its id before the transitions take theirs,
+// which the rollup aggregator relies on.
+func (q *TransitionQueue) dropPurged(ctx context.Context, repo postgres.Repository, batch *transitionBatch) error {
+	ids := make([]int64, 0, batch.len())
+	for _, p := range batch.params {
+		ids = append(ids, p.LinkID)
+	}
+	ids = append(ids, batch.previewViews...)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	existing, err := repo.LockExistingLinks(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to lock links: %w", err)
+	}
+	exists := make(map[int64]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	params, events := batch.params[:0], batch.events[:0]
+	for i, p := range batch.params {
+		if exists[p.LinkID] {
+			params, events = append(params, p), append(events, batch.events[i])
+		}
+	}
+	previewViews := batch.previewViews[:0]
+	for _, id := range batch.previewViews {
+		if exists[id] {
+			previewViews = append(previewViews, id)
+		}
+	}
+
+	if orphaned := batch.len() - len(params) - len(previewViews); orphaned > 0 {
+		q.orphaned.Add(int64(orphaned))
+		log.Info().Int("scans", orphaned).Msg("Skipped scans of purged links")
+	}
+	batch.params, batch.events, batch.previewViews = params, events, previewViews
+	return nil
+}
+
+// isRetryableWriteError reports whether writing a batch again may succeed:
+// the database could not be reached, the connection failed before the
+// statement was sent, or the server gave up on it for a transient reason
+// such as a deadlock, a shutdown or running out of connections.
+func isRetryableWriteError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || pgconn.SafeToRetry(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		for _, class := range []string{"08", "40", "53", "57P"} {
+			if strings.HasPrefix(pgErr.Code, class) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// detachRequest copies the strings of a request, which may point into
+// buffers the server reuses once the handler has returned.
+func detachRequest(req RedirectRequest) RedirectRequest {
+	req.Hash = strings.Clone(req.Hash)
+	req.Host = strings.Clone(req.Host)
+	req.Referer = strings.Clone(req.Referer)
+	req.UserAgent = strings.Clone(req.UserAgent)
+	req.IP = strings.Clone(req.IP)
+	if req.Headers != nil {
+		headers := make(http.Header, len(req.Headers))
+		for key, values := range req.Headers {
+			copied := make([]string, len(values))
+			for i, v := range values {
+				copied[i] = strings.Clone(v)
+			}
+			headers[strings.Clone(key)] = copied
+		}
+		req.Headers = headers
+	}
+	return req
+}
+
+// reportDrops logs the scans dropped since the last report.
+func (q *TransitionQueue) reportDrops() {
+	dropped := q.dropped.Load()
+	if last := q.reportedDrops.Swap(dropped); dropped > last {
+		log.Warn().Int64("dropped", dropped-last).Int64("total_dropped", dropped).
+			Msg("Transition queue full, scans dropped")
+	}
+}
@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// transitionRepo fails BeginTx with the scripted errors, one per call, and
+// then stores batches. A batch with a scan of badLink breaks the foreign
+// key; purgedLink no longer exists.
+type transitionRepo struct {
+	postgres.Repository
+	errs        []error
+	badLink     int64
+	purgedLink  int64
+	begins      int
+	transitions int
+}
+
+func (r *transitionRepo) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	r.begins++
+	if r.begins <= len(r.errs) {
+		return nil, r.errs[r.begins-1]
+	}
+	return commitTx{}, nil
+}
+
+func (r *transitionRepo) WithTX(tx pgx.Tx) postgres.Repository { return r }
+
+func (r *transitionRepo) LockExistingLinks(ctx context.Context, ids []int64) ([]int64, error) {
+	var existing []int64
+	for _, id := range ids {
+		if id != r.purgedLink {
+			existing = append(existing, id)
+		}
+	}
+	return existing, nil
+}
+
+func (r *transitionRepo) CreateTransitions(ctx context.Context, arg []sqldb.CreateTransitionsParams) (int64, error) {
+	for _, p := range arg {
+		if r.badLink != 0 && p.LinkID == r.badLink {
+			return 0, &pgconn.PgError{Code: "23503"}
+		}
+	}
+	r.transitions += len(arg)
+	return int64(len(arg)), nil
+}
+
+func (r *transitionRepo) EnqueueWebhookEvents(ctx context.Context, arg sqldb.EnqueueWebhookEventsParams) error {
+	return nil
+}
+
+// commitTx commits and rolls back nothing; the other methods are unused.
+type commitTx struct{ pgx.Tx }
+
+func (commitTx) Commit(ctx context.Context) error   { return nil }
+func (commitTx) Rollback(ctx context.Context) error { return nil }
+
+type countingBroker struct {
+	ScanBroker
+	published int
+}
+
+func (b *countingBroker) Publish(event dto.ScanEvent) { b.published++ }
+
+func newTestBatch() *transitionBatch {
+	return &transitionBatch{
+		params: []sqldb.CreateTransitionsParams{{LinkID: 1}, {LinkID: 2}},
+		events: []dto.ScanEvent{{LinkID: 1, UserID: 7}, {LinkID: 2, UserID: 7}},
+	}
+}
+
+func TestTransitionQueueWriteRetries(t *testing.T) {
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	tooMany := &pgconn.PgError{Code: "53300"}
+	unique := &pgconn.PgError{Code: "23505"}
+
+	tests := []struct {
+		name    string
+		errs    []error
+		begins  int
+		written bool
+	}{
+		{"first attempt", nil, 1, true},
+		{"transient failures", []error{deadlock, tooMany}, transitionWriteAttempts, true},
+		{"out of attempts", []error{deadlock, deadlock, deadlock}, transitionWriteAttempts, false},
+		// A rejected batch is written again one scan at a time.
+		{"rejected batch", []error{unique}, 3, true},
+		{"rejected rows", []error{unique, unique, unique}, 3, false},
+		{"unknown failure", []error{errors.New("boom")}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &transitionRepo{errs: tt.errs}
+			broker := &countingBroker{}
+			q := NewTransitionQueue(repo, nil, nil, broker, &config.Config{})
+
+			q.write(newTestBatch())
+
+			if repo.begins != tt.begins {
+				t.Errorf("attempts = %d, want %d", repo.begins, tt.begins)
+			}
+			stats := q.Stats()
+			if tt.written {
+				if repo.transitions != 2 || stats.Written != 2 || stats.Failed != 0 || broker.published != 2 {
+					t.Errorf("stored %d, written %d, failed %d, published %d; want the batch stored and published",
+						repo.transitions, stats.Written, stats.Failed, broker.published)
+				}
+			} else {
+				if repo.transitions != 0 || stats.Written != 0 || stats.Failed != 2 || broker.published != 0 {
+					t.Errorf("stored %d, written %d, failed %d, published %d; want the batch counted as failed",
+						repo.transitions, stats.Written, stats.Failed, broker.published)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitionQueueWriteRowByRow(t *testing.T) {
+	repo := &transitionRepo{badLink: 2}
+	broker := &countingBroker{}
+	q := NewTransitionQueue(repo, nil, nil, broker, &config.Config{})
+
+	batch := newTestBatch()
+	batch.params = append(batch.params, sqldb.CreateTransitionsParams{LinkID: 3})
+	batch.events = append(batch.events, dto.ScanEvent{LinkID: 3, UserID: 8})
+	q.write(batch)
+
+	stats := q.Stats()
+	if repo.transitions != 2 || stats.Written != 2 || stats.Failed != 1 || broker.published != 2 {
+		t.Errorf("stored %d, written %d, failed %d, published %d; want only the scan of the bad link lost",
+			repo.transitions, stats.Written, stats.Failed, broker.published)
+	}
+}
+
+func TestTransitionQueueSkipsPurgedLinks(t *testing.T) {
+	repo := &transitionRepo{purgedLink: 2}
+	broker := &countingBroker{}
+	q := NewTransitionQueue(repo, nil, nil, broker, &config.Config{})
+
+	q.write(newTestBatch())
+
+	stats := q.Stats()
+	if repo.begins != 1 || repo.transitions != 1 || stats.Written != 1 || stats.Orphaned != 1 || stats.Failed != 0 || broker.published != 1 {
+		t.Errorf("attempts %d, stored %d, written %d, orphaned %d, failed %d, published %d; want the scan of the purged link skipped",
+			repo.begins, repo.transitions, stats.Written, stats.Orphaned, stats.Failed, broker.published)
+	}
+}
+
+func TestIsRetryableWriteError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "40001"}, true},
+		{&pgconn.PgError{Code: "40P01"}, true},
+		{&pgconn.PgError{Code: "08006"}, true},
+		{&pgconn.PgError{Code: "53300"}, true},
+		{&pgconn.PgError{Code: "57P01"}, true},
+		{fmt.Errorf("failed to create transitions: %w", &pgconn.PgError{Code: "40001"}), true},
+		{&pgconn.ConnectError{}, true},
+		{&pgconn.PgError{Code: "23503"}, false},
+		{&pgconn.PgError{Code: "22001"}, false},
+		{&pgconn.PgError{Code: "57014"}, false},
+		{context.DeadlineExceeded, false},
+		{io.ErrUnexpectedEOF, false},
+	}
+
+	for _, tt := range tests {
+		if got := isRetryableWriteError(tt.err); got != tt.want {
+			t.Errorf("isRetryableWriteError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// deadlineGeo records whether it was asked with a deadline.
+type deadlineGeo struct {
+	deadline time.Time
+	ok       bool
+}
+
+func (g *deadlineGeo) Resolve(ctx context.Context, q GeoQuery) (GeoLocation, bool) {
+	g.deadline, g.ok = ctx.Deadline()
+	return GeoLocation{Country: "DE", Source: "test"}, true
+}
+
+func TestTransitionQueueEnrichGeoDeadline(t *testing.T) {
+	geo := &deadlineGeo{}
+	q := NewTransitionQueue(&transitionRepo{}, geo, nil, nil, &config.Config{})
+
+	start := time.Now()
+	params, _ := q.enrich(pendingScan{link: sqldb.Link{ID: 1}, at: start})
+
+	if !geo.ok {
+		t.Fatal("geo resolver called without a deadline")
+	}
+	if d := geo.deadline.Sub(start); d <= 0 || d > geoResolveTimeout+time.Second {
+		t.Errorf("deadline %v after the scan, want about %v", d, geoResolveTimeout)
+	}
+	if params.Country == nil || *params.Country != "DE" {
+		t.Errorf("country = %v, want DE", params.Country)
+	}
+	if !params.CreatedAt.Equal(start) {
+		t.Errorf("created at %v, want the scan time %v", params.CreatedAt, start)
+	}
+}
+
+// barrierGeo answers once n lookups are waiting at the same time, or fails
+// them when their deadline passes first.
+type barrierGeo struct {
+	n       int
+	mu      sync.Mutex
+	waiting int
+	release chan struct{}
+}
+
+func (g *barrierGeo) Resolve(ctx context.Context, q GeoQuery) (GeoLocation, bool) {
+	g.mu.Lock()
+	g.waiting++
+	if g.waiting == g.n {
+		close(g.release)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-g.release:
+		return GeoLocation{Country: "DE", Source: "test"}, true
+	case <-ctx.Done():
+		return GeoLocation{}, false
+	}
+}
+
+func TestTransitionQueueEnrichesConcurrently(t *testing.T) {
+	const scans = 8
+	geo := &barrierGeo{n: scans, release: make(chan struct{})}
+	repo := &transitionRepo{}
+	cfg := &config.Config{
+		TransitionQueueSize:     scans,
+		TransitionWorkers:       1,
+		TransitionEnrichWorkers: scans,
+		TransitionBatchSize:     scans,
+		TransitionFlushInterval: time.Hour,
+	}
+	q := NewTransitionQueue(repo, geo, nil, &countingBroker{}, cfg)
+	q.Start()
+
+	for i := range scans {
+		q.Push(sqldb.Link{ID: int64(i + 1)}, nil, RedirectRequest{})
+	}
+	select {
+	case <-geo.release:
+	case <-time.After(geoResolveTimeout / 2):
+		t.Fatalf("%d of %d geo lookups ran at once, want all of them", geo.waiting, scans)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if stats := q.Stats(); stats.Written != scans || repo.transitions != scans {
+		t.Errorf("written %d, stored %d; want %d", stats.Written, repo.transitions, scans)
+	}
+}
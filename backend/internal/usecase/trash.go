@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/dto"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	trashPurgePollInterval = time.Hour
+	trashPurgeBatchSize    = 100
+)
+
+var ErrLinkGone = errors.New("link has been deleted")
+
+func (uc *LinkUseCase) GetTrash(ctx context.Context, userID int64) (*dto.GetTrashResponse, error) {
+	rows, err := uc.repo.GetTrashedLinks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed links: %w", err)
+	}
+
+	links := make([]dto.TrashedLinkInfo, 0, len(rows))
+	for _, row := range rows {
+		item := dto.TrashedLinkInfo{
+			ID:          row.ID,
+			OriginalURL: row.OriginalUrl,
+			Name:        row.Name,
+			Hash:        row.Hash,
+			CreatedAt:   row.CreatedAt,
+		}
+		if row.DeletedAt != nil {
+			item.DeletedAt = *row.DeletedAt
+			if uc.retention > 0 {
+				purgeAt := row.DeletedAt.Add(uc.retention)
+				item.PurgeAt = &purgeAt
+			}
+		}
+		links = append(links, item)
+	}
+
+	return &dto.GetTrashResponse{Links: links, Message: "Success get trashed links"}, nil
+}
+
+// RestoreLink takes a link out of the trash; it redirects again right away.
+func (uc *LinkUseCase) RestoreLink(ctx context.Context, linkID int64, userID int64) (*dto.RestoreLinkResponse, error) {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	hash, err := repoWithTx.RestoreLink(ctx, sqldb.RestoreLinkParams{ID: linkID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to restore link: %w", err)
+	}
+
+	if err := repoWithTx.NotifyLinkChanged(ctx, hash); err != nil {
+		return nil, fmt.Errorf("failed to notify link change: %w", err)
+	}
+
+	data := dto.WebhookLinkData{ID: linkID, Hash: hash}
+	if err := enqueueWebhookEvent(ctx, repoWithTx, userID, WebhookEventLinkRestored, data); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	uc.cache.Invalidate(hash)
+
+	return &dto.RestoreLinkResponse{Message: "Link restored successfully", ID: linkID}, nil
+}
+
+// PurgeLink permanently deletes a trashed link without waiting for the
+// retention period.
+func (uc *LinkUseCase) PurgeLink(ctx context.Context, linkID int64, userID int64) error {
+	link, err := uc.repo.GetTrashedLink(ctx, sqldb.GetTrashedLinkParams{ID: linkID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrLinkNotFound
+		}
+		return fmt.Errorf("failed to get trashed link: %w", err)
+	}
+
+	return uc.purgeLink(ctx, link.ID, link.UserID, link.Hash, link.FileID)
+}
+
+// PurgeExpiredLinks permanently deletes links that have been in the trash
+// longer than the retention period and returns how many were removed.
+func (uc *LinkUseCase) PurgeExpiredLinks(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-uc.retention)
+	purged := 0
+	for {
+		links, err := uc.repo.GetExpiredTrashedLinks(ctx, sqldb.GetExpiredTrashedLinksParams{
+			DeletedAt: &cutoff,
+			Limit:     trashPurgeBatchSize,
+		})
+		if err != nil {
+			return purged, fmt.Errorf("failed to get expired trashed links: %w", err)
+		}
+
+		for _, link := range links {
+			if err := uc.purgeLink(ctx, link.ID, link.UserID, link.Hash, link.FileID); err != nil {
+				// A link restored since the listing is simply skipped.
+				if errors.Is(err, ErrLinkNotFound) {
+					continue
+				}
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(links) < trashPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// purgeLink deletes a trashed link together with its QR code, analytics,
+// revisions, tags and hosted file. It fails with ErrLinkNotFound if the link
+// was restored in the meantime.
+func (uc *LinkUseCase) purgeLink(ctx context.Context, linkID, userID int64, hash string, fileID *int64) error {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	if err := repoWithTx.DeleteTransitionsByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete transitions: %w", err)
+	}
+
+	if err := repoWithTx.DeleteHourlyRollupsByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete hourly rollups: %w", err)
+	}
+
+	if err := repoWithTx.DeleteDailyRollupsByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete daily rollups: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLatestRollupByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete latest rollup: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkSchedulesByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete link schedules: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkHealthByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete link health: %w", err)
+	}
+
+	if err := repoWithTx.DeleteQRCodeByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete qr code: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkRevisionsByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete link revisions: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkTagsByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete link tags: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkPreviewByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete link preview: %w", err)
+	}
+
+	if err := repoWithTx.DeleteLinkPreviewViewsByLinkID(ctx, linkID); err != nil {
+		return fmt.Errorf("failed to delete link preview views: %w", err)
+	}
+
+	fileKey, err := detachLinkFile(ctx, repoWithTx, linkID, fileID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := repoWithTx.DeleteLink(ctx, sqldb.DeleteLinkParams{ID: linkID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+
+	if err := repoWithTx.NotifyLinkChanged(ctx, hash); err != nil {
+		return fmt.Errorf("failed to notify link change: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	uc.cache.Invalidate(hash)
+
+	if fileKey != "" {
+		uc.removeStoredFile(fileKey)
+	}
+
+	return nil
+}
+
+// TrashPurger periodically purges links whose trash retention has expired.
+type TrashPurger struct {
+	links     *LinkUseCase
+	retention time.Duration
+}
+
+func NewTrashPurger(links *LinkUseCase, cfg *config.Config) *TrashPurger {
+	return &TrashPurger{
+		links:     links,
+		retention: cfg.TrashRetention,
+	}
+}
+
+// Run blocks until ctx is done. A non-positive retention keeps trashed links
+// until they are purged by hand.
+func (p *TrashPurger) Run(ctx context.Context) {
+	if p.retention <= 0 {
+		log.Info().Msg("Trash purge disabled")
+		return
+	}
+
+	ticker := time.NewTicker(trashPurgePollInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.links.PurgeExpiredLinks(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to purge trashed links")
+		}
+		if purged > 0 {
+			log.Info().Int("count", purged).Msg("Purged trashed links")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
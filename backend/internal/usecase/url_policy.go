@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/pkg/urlnorm"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrURLRejected           = errors.New("destination url rejected")
+	ErrURLRedirectLoop       = fmt.Errorf("%w: it points back to this service", ErrURLRejected)
+	ErrURLBlocked            = fmt.Errorf("%w: domain is blocked", ErrURLRejected)
+	ErrBlockedDomainNotFound = errors.New("blocked domain not found")
+)
+
+// URLPolicy decides which destinations links may point to.
+type URLPolicy struct {
+	repo    postgres.Repository
+	appHost string
+}
+
+func NewURLPolicy(repo postgres.Repository, cfg *config.Config) *URLPolicy {
+	return &URLPolicy{repo: repo, appHost: appHost(cfg)}
+}
+
+// Check normalizes rawURL and returns the form that should be stored. It
+// rejects non-http(s) schemes, destinations on our own or verified customer
+// domains (which would loop through /redirect) and blocklisted domains.
+// Unverified domains do not count, so claiming a host cannot block links to
+// it.
+func (p *URLPolicy) Check(ctx context.Context, rawURL string) (string, error) {
+	u, err := urlnorm.Normalize(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrURLRejected, err)
+	}
+	host := u.Hostname()
+
+	if host == p.appHost {
+		return "", ErrURLRedirectLoop
+	}
+	_, err = p.repo.GetVerifiedDomainByHost(ctx, host)
+	if err == nil {
+		return "", ErrURLRedirectLoop
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to check custom domains: %w", err)
+	}
+
+	blocked, err := p.repo.IsAnyDomainBlocked(ctx, urlnorm.ParentDomains(host))
+	if err != nil {
+		return "", fmt.Errorf("failed to check blocklist: %w", err)
+	}
+	if blocked {
+		return "", ErrURLBlocked
+	}
+
+	return u.String(), nil
+}
+
+func (p *URLPolicy) GetBlockedDomains(ctx context.Context) (*dto.GetBlockedDomainsResponse, error) {
+	rows, err := p.repo.GetBlockedDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked domains: %w", err)
+	}
+
+	items := make([]dto.BlockedDomainInfo, len(rows))
+	for i, r := range rows {
+		items[i] = toBlockedDomainInfo(r)
+	}
+	return &dto.GetBlockedDomainsResponse{Domains: items}, nil
+}
+
+func (p *URLPolicy) BlockDomain(ctx context.Context, req dto.BlockDomainRequest) (*dto.BlockedDomainInfo, error) {
+	host, err := urlnorm.NormalizeHost(req.Host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrURLRejected, err)
+	}
+
+	row, err := p.repo.CreateBlockedDomain(ctx, sqldb.CreateBlockedDomainParams{
+		Host:   host,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to block domain: %w", err)
+	}
+
+	info := toBlockedDomainInfo(row)
+	return &info, nil
+}
+
+func (p *URLPolicy) UnblockDomain(ctx context.Context, id int64) error {
+	rowsAffected, err := p.repo.DeleteBlockedDomain(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to unblock domain: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrBlockedDomainNotFound
+	}
+	return nil
+}
+
+func toBlockedDomainInfo(d sqldb.BlockedDomain) dto.BlockedDomainInfo {
+	return dto.BlockedDomainInfo{
+		ID:        d.ID,
+		Host:      d.Host,
+		Reason:    d.Reason,
+		CreatedAt: d.CreatedAt,
+	}
+}
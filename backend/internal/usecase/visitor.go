@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+)
+
+const visitorSaltSize = 32
+
+// VisitorHasher turns an IP and User-Agent into a visitor hash that stays
+// the same for one UTC day. The day's salt lives in the database so every
+// instance produces the same hashes, and older salts are deleted when a
+// new day starts, so a visitor can't be followed from one day to the next.
+type VisitorHasher struct {
+	repo postgres.Repository
+
+	mu   sync.Mutex
+	day  time.Time
+	salt []byte
+}
+
+func NewVisitorHasher(repo postgres.Repository) *VisitorHasher {
+	return &VisitorHasher{repo: repo}
+}
+
+func (h *VisitorHasher) Hash(ctx context.Context, ip, userAgent string) (string, error) {
+	salt, err := h.saltFor(ctx, time.Now().UTC().Truncate(24*time.Hour))
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+func (h *VisitorHasher) saltFor(ctx context.Context, day time.Time) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.day.Equal(day) {
+		return h.salt, nil
+	}
+
+	// Whichever instance inserts first decides the salt of the day.
+	salt := make([]byte, visitorSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate visitor salt: %w", err)
+	}
+	if err := h.repo.CreateVisitorSalt(ctx, sqldb.CreateVisitorSaltParams{Day: day, Salt: salt}); err != nil {
+		return nil, fmt.Errorf("failed to create visitor salt: %w", err)
+	}
+	salt, err := h.repo.GetVisitorSalt(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get visitor salt: %w", err)
+	}
+	if err := h.repo.DeleteVisitorSaltsBefore(ctx, day); err != nil {
+		return nil, fmt.Errorf("failed to delete old visitor salts: %w", err)
+	}
+
+	h.day, h.salt = day, salt
+	return salt, nil
+}
@@ -0,0 +1,333 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"qrcodegen/internal/dto"
+	"qrcodegen/internal/pkg/urlnorm"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	WebhookEventLinkScanned = "link.scanned"
+	WebhookEventLinkCreated = "link.created"
+	WebhookEventLinkUpdated = "link.updated"
+	WebhookEventLinkDeleted = "link.deleted"
+	// WebhookEventLinkRestored is sent when a deleted link leaves the trash.
+	WebhookEventLinkRestored = "link.restored"
+	// WebhookEventTest is only sent on request and cannot be subscribed to.
+	WebhookEventTest = "webhook.test"
+
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+
+	webhookSecretBytes     = 24
+	webhookEventIDBytes    = 16
+	webhookDeliveriesLimit = 100
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found or access denied")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found or already pending")
+	ErrInvalidWebhookURL       = errors.New("invalid webhook url")
+)
+
+type WebhookUseCase struct {
+	repo postgres.Repository
+}
+
+func NewWebhookUseCase(repo postgres.Repository) *WebhookUseCase {
+	return &WebhookUseCase{repo: repo}
+}
+
+// CreateWebhook subscribes url to events. The response is the only place
+// the signing secret is shown.
+func (uc *WebhookUseCase) CreateWebhook(ctx context.Context, req dto.CreateWebhookRequest, userID int64) (*dto.WebhookInfo, error) {
+	url, err := normalizeWebhookURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomHex(webhookSecretBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook, err := uc.repo.CreateWebhook(ctx, sqldb.CreateWebhookParams{
+		UserID: userID,
+		Url:    url,
+		Secret: "whsec_" + secret,
+		Events: req.Events,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	info := toWebhookInfo(webhook)
+	info.Secret = webhook.Secret
+	return &info, nil
+}
+
+func (uc *WebhookUseCase) GetWebhooks(ctx context.Context, userID int64) (*dto.GetWebhooksResponse, error) {
+	rows, err := uc.repo.GetWebhooksByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks by user id: %w", err)
+	}
+
+	webhooks := make([]dto.WebhookInfo, len(rows))
+	for i, r := range rows {
+		webhooks[i] = toWebhookInfo(r)
+	}
+	return &dto.GetWebhooksResponse{Webhooks: webhooks}, nil
+}
+
+func (uc *WebhookUseCase) UpdateWebhook(ctx context.Context, webhookID, userID int64, req dto.UpdateWebhookRequest) (*dto.WebhookInfo, error) {
+	params := sqldb.UpdateWebhookParams{
+		Events: req.Events,
+		Active: req.Active,
+		ID:     webhookID,
+		UserID: userID,
+	}
+	if req.URL != nil {
+		url, err := normalizeWebhookURL(*req.URL)
+		if err != nil {
+			return nil, err
+		}
+		params.Url = &url
+	}
+
+	webhook, err := uc.repo.UpdateWebhook(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	info := toWebhookInfo(webhook)
+	return &info, nil
+}
+
+// DeleteWebhook removes the webhook along with its delivery log; pending
+// deliveries are dropped.
+func (uc *WebhookUseCase) DeleteWebhook(ctx context.Context, webhookID, userID int64) error {
+	tx, err := uc.repo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repoWithTx := uc.repo.WithTX(tx)
+
+	if _, err := repoWithTx.GetWebhookByID(ctx, sqldb.GetWebhookByIDParams{ID: webhookID, UserID: userID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to get webhook by id: %w", err)
+	}
+
+	if err := repoWithTx.DeleteWebhookDeliveriesByWebhookID(ctx, webhookID); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	if _, err := repoWithTx.DeleteWebhook(ctx, sqldb.DeleteWebhookParams{ID: webhookID, UserID: userID}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// TestWebhook queues a webhook.test event for the webhook, whether or not it
+// is active. The dispatcher sends it like any other delivery, so its
+// outcome shows up in the delivery log.
+func (uc *WebhookUseCase) TestWebhook(ctx context.Context, webhookID, userID int64) (*dto.WebhookDeliveryInfo, error) {
+	if _, err := uc.ownedWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	event, err := newWebhookEvent(WebhookEventTest, dto.WebhookTestData{WebhookID: webhookID})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	delivery, err := uc.repo.CreateWebhookDelivery(ctx, sqldb.CreateWebhookDeliveryParams{
+		WebhookID:     webhookID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		NextAttemptAt: event.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	info := toWebhookDeliveryInfo(delivery)
+	return &info, nil
+}
+
+// GetWebhookDeliveries lists the webhook's deliveries newest first,
+// optionally only those with status.
+func (uc *WebhookUseCase) GetWebhookDeliveries(ctx context.Context, webhookID, userID int64, status *string, beforeID *int64) (*dto.GetWebhookDeliveriesResponse, error) {
+	if _, err := uc.ownedWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.repo.GetWebhookDeliveries(ctx, sqldb.GetWebhookDeliveriesParams{
+		WebhookID: webhookID,
+		Status:    status,
+		BeforeID:  beforeID,
+		MaxRows:   webhookDeliveriesLimit + 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	resp := &dto.GetWebhookDeliveriesResponse{}
+	if len(rows) > webhookDeliveriesLimit {
+		rows = rows[:webhookDeliveriesLimit]
+		resp.NextBeforeID = &rows[len(rows)-1].ID
+	}
+	resp.Deliveries = make([]dto.WebhookDeliveryInfo, len(rows))
+	for i, r := range rows {
+		resp.Deliveries[i] = toWebhookDeliveryInfo(r)
+	}
+	return resp, nil
+}
+
+// RetryWebhookDelivery queues a dead or delivered delivery again with a
+// fresh set of attempts.
+func (uc *WebhookUseCase) RetryWebhookDelivery(ctx context.Context, webhookID, deliveryID, userID int64) (*dto.WebhookDeliveryInfo, error) {
+	if _, err := uc.ownedWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := uc.repo.RetryWebhookDelivery(ctx, sqldb.RetryWebhookDeliveryParams{ID: deliveryID, WebhookID: webhookID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+
+	info := toWebhookDeliveryInfo(delivery)
+	return &info, nil
+}
+
+func (uc *WebhookUseCase) ownedWebhook(ctx context.Context, webhookID, userID int64) (sqldb.Webhook, error) {
+	webhook, err := uc.repo.GetWebhookByID(ctx, sqldb.GetWebhookByIDParams{ID: webhookID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sqldb.Webhook{}, ErrWebhookNotFound
+		}
+		return sqldb.Webhook{}, fmt.Errorf("failed to get webhook by id: %w", err)
+	}
+	return webhook, nil
+}
+
+// enqueueWebhookEvent adds an event to the outbox of the user's webhooks
+// that subscribe to it. It must run in the transaction that makes the
+// change, so the event is delivered if and only if the change commits.
+func enqueueWebhookEvent(ctx context.Context, repoWithTx postgres.Repository, userID int64, eventType string, data any) error {
+	event, err := newWebhookEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	if err := repoWithTx.EnqueueWebhookEvent(ctx, sqldb.EnqueueWebhookEventParams{
+		EventID:   event.ID,
+		EventType: eventType,
+		Payload:   payload,
+		UserID:    userID,
+	}); err != nil {
+		return fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+	return nil
+}
+
+func newWebhookEvent(eventType string, data any) (dto.WebhookEvent, error) {
+	id, err := randomHex(webhookEventIDBytes)
+	if err != nil {
+		return dto.WebhookEvent{}, fmt.Errorf("failed to generate webhook event id: %w", err)
+	}
+	return dto.WebhookEvent{
+		ID:        "evt_" + id,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}, nil
+}
+
+func webhookLinkData(link sqldb.Link) dto.WebhookLinkData {
+	return dto.WebhookLinkData{
+		ID:          link.ID,
+		Hash:        link.Hash,
+		Name:        link.Name,
+		OriginalURL: link.OriginalUrl,
+		DomainID:    link.DomainID,
+	}
+}
+
+func normalizeWebhookURL(rawURL string) (string, error) {
+	u, err := urlnorm.Normalize(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidWebhookURL, err)
+	}
+	return u.String(), nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toWebhookInfo(w sqldb.Webhook) dto.WebhookInfo {
+	return dto.WebhookInfo{
+		ID:        w.ID,
+		URL:       w.Url,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryInfo(d sqldb.WebhookDelivery) dto.WebhookDeliveryInfo {
+	info := dto.WebhookDeliveryInfo{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == WebhookDeliveryPending {
+		info.NextAttemptAt = &d.NextAttemptAt
+	}
+	return info
+}
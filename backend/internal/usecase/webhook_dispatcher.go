@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	webhookRetryBase       = 30 * time.Second
+	webhookRetryMax        = 6 * time.Hour
+	webhookCleanupInterval = time.Hour
+	maxWebhookErrorLength  = 500
+)
+
+// WebhookRequest is one signed attempt to deliver an event.
+type WebhookRequest struct {
+	URL       string
+	EventID   string
+	EventType string
+	Signature string
+	Body      []byte
+}
+
+// WebhookSender posts a webhook request and returns the response status.
+type WebhookSender interface {
+	Send(ctx context.Context, req WebhookRequest) (int, error)
+}
+
+// WebhookDispatcher sends the deliveries queued in the webhook outbox. A
+// failed delivery is retried with exponential backoff until it runs out of
+// attempts and is marked dead. Deliveries are leased while they are sent,
+// so several instances can run the dispatcher side by side, and one whose
+// instance died is retried once its lease runs out.
+type WebhookDispatcher struct {
+	repo        postgres.Repository
+	sender      WebhookSender
+	interval    time.Duration
+	timeout     time.Duration
+	maxAttempts int64
+	concurrency int
+	retention   time.Duration
+	lastCleanup time.Time
+}
+
+func NewWebhookDispatcher(repo postgres.Repository, sender WebhookSender, cfg *config.Config) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo:        repo,
+		sender:      sender,
+		interval:    cfg.WebhookPollInterval,
+		timeout:     cfg.WebhookTimeout,
+		maxAttempts: int64(max(cfg.WebhookMaxAttempts, 1)),
+		concurrency: max(cfg.WebhookConcurrency, 1),
+		retention:   cfg.WebhookRetention,
+	}
+}
+
+// Run blocks until ctx is done. A non-positive interval disables sending;
+// events keep piling up in the outbox until it is enabled again.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	if d.interval <= 0 {
+		log.Info().Msg("Webhook deliveries disabled")
+		return
+	}
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDue(ctx)
+		d.cleanUp(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchDue sends due deliveries in batches of one per worker until none
+// are left.
+func (d *WebhookDispatcher) dispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.repo.ClaimWebhookDeliveries(ctx, sqldb.ClaimWebhookDeliveriesParams{
+			BatchSize: int64(d.concurrency),
+			// Every request of the batch runs at once and is bounded by the
+			// timeout; the margin covers recording the outcome.
+			LeaseUntil: time.Now().Add(d.timeout + time.Minute),
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Msg("Failed to claim webhook deliveries")
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < d.concurrency {
+			return
+		}
+	}
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery sqldb.ClaimWebhookDeliveriesRow) {
+	status, err := d.sender.Send(ctx, WebhookRequest{
+		URL:       delivery.Url,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Signature: signWebhook(delivery.Secret, time.Now(), delivery.Payload),
+		Body:      delivery.Payload,
+	})
+	if ctx.Err() != nil {
+		// Shutting down; the delivery is retried once its lease runs out.
+		return
+	}
+
+	var code *int64
+	if status > 0 {
+		c := int64(status)
+		code = &c
+	}
+	if err == nil && (status < 200 || status > 299) {
+		err = fmt.Errorf("unexpected status %d", status)
+	}
+
+	if err == nil {
+		if err := d.repo.MarkWebhookDeliverySucceeded(ctx, sqldb.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			LastStatusCode: code,
+		}); err != nil {
+			log.Error().Err(err).Int64("delivery_id", delivery.ID).Msg("Failed to record webhook delivery")
+		}
+		return
+	}
+
+	msg := err.Error()
+	if len(msg) > maxWebhookErrorLength {
+		msg = msg[:maxWebhookErrorLength]
+	}
+	params := sqldb.MarkWebhookDeliveryFailedParams{
+		ID:             delivery.ID,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  time.Now().Add(webhookBackoff(delivery.Attempts)),
+		LastStatusCode: code,
+		LastError:      &msg,
+	}
+	if delivery.Attempts >= d.maxAttempts {
+		params.Status = WebhookDeliveryDead
+		params.NextAttemptAt = time.Now()
+		log.Warn().Int64("delivery_id", delivery.ID).Str("event_type", delivery.EventType).Str("error", msg).
+			Msg("Webhook delivery failed for good")
+	}
+	if err := d.repo.MarkWebhookDeliveryFailed(ctx, params); err != nil {
+		log.Error().Err(err).Int64("delivery_id", delivery.ID).Msg("Failed to record webhook delivery")
+	}
+}
+
+// cleanUp drops finished deliveries older than the retention period, at
+// most once per cleanup interval.
+func (d *WebhookDispatcher) cleanUp(ctx context.Context) {
+	if d.retention <= 0 || time.Since(d.lastCleanup) < webhookCleanupInterval {
+		return
+	}
+	d.lastCleanup = time.Now()
+
+	deleted, err := d.repo.DeleteFinishedWebhookDeliveries(ctx, time.Now().Add(-d.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to delete old webhook deliveries")
+		}
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("Deleted old webhook deliveries")
+	}
+}
+
+// webhookBackoff is the wait after the given number of failed attempts: 30s,
+// doubling each time up to 6h.
+func webhookBackoff(attempts int64) time.Duration {
+	delay := webhookRetryBase
+	for i := int64(1); i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
+
+// signWebhook returns the signature header of a webhook body sent at t:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">".
+// Receivers recompute it with their secret and reject stale timestamps to
+// stop replays.
+func signWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"qrcodegen/config"
+	"qrcodegen/internal/repository/postgres"
+	sqldb "qrcodegen/sqlc/generated"
+)
+
+// webhookRepo hands out its due deliveries like the claim query, counting
+// the attempt up front, and records the outcomes.
+type webhookRepo struct {
+	postgres.Repository
+
+	mu        sync.Mutex
+	due       []sqldb.ClaimWebhookDeliveriesRow
+	claims    []sqldb.ClaimWebhookDeliveriesParams
+	succeeded []sqldb.MarkWebhookDeliverySucceededParams
+	failed    []sqldb.MarkWebhookDeliveryFailedParams
+}
+
+func (r *webhookRepo) ClaimWebhookDeliveries(ctx context.Context, arg sqldb.ClaimWebhookDeliveriesParams) ([]sqldb.ClaimWebhookDeliveriesRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claims = append(r.claims, arg)
+	n := min(int(arg.BatchSize), len(r.due))
+	claimed := r.due[:n]
+	r.due = r.due[n:]
+	for i := range claimed {
+		claimed[i].Attempts++
+	}
+	return claimed, nil
+}
+
+func (r *webhookRepo) MarkWebhookDeliverySucceeded(ctx context.Context, arg sqldb.MarkWebhookDeliverySucceededParams) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.succeeded = append(r.succeeded, arg)
+	return nil
+}
+
+func (r *webhookRepo) MarkWebhookDeliveryFailed(ctx context.Context, arg sqldb.MarkWebhookDeliveryFailedParams) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = append(r.failed, arg)
+	return nil
+}
+
+// stubSender answers every request with send and records the requests.
+type stubSender struct {
+	mu   sync.Mutex
+	reqs []WebhookRequest
+	send func(ctx context.Context, req WebhookRequest) (int, error)
+}
+
+func (s *stubSender) Send(ctx context.Context, req WebhookRequest) (int, error) {
+	s.mu.Lock()
+	s.reqs = append(s.reqs, req)
+	s.mu.Unlock()
+	return s.send(ctx, req)
+}
+
+func newTestWebhookDispatcher(repo *webhookRepo, sender *stubSender) *WebhookDispatcher {
+	return NewWebhookDispatcher(repo, sender, &config.Config{
+		WebhookTimeout:     10 * time.Second,
+		WebhookMaxAttempts: 3,
+		WebhookConcurrency: 2,
+	})
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"link.scanned"}`)
+	got := signWebhook("s3cret", time.Unix(1700000000, 0), body)
+
+	ts, sig, ok := strings.Cut(got, ",")
+	if !ok || ts != "t=1700000000" || !strings.HasPrefix(sig, "v1=") {
+		t.Fatalf("signWebhook() = %q, want t=<unix seconds>,v1=<hex>", got)
+	}
+
+	// What a receiver computes from the header and the raw body.
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	if want := hex.EncodeToString(mac.Sum(nil)); sig[len("v1="):] != want {
+		t.Errorf("signature = %s, want %s", sig[len("v1="):], want)
+	}
+
+	if signWebhook("other", time.Unix(1700000000, 0), body) == got {
+		t.Error("signature does not depend on the secret")
+	}
+	if signWebhook("s3cret", time.Unix(1700000001, 0), body) == got {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int64
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 30 * time.Second << 9},
+		{11, 6 * time.Hour},
+		{1000, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDispatcherDeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int64
+		status   int
+		err      error
+		// wantStatus is empty for a success.
+		wantStatus string
+		wantCode   int64
+		wantError  string
+		wantDelay  time.Duration
+	}{
+		{name: "success", attempts: 1, status: 204, wantCode: 204},
+		{name: "error status", attempts: 1, status: 500, wantStatus: WebhookDeliveryPending, wantCode: 500,
+			wantError: "unexpected status 500", wantDelay: 30 * time.Second},
+		{name: "redirect", attempts: 2, status: 301, wantStatus: WebhookDeliveryPending, wantCode: 301,
+			wantError: "unexpected status 301", wantDelay: time.Minute},
+		{name: "no response", attempts: 2, err: errors.New("connection refused"), wantStatus: WebhookDeliveryPending,
+			wantError: "connection refused", wantDelay: time.Minute},
+		{name: "last attempt", attempts: 3, status: 503, wantStatus: WebhookDeliveryDead, wantCode: 503,
+			wantError: "unexpected status 503"},
+		{name: "long error", attempts: 1, err: errors.New(strings.Repeat("x", 600)), wantStatus: WebhookDeliveryPending,
+			wantError: strings.Repeat("x", maxWebhookErrorLength), wantDelay: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &webhookRepo{}
+			sender := &stubSender{send: func(context.Context, WebhookRequest) (int, error) { return tt.status, tt.err }}
+			d := newTestWebhookDispatcher(repo, sender)
+
+			delivery := sqldb.ClaimWebhookDeliveriesRow{
+				ID:        7,
+				EventID:   "evt_1",
+				EventType: "link.scanned",
+				Payload:   []byte(`{}`),
+				Attempts:  tt.attempts,
+				Url:       "https://hooks.example.com",
+				Secret:    "s3cret",
+			}
+			before := time.Now()
+			d.deliver(context.Background(), delivery)
+
+			req := sender.reqs[0]
+			if req.URL != delivery.Url || req.EventID != "evt_1" || req.EventType != "link.scanned" {
+				t.Errorf("request = %+v, want it addressed from the delivery", req)
+			}
+			if ts, _, _ := strings.Cut(req.Signature, ","); signWebhook("s3cret", parseSignatureTime(t, ts), req.Body) != req.Signature {
+				t.Errorf("signature %q does not match the body", req.Signature)
+			}
+
+			if tt.wantStatus == "" {
+				if len(repo.succeeded) != 1 || len(repo.failed) != 0 || *repo.succeeded[0].LastStatusCode != tt.wantCode {
+					t.Fatalf("succeeded %+v, failed %+v; want one success", repo.succeeded, repo.failed)
+				}
+				return
+			}
+
+			if len(repo.failed) != 1 || len(repo.succeeded) != 0 {
+				t.Fatalf("succeeded %+v, failed %+v; want one failure", repo.succeeded, repo.failed)
+			}
+			got := repo.failed[0]
+			if got.ID != 7 || got.Status != tt.wantStatus || *got.LastError != tt.wantError {
+				t.Errorf("failure = %s %q, want %s %q", got.Status, *got.LastError, tt.wantStatus, tt.wantError)
+			}
+			if (got.LastStatusCode == nil) != (tt.wantCode == 0) || (got.LastStatusCode != nil && *got.LastStatusCode != tt.wantCode) {
+				t.Errorf("status code = %v, want %d", got.LastStatusCode, tt.wantCode)
+			}
+			if delay := got.NextAttemptAt.Sub(before); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+				t.Errorf("next attempt in %v, want %v", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func parseSignatureTime(t *testing.T, ts string) time.Time {
+	t.Helper()
+	unix, err := strconv.ParseInt(strings.TrimPrefix(ts, "t="), 10, 64)
+	if err != nil {
+		t.Fatalf("signature timestamp %q: %v", ts, err)
+	}
+	return time.Unix(unix, 0)
+}
+
+func TestWebhookDispatcherLease(t *testing.T) {
+	repo := &webhookRepo{}
+	for i := range 3 {
+		repo.due = append(repo.due, sqldb.ClaimWebhookDeliveriesRow{ID: int64(i + 1), Url: "https://hooks.example.com"})
+	}
+	sender := &stubSender{send: func(context.Context, WebhookRequest) (int, error) { return 200, nil }}
+	d := newTestWebhookDispatcher(repo, sender)
+
+	before := time.Now()
+	d.dispatchDue(context.Background())
+
+	// Batches of one per worker until a short batch shows nothing is left.
+	if len(repo.claims) != 2 || len(repo.succeeded) != 3 {
+		t.Fatalf("%d claims and %d deliveries, want 2 and 3", len(repo.claims), len(repo.succeeded))
+	}
+	for _, claim := range repo.claims {
+		// The lease covers the request timeout and recording the outcome.
+		lease := claim.LeaseUntil.Sub(before)
+		if claim.BatchSize != 2 || lease < 10*time.Second+time.Minute || lease > 10*time.Second+time.Minute+time.Second {
+			t.Errorf("claim = batch of %d leased for %v, want 2 for 1m10s", claim.BatchSize, lease)
+		}
+	}
+}
+
+func TestWebhookDispatcherShutdownKeepsLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := &webhookRepo{due: []sqldb.ClaimWebhookDeliveriesRow{{ID: 1}}}
+	sender := &stubSender{send: func(ctx context.Context, req WebhookRequest) (int, error) {
+		cancel()
+		return 0, ctx.Err()
+	}}
+	d := newTestWebhookDispatcher(repo, sender)
+
+	d.dispatchDue(ctx)
+
+	// Nothing is recorded; the delivery is retried once its lease runs out,
+	// with the attempt already counted by the claim.
+	if len(sender.reqs) != 1 || len(repo.succeeded) != 0 || len(repo.failed) != 0 {
+		t.Errorf("%d sent, %d succeeded, %d failed; want the interrupted delivery left leased",
+			len(sender.reqs), len(repo.succeeded), len(repo.failed))
+	}
+}
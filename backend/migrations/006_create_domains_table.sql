@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE "domains" (
+  "id" serial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "host" varchar NOT NULL UNIQUE,
+  "verification_token" varchar NOT NULL,
+  "verified_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "domains" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+CREATE INDEX ON "domains" ("user_id");
+
+ALTER TABLE "links" ADD COLUMN "domain_id" integer;
+ALTER TABLE "links" ADD FOREIGN KEY ("domain_id") REFERENCES "domains" ("id");
+
+-- +goose Down
+ALTER TABLE "links" DROP COLUMN IF EXISTS "domain_id";
+DROP TABLE IF EXISTS "domains";
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE "blocked_domains" (
+  "id" serial PRIMARY KEY,
+  "host" varchar NOT NULL UNIQUE,
+  "reason" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "users" ADD COLUMN "is_admin" boolean NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_admin";
+DROP TABLE IF EXISTS "blocked_domains";
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE "link_health" (
+  "link_id" integer PRIMARY KEY,
+  "status_code" integer,
+  "redirect_chain" varchar[] NOT NULL DEFAULT '{}',
+  "error" varchar,
+  "healthy" boolean NOT NULL,
+  "consecutive_failures" integer NOT NULL DEFAULT 0,
+  "checked_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "link_health" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+CREATE INDEX ON "link_health" ("checked_at");
+
+-- +goose Down
+DROP TABLE IF EXISTS "link_health";
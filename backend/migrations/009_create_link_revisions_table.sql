@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE "link_revisions" (
+  "id" serial PRIMARY KEY,
+  "link_id" integer NOT NULL,
+  "user_id" integer NOT NULL,
+  "action" varchar NOT NULL,
+  "original_url" varchar NOT NULL,
+  "color" varchar(6) NOT NULL,
+  "background" varchar(6) NOT NULL,
+  "smoothing" float,
+  "domain_id" integer,
+  "rolled_back_from" integer,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "link_revisions" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+ALTER TABLE "link_revisions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+CREATE INDEX ON "link_revisions" ("link_id");
+
+-- links.revision_id points at the current revision. It has no foreign key so
+-- a link and its revisions can be deleted without breaking the cycle first.
+ALTER TABLE "links" ADD COLUMN "revision_id" integer;
+ALTER TABLE "transitions" ADD COLUMN "revision_id" integer;
+CREATE INDEX ON "transitions" ("revision_id");
+
+INSERT INTO "link_revisions" ("link_id", "user_id", "action", "original_url", "color", "background", "smoothing", "domain_id", "created_at")
+SELECT l.id, l.user_id, 'create', l.original_url, qc.color, qc.background, qc.smoothing, l.domain_id, l.updated_at
+FROM "links" l
+JOIN "qr_codes" qc ON qc.link_id = l.id;
+
+UPDATE "links" SET "revision_id" = r.id
+FROM "link_revisions" r
+WHERE r.link_id = "links".id;
+
+-- +goose Down
+ALTER TABLE "transitions" DROP COLUMN IF EXISTS "revision_id";
+ALTER TABLE "links" DROP COLUMN IF EXISTS "revision_id";
+DROP TABLE IF EXISTS "link_revisions";
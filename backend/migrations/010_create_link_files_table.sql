@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE "link_files" (
+  "id" serial PRIMARY KEY,
+  "link_id" integer NOT NULL UNIQUE,
+  "storage_key" varchar NOT NULL,
+  "filename" varchar NOT NULL,
+  "content_type" varchar NOT NULL,
+  "size" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "link_files" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+
+-- Set for links that serve a hosted file instead of redirecting. Like
+-- revision_id it has no foreign key to avoid a reference cycle.
+ALTER TABLE "links" ADD COLUMN "file_id" integer;
+
+-- +goose Down
+ALTER TABLE "links" DROP COLUMN IF EXISTS "file_id";
+DROP TABLE IF EXISTS "link_files";
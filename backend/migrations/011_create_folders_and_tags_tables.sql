@@ -0,0 +1,41 @@
+-- +goose Up
+CREATE TABLE "folders" (
+  "id" serial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "name" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("user_id", "name")
+);
+
+ALTER TABLE "folders" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE TABLE "tags" (
+  "id" serial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "name" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("user_id", "name")
+);
+
+ALTER TABLE "tags" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+CREATE TABLE "link_tags" (
+  "link_id" integer NOT NULL,
+  "tag_id" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("link_id", "tag_id")
+);
+
+ALTER TABLE "link_tags" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+ALTER TABLE "link_tags" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id");
+CREATE INDEX ON "link_tags" ("tag_id");
+
+ALTER TABLE "links" ADD COLUMN "folder_id" integer;
+ALTER TABLE "links" ADD FOREIGN KEY ("folder_id") REFERENCES "folders" ("id");
+CREATE INDEX ON "links" ("folder_id");
+
+-- +goose Down
+ALTER TABLE "links" DROP COLUMN IF EXISTS "folder_id";
+DROP TABLE IF EXISTS "link_tags";
+DROP TABLE IF EXISTS "tags";
+DROP TABLE IF EXISTS "folders";
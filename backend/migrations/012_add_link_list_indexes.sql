@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX "links_user_id_created_at_idx" ON "links" ("user_id", "created_at", "id");
+CREATE INDEX "transitions_link_id_created_at_idx" ON "transitions" ("link_id", "created_at");
+
+-- +goose Down
+DROP INDEX IF EXISTS "transitions_link_id_created_at_idx";
+DROP INDEX IF EXISTS "links_user_id_created_at_idx";
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE "links" ADD COLUMN "deleted_at" timestamptz;
+CREATE INDEX "links_deleted_at_idx" ON "links" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS "links_deleted_at_idx";
+ALTER TABLE "links" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE "links" ADD COLUMN "cloned_from" integer;
+ALTER TABLE "links" ADD FOREIGN KEY ("cloned_from") REFERENCES "links" ("id") ON DELETE SET NULL;
+CREATE INDEX ON "links" ("cloned_from");
+
+-- +goose Down
+ALTER TABLE "links" DROP COLUMN IF EXISTS "cloned_from";
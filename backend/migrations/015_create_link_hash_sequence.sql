@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE SEQUENCE "link_hash_seq";
+
+-- +goose Down
+DROP SEQUENCE IF EXISTS "link_hash_seq";
@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TABLE "links" ADD COLUMN "interstitial" boolean NOT NULL DEFAULT false;
+ALTER TABLE "transitions" ADD COLUMN "via_preview" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "link_previews" (
+  "link_id" integer PRIMARY KEY,
+  "title" varchar,
+  "description" varchar,
+  "image_url" varchar,
+  "fetched_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "link_previews" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+
+CREATE TABLE "link_preview_views" (
+  "id" serial PRIMARY KEY,
+  "link_id" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "link_preview_views" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+CREATE INDEX ON "link_preview_views" ("link_id");
+
+-- +goose Down
+DROP TABLE IF EXISTS "link_preview_views";
+DROP TABLE IF EXISTS "link_previews";
+ALTER TABLE "transitions" DROP COLUMN IF EXISTS "via_preview";
+ALTER TABLE "links" DROP COLUMN IF EXISTS "interstitial";
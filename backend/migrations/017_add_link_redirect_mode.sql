@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE "links" ADD COLUMN "redirect_mode" varchar NOT NULL DEFAULT '302';
+ALTER TABLE "links" ADD CONSTRAINT "links_redirect_mode_check"
+  CHECK ("redirect_mode" IN ('301', '302', '307', '308', 'meta_refresh', 'javascript'));
+
+-- +goose Down
+ALTER TABLE "links" DROP CONSTRAINT IF EXISTS "links_redirect_mode_check";
+ALTER TABLE "links" DROP COLUMN IF EXISTS "redirect_mode";
@@ -0,0 +1,35 @@
+-- +goose Up
+-- A schedule entry sends scans to original_url while it is active. "once"
+-- entries switch at starts_at; "weekly" entries are active on the weekdays
+-- in the weekdays bitmask (bit 0 = Sunday) between start_minute and
+-- end_minute, in minutes since local midnight of time_zone. A window whose
+-- end is not after its start runs past midnight. starts_at and ends_at
+-- bound either kind.
+CREATE TABLE "link_schedules" (
+  "id" serial PRIMARY KEY,
+  "link_id" integer NOT NULL,
+  "kind" varchar NOT NULL CHECK ("kind" IN ('once', 'weekly')),
+  "original_url" varchar NOT NULL,
+  "time_zone" varchar NOT NULL DEFAULT 'UTC',
+  "starts_at" timestamptz,
+  "ends_at" timestamptz,
+  "weekdays" integer NOT NULL DEFAULT 0,
+  "start_minute" integer NOT NULL DEFAULT 0,
+  "end_minute" integer NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "link_schedules" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+CREATE INDEX ON "link_schedules" ("link_id");
+
+ALTER TABLE "transitions" ADD COLUMN "schedule_id" integer;
+ALTER TABLE "transitions" ADD FOREIGN KEY ("schedule_id") REFERENCES "link_schedules" ("id") ON DELETE SET NULL;
+
+-- The destination the stored metadata was fetched from; it changes while a
+-- schedule entry is active.
+ALTER TABLE "link_previews" ADD COLUMN "original_url" varchar NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE "link_previews" DROP COLUMN IF EXISTS "original_url";
+ALTER TABLE "transitions" DROP COLUMN IF EXISTS "schedule_id";
+DROP TABLE IF EXISTS "link_schedules";
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Keyed hash of IP and User-Agent under the salt of the day the scan
+-- happened. Salts are deleted once their day is over, so hashes can't be
+-- recomputed or linked across days.
+ALTER TABLE "transitions" ADD COLUMN "visitor_hash" varchar;
+
+CREATE TABLE "visitor_salts" (
+  "day" timestamptz PRIMARY KEY,
+  "salt" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS "visitor_salts";
+ALTER TABLE "transitions" DROP COLUMN IF EXISTS "visitor_hash";
@@ -0,0 +1,44 @@
+-- +goose Up
+-- Transition counts per link, bucket and dimension value, kept up to date by
+-- the rollup aggregator. Hourly buckets start on the UTC hour, daily ones on
+-- UTC midnight. The 'total' dimension has a single '' value; for country,
+-- city, browser, os and referer '' stands for "not recorded".
+CREATE TABLE "transition_rollups_hourly" (
+  "link_id" integer NOT NULL,
+  "bucket" timestamptz NOT NULL,
+  "dimension" varchar NOT NULL,
+  "value" varchar NOT NULL,
+  "transitions_count" bigint NOT NULL,
+  "unique_visitors" bigint NOT NULL,
+  PRIMARY KEY ("link_id", "dimension", "bucket", "value")
+);
+
+ALTER TABLE "transition_rollups_hourly" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+
+CREATE TABLE "transition_rollups_daily" (
+  "link_id" integer NOT NULL,
+  "bucket" timestamptz NOT NULL,
+  "dimension" varchar NOT NULL,
+  "value" varchar NOT NULL,
+  "transitions_count" bigint NOT NULL,
+  "unique_visitors" bigint NOT NULL,
+  PRIMARY KEY ("link_id", "dimension", "bucket", "value")
+);
+
+ALTER TABLE "transition_rollups_daily" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+
+-- Transitions with ids up to last_transition_id are rolled up. Existing
+-- transitions are left to the rollup-backfill command.
+CREATE TABLE "rollup_state" (
+  "name" varchar PRIMARY KEY,
+  "last_transition_id" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+INSERT INTO "rollup_state" ("name", "last_transition_id")
+SELECT 'transitions', COALESCE(MAX("id"), 0) FROM "transitions";
+
+-- +goose Down
+DROP TABLE IF EXISTS "rollup_state";
+DROP TABLE IF EXISTS "transition_rollups_daily";
+DROP TABLE IF EXISTS "transition_rollups_hourly";
@@ -0,0 +1,40 @@
+-- +goose Up
+-- A webhook receives the events listed in events, signed with secret.
+CREATE TABLE "webhooks" (
+  "id" serial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "events" varchar[] NOT NULL,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhooks" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+CREATE INDEX ON "webhooks" ("user_id");
+
+-- The outbox: one row per event and webhook, written in the transaction that
+-- makes the change. Pending deliveries are sent once next_attempt_at has
+-- passed; those that keep failing end up as 'dead'.
+CREATE TABLE "webhook_deliveries" (
+  "id" serial PRIMARY KEY,
+  "webhook_id" integer NOT NULL,
+  "event_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'dead')),
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_status_code" integer,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id");
+CREATE INDEX ON "webhook_deliveries" ("webhook_id", "id");
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhooks";
@@ -0,0 +1,6 @@
+-- +goose Up
+-- State or province of the scanner, filled by resolvers with city-level data.
+ALTER TABLE "transitions" ADD COLUMN "region" varchar;
+
+-- +goose Down
+ALTER TABLE "transitions" DROP COLUMN IF EXISTS "region";
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Which geo resolver answered for the scan: header, mmdb or ipinfo.
+ALTER TABLE "transitions" ADD COLUMN "geo_source" varchar;
+
+-- +goose Down
+ALTER TABLE "transitions" DROP COLUMN IF EXISTS "geo_source";
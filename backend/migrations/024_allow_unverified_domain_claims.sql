@@ -0,0 +1,14 @@
+-- +goose Up
+-- Any user may claim a host until someone verifies it; only verified hosts
+-- are unique, so an abandoned claim no longer blocks the real owner.
+ALTER TABLE "domains" DROP CONSTRAINT IF EXISTS "domains_host_key";
+CREATE UNIQUE INDEX "domains_verified_host_key" ON "domains" ("host") WHERE "verified_at" IS NOT NULL;
+CREATE UNIQUE INDEX "domains_user_id_host_key" ON "domains" ("user_id", "host");
+
+-- +goose Down
+DELETE FROM "domains" d WHERE "verified_at" IS NULL
+  AND EXISTS (SELECT 1 FROM "domains" o WHERE o."host" = d."host" AND o."id" <> d."id")
+  AND NOT EXISTS (SELECT 1 FROM "links" l WHERE l."domain_id" = d."id");
+DROP INDEX IF EXISTS "domains_user_id_host_key";
+DROP INDEX IF EXISTS "domains_verified_host_key";
+ALTER TABLE "domains" ADD CONSTRAINT "domains_host_key" UNIQUE ("host");
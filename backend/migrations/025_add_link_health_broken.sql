@@ -0,0 +1,9 @@
+-- +goose Up
+-- Set by the health checker once a destination has failed
+-- HEALTH_CHECK_ALERT_AFTER checks in a row, the point at which it alerts.
+-- Existing rows assume the default of 3 until their next check.
+ALTER TABLE "link_health" ADD COLUMN "broken" boolean NOT NULL DEFAULT false;
+UPDATE "link_health" SET "broken" = "consecutive_failures" >= 3;
+
+-- +goose Down
+ALTER TABLE "link_health" DROP COLUMN IF EXISTS "broken";
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Time of the newest rolled up transition per link, kept by the rollup
+-- aggregator so the link list does not read transitions.
+CREATE TABLE "transition_rollups_latest" (
+  "link_id" integer PRIMARY KEY,
+  "last_transition_at" timestamptz NOT NULL
+);
+
+ALTER TABLE "transition_rollups_latest" ADD FOREIGN KEY ("link_id") REFERENCES "links" ("id");
+
+INSERT INTO "transition_rollups_latest" ("link_id", "last_transition_at")
+SELECT "link_id", MAX("created_at") FROM "transitions"
+WHERE "id" <= (SELECT "last_transition_id" FROM "rollup_state" WHERE "name" = 'transitions')
+GROUP BY "link_id";
+
+-- Lets the aggregator tell whether a visitor was already counted in a
+-- bucket without scanning the bucket.
+CREATE INDEX "transitions_link_id_visitor_hash_created_at_idx" ON "transitions" ("link_id", "visitor_hash", "created_at");
+
+-- +goose Down
+DROP INDEX IF EXISTS "transitions_link_id_visitor_hash_created_at_idx";
+DROP TABLE IF EXISTS "transition_rollups_latest";
@@ -0,0 +1,22 @@
+-- +goose Up
+-- The aggregator rolls up transitions only up to settled_transition_id.
+-- Each run records the last transition id handed out as pending, together
+-- with the snapshot xmax of that moment; once every transaction below that
+-- xmax has ended, no transition with a lower id can still be committing and
+-- the pending id becomes the settled one.
+ALTER TABLE "rollup_state" ADD COLUMN "settled_transition_id" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "rollup_state" ADD COLUMN "pending_transition_id" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "rollup_state" ADD COLUMN "pending_xmax" xid8;
+
+-- The transitions recorded before the rollups existed are backfilled by the
+-- server on its first start with this migration; until then the counts of
+-- older links leave them out.
+ALTER TABLE "rollup_state" ADD COLUMN "backfilled_at" timestamptz;
+
+UPDATE "rollup_state" SET "settled_transition_id" = "last_transition_id";
+
+-- +goose Down
+ALTER TABLE "rollup_state" DROP COLUMN IF EXISTS "backfilled_at";
+ALTER TABLE "rollup_state" DROP COLUMN IF EXISTS "pending_xmax";
+ALTER TABLE "rollup_state" DROP COLUMN IF EXISTS "pending_transition_id";
+ALTER TABLE "rollup_state" DROP COLUMN IF EXISTS "settled_transition_id";
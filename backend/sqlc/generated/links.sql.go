@@ -10,23 +10,75 @@ import (
 	"time"
 )
 
+const countLinksByUser = `-- name: CountLinksByUser :one
+SELECT COUNT(*) FROM links l
+WHERE l.user_id = $1 AND l.deleted_at IS NULL
+  AND ($2::text IS NULL OR l.name ILIKE '%' || $2 || '%')
+  AND ($3::integer IS NULL OR l.folder_id = $3)
+  AND ($4::integer IS NULL OR EXISTS (
+    SELECT 1 FROM link_tags lt WHERE lt.link_id = l.id AND lt.tag_id = $4
+  ))
+  AND ($5::timestamptz IS NULL OR l.created_at >= $5)
+  AND ($6::timestamptz IS NULL OR l.created_at < $6)
+  AND ($7::text IS NULL OR
+    lower(substring(l.original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)')) IN ($7, 'www.' || $7))
+  AND ($8::boolean IS NULL OR
+    EXISTS (SELECT 1 FROM transition_rollups_latest lr WHERE lr.link_id = l.id) = $8)
+`
+
+type CountLinksByUserParams struct {
+	UserID      int64      `json:"user_id"`
+	Search      *string    `json:"search"`
+	FolderID    *int64     `json:"folder_id"`
+	TagID       *int64     `json:"tag_id"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	Domain      *string    `json:"domain"`
+	HasScans    *bool      `json:"has_scans"`
+}
+
+func (q *Queries) CountLinksByUser(ctx context.Context, arg CountLinksByUserParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countLinksByUser,
+		arg.UserID,
+		arg.Search,
+		arg.FolderID,
+		arg.TagID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Domain,
+		arg.HasScans,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createLink = `-- name: CreateLink :one
 INSERT INTO links (
   original_url,
   hash,
   user_id,
-  name
+  name,
+  domain_id,
+  cloned_from,
+  interstitial,
+  redirect_mode
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, original_url, hash, created_at, updated_at, user_id, name
+ON CONFLICT (hash) DO NOTHING
+RETURNING id, original_url, hash, created_at, updated_at, user_id, name, domain_id, revision_id, file_id, folder_id, deleted_at, cloned_from, interstitial, redirect_mode
 `
 
 type CreateLinkParams struct {
-	OriginalUrl string `json:"original_url"`
-	Hash        string `json:"hash"`
-	UserID      int64  `json:"user_id"`
-	Name        string `json:"name"`
+	OriginalUrl  string `json:"original_url"`
+	Hash         string `json:"hash"`
+	UserID       int64  `json:"user_id"`
+	Name         string `json:"name"`
+	DomainID     *int64 `json:"domain_id"`
+	ClonedFrom   *int64 `json:"cloned_from"`
+	Interstitial bool   `json:"interstitial"`
+	RedirectMode string `json:"redirect_mode"`
 }
 
 func (q *Queries) CreateLink(ctx context.Context, arg CreateLinkParams) (Link, error) {
@@ -35,6 +87,10 @@ func (q *Queries) CreateLink(ctx context.Context, arg CreateLinkParams) (Link, e
 		arg.Hash,
 		arg.UserID,
 		arg.Name,
+		arg.DomainID,
+		arg.ClonedFrom,
+		arg.Interstitial,
+		arg.RedirectMode,
 	)
 	var i Link
 	err := row.Scan(
@@ -45,6 +101,14 @@ func (q *Queries) CreateLink(ctx context.Context, arg CreateLinkParams) (Link, e
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.Name,
+		&i.DomainID,
+		&i.RevisionID,
+		&i.FileID,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.ClonedFrom,
+		&i.Interstitial,
+		&i.RedirectMode,
 	)
 	return i, err
 }
@@ -86,45 +150,25 @@ func (q *Queries) CreateQRCode(ctx context.Context, arg CreateQRCodeParams) (QrC
 	return i, err
 }
 
-const createTransition = `-- name: CreateTransition :exec
-INSERT INTO transitions (
-  link_id,
-  country,
-  city,
-  referer,
-  user_agent,
-  browser,
-  os
-) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
-)
-`
-
-type CreateTransitionParams struct {
-	LinkID    int64   `json:"link_id"`
-	Country   *string `json:"country"`
-	City      *string `json:"city"`
-	Referer   *string `json:"referer"`
-	UserAgent *string `json:"user_agent"`
-	Browser   *string `json:"browser"`
-	Os        *string `json:"os"`
-}
-
-func (q *Queries) CreateTransition(ctx context.Context, arg CreateTransitionParams) error {
-	_, err := q.db.Exec(ctx, createTransition,
-		arg.LinkID,
-		arg.Country,
-		arg.City,
-		arg.Referer,
-		arg.UserAgent,
-		arg.Browser,
-		arg.Os,
-	)
-	return err
+type CreateTransitionsParams struct {
+	LinkID      int64     `json:"link_id"`
+	Country     *string   `json:"country"`
+	Region      *string   `json:"region"`
+	City        *string   `json:"city"`
+	Referer     *string   `json:"referer"`
+	UserAgent   *string   `json:"user_agent"`
+	Browser     *string   `json:"browser"`
+	Os          *string   `json:"os"`
+	RevisionID  *int64    `json:"revision_id"`
+	ViaPreview  bool      `json:"via_preview"`
+	ScheduleID  *int64    `json:"schedule_id"`
+	VisitorHash *string   `json:"visitor_hash"`
+	GeoSource   *string   `json:"geo_source"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 const deleteLink = `-- name: DeleteLink :execrows
-DELETE FROM links WHERE id = $1 AND user_id = $2
+DELETE FROM links WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
 `
 
 type DeleteLinkParams struct {
@@ -158,6 +202,50 @@ func (q *Queries) DeleteTransitionsByLinkID(ctx context.Context, linkID int64) e
 	return err
 }
 
+const getExpiredTrashedLinks = `-- name: GetExpiredTrashedLinks :many
+SELECT id, user_id, hash, file_id FROM links
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2
+`
+
+type GetExpiredTrashedLinksParams struct {
+	DeletedAt *time.Time `json:"deleted_at"`
+	Limit     int64      `json:"limit"`
+}
+
+type GetExpiredTrashedLinksRow struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Hash   string `json:"hash"`
+	FileID *int64 `json:"file_id"`
+}
+
+func (q *Queries) GetExpiredTrashedLinks(ctx context.Context, arg GetExpiredTrashedLinksParams) ([]GetExpiredTrashedLinksRow, error) {
+	rows, err := q.db.Query(ctx, getExpiredTrashedLinks, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetExpiredTrashedLinksRow
+	for rows.Next() {
+		var i GetExpiredTrashedLinksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Hash,
+			&i.FileID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getLinkAndQRCodeByID = `-- name: GetLinkAndQRCodeByID :one
 SELECT
     l.id,
@@ -168,13 +256,23 @@ SELECT
     l.name,
     qc.color,
     qc.background,
-    qc.smoothing
+    qc.smoothing,
+    l.domain_id,
+    d.host AS domain_host,
+    l.revision_id,
+    l.file_id,
+    l.folder_id,
+    l.cloned_from,
+    l.interstitial,
+    l.redirect_mode
 FROM
     links l
 JOIN
     qr_codes qc ON l.id = qc.link_id
+LEFT JOIN
+    domains d ON d.id = l.domain_id
 WHERE
-    l.id = $1 AND l.user_id = $2
+    l.id = $1 AND l.user_id = $2 AND l.deleted_at IS NULL
 `
 
 type GetLinkAndQRCodeByIDParams struct {
@@ -183,15 +281,23 @@ type GetLinkAndQRCodeByIDParams struct {
 }
 
 type GetLinkAndQRCodeByIDRow struct {
-	ID          int64     `json:"id"`
-	OriginalUrl string    `json:"original_url"`
-	Hash        string    `json:"hash"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Name        string    `json:"name"`
-	Color       string    `json:"color"`
-	Background  string    `json:"background"`
-	Smoothing   *float64  `json:"smoothing"`
+	ID           int64     `json:"id"`
+	OriginalUrl  string    `json:"original_url"`
+	Hash         string    `json:"hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Name         string    `json:"name"`
+	Color        string    `json:"color"`
+	Background   string    `json:"background"`
+	Smoothing    *float64  `json:"smoothing"`
+	DomainID     *int64    `json:"domain_id"`
+	DomainHost   *string   `json:"domain_host"`
+	RevisionID   *int64    `json:"revision_id"`
+	FileID       *int64    `json:"file_id"`
+	FolderID     *int64    `json:"folder_id"`
+	ClonedFrom   *int64    `json:"cloned_from"`
+	Interstitial bool      `json:"interstitial"`
+	RedirectMode string    `json:"redirect_mode"`
 }
 
 func (q *Queries) GetLinkAndQRCodeByID(ctx context.Context, arg GetLinkAndQRCodeByIDParams) (GetLinkAndQRCodeByIDRow, error) {
@@ -207,12 +313,20 @@ func (q *Queries) GetLinkAndQRCodeByID(ctx context.Context, arg GetLinkAndQRCode
 		&i.Color,
 		&i.Background,
 		&i.Smoothing,
+		&i.DomainID,
+		&i.DomainHost,
+		&i.RevisionID,
+		&i.FileID,
+		&i.FolderID,
+		&i.ClonedFrom,
+		&i.Interstitial,
+		&i.RedirectMode,
 	)
 	return i, err
 }
 
 const getLinkByHash = `-- name: GetLinkByHash :one
-SELECT id, original_url, hash, created_at, updated_at, user_id, name FROM links
+SELECT id, original_url, hash, created_at, updated_at, user_id, name, domain_id, revision_id, file_id, folder_id, deleted_at, cloned_from, interstitial, redirect_mode FROM links
 WHERE hash = $1 LIMIT 1
 `
 
@@ -227,6 +341,14 @@ func (q *Queries) GetLinkByHash(ctx context.Context, hash string) (Link, error)
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.Name,
+		&i.DomainID,
+		&i.RevisionID,
+		&i.FileID,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.ClonedFrom,
+		&i.Interstitial,
+		&i.RedirectMode,
 	)
 	return i, err
 }
@@ -262,43 +384,217 @@ func (q *Queries) GetLinksByUserID(ctx context.Context, userID int64) ([]GetLink
 	return items, nil
 }
 
-const getLinksSummaryByUser = `-- name: GetLinksSummaryByUser :many
-SELECT 
-  l.id,
-  l.original_url,
-  l.name,
-  l.created_at,
-  COALESCE(COUNT(t.id), 0) AS transitions_count
+const getLinksForExport = `-- name: GetLinksForExport :many
+SELECT
+    l.name,
+    l.original_url,
+    l.hash,
+    d.host AS domain_host,
+    qc.color,
+    qc.background,
+    qc.smoothing,
+    l.interstitial,
+    l.redirect_mode,
+    f.filename AS file_name,
+    COUNT(t.id) AS transitions_count,
+    l.created_at
 FROM links l
+JOIN qr_codes qc ON qc.link_id = l.id
+LEFT JOIN domains d ON d.id = l.domain_id
+LEFT JOIN link_files f ON f.id = l.file_id
 LEFT JOIN transitions t ON t.link_id = l.id
-WHERE l.user_id = $1
-GROUP BY l.id
-ORDER BY l.created_at DESC
+WHERE l.user_id = $1 AND l.deleted_at IS NULL
+GROUP BY l.id, d.host, qc.color, qc.background, qc.smoothing, f.filename
+ORDER BY l.created_at, l.id
 `
 
-type GetLinksSummaryByUserRow struct {
-	ID               int64       `json:"id"`
-	OriginalUrl      string      `json:"original_url"`
-	Name             string      `json:"name"`
-	CreatedAt        time.Time   `json:"created_at"`
-	TransitionsCount interface{} `json:"transitions_count"`
+type GetLinksForExportRow struct {
+	Name             string    `json:"name"`
+	OriginalUrl      string    `json:"original_url"`
+	Hash             string    `json:"hash"`
+	DomainHost       *string   `json:"domain_host"`
+	Color            string    `json:"color"`
+	Background       string    `json:"background"`
+	Smoothing        *float64  `json:"smoothing"`
+	Interstitial     bool      `json:"interstitial"`
+	RedirectMode     string    `json:"redirect_mode"`
+	FileName         *string   `json:"file_name"`
+	TransitionsCount int64     `json:"transitions_count"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
-func (q *Queries) GetLinksSummaryByUser(ctx context.Context, userID int64) ([]GetLinksSummaryByUserRow, error) {
-	rows, err := q.db.Query(ctx, getLinksSummaryByUser, userID)
+func (q *Queries) GetLinksForExport(ctx context.Context, userID int64) ([]GetLinksForExportRow, error) {
+	rows, err := q.db.Query(ctx, getLinksForExport, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetLinksSummaryByUserRow
+	var items []GetLinksForExportRow
 	for rows.Next() {
-		var i GetLinksSummaryByUserRow
+		var i GetLinksForExportRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.OriginalUrl,
+			&i.Hash,
+			&i.DomainHost,
+			&i.Color,
+			&i.Background,
+			&i.Smoothing,
+			&i.Interstitial,
+			&i.RedirectMode,
+			&i.FileName,
+			&i.TransitionsCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLinksPageByUser = `-- name: GetLinksPageByUser :many
+WITH summary AS (
+  SELECT
+    l.id,
+    l.original_url,
+    l.name,
+    l.created_at,
+    l.folder_id,
+    ts.transitions_count,
+    ts.unique_visitors,
+    lr.last_transition_at,
+    EXISTS (SELECT 1 FROM link_health h WHERE h.link_id = l.id AND h.broken) AS broken,
+    ARRAY(SELECT lt.tag_id FROM link_tags lt WHERE lt.link_id = l.id ORDER BY lt.tag_id)::integer[] AS tag_ids
+  FROM links l
+  LEFT JOIN LATERAL (
+    SELECT
+      COALESCE(SUM(r.transitions_count), 0)::bigint AS transitions_count,
+      COALESCE(SUM(r.unique_visitors), 0)::bigint AS unique_visitors
+    FROM transition_rollups_daily r WHERE r.link_id = l.id AND r.dimension = 'total'
+  ) ts ON true
+  LEFT JOIN transition_rollups_latest lr ON lr.link_id = l.id
+  WHERE l.user_id = $1 AND l.deleted_at IS NULL
+    AND ($2::text IS NULL OR l.name ILIKE '%' || $2 || '%')
+    AND ($3::integer IS NULL OR l.folder_id = $3)
+    AND ($4::integer IS NULL OR EXISTS (
+      SELECT 1 FROM link_tags lt WHERE lt.link_id = l.id AND lt.tag_id = $4
+    ))
+    AND ($5::timestamptz IS NULL OR l.created_at >= $5)
+    AND ($6::timestamptz IS NULL OR l.created_at < $6)
+    AND ($7::text IS NULL OR
+      lower(substring(l.original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)')) IN ($7, 'www.' || $7))
+    AND ($8::boolean IS NULL OR (lr.link_id IS NOT NULL) = $8)
+), keyed AS (
+  SELECT
+    s.id, s.original_url, s.name, s.created_at, s.folder_id, s.transitions_count, s.unique_visitors, s.last_transition_at, s.broken, s.tag_ids,
+    (CASE $9::text
+      WHEN 'created_at' THEN s.created_at
+      WHEN 'last_scan' THEN COALESCE(s.last_transition_at, 'epoch')
+      ELSE 'epoch'
+    END)::timestamptz AS sort_time,
+    (CASE $9::text WHEN 'transitions' THEN s.transitions_count ELSE 0 END)::bigint AS sort_num,
+    (CASE $9::text WHEN 'name' THEN s.name ELSE '' END)::text AS sort_text
+  FROM summary s
+)
+SELECT
+  k.id, k.original_url, k.name, k.created_at, k.folder_id, k.transitions_count, k.unique_visitors, k.last_transition_at,
+  k.broken, k.tag_ids, k.sort_time, k.sort_num, k.sort_text
+FROM keyed k
+WHERE $10::integer IS NULL
+  OR ($11::text = 'asc' AND (k.sort_time, k.sort_num, k.sort_text, k.id) >
+    ($12::timestamptz, $13::bigint, $14::text, $10))
+  OR ($11::text <> 'asc' AND (k.sort_time, k.sort_num, k.sort_text, k.id) <
+    ($12, $13, $14, $10))
+ORDER BY
+  CASE WHEN $11 = 'asc' THEN k.sort_time END ASC,
+  CASE WHEN $11 = 'asc' THEN k.sort_num END ASC,
+  CASE WHEN $11 = 'asc' THEN k.sort_text END ASC,
+  CASE WHEN $11 = 'asc' THEN k.id END ASC,
+  k.sort_time DESC, k.sort_num DESC, k.sort_text DESC, k.id DESC
+LIMIT $15
+`
+
+type GetLinksPageByUserParams struct {
+	UserID      int64      `json:"user_id"`
+	Search      *string    `json:"search"`
+	FolderID    *int64     `json:"folder_id"`
+	TagID       *int64     `json:"tag_id"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	Domain      *string    `json:"domain"`
+	HasScans    *bool      `json:"has_scans"`
+	SortBy      string     `json:"sort_by"`
+	CursorID    *int64     `json:"cursor_id"`
+	SortOrder   string     `json:"sort_order"`
+	CursorTime  *time.Time `json:"cursor_time"`
+	CursorNum   *int64     `json:"cursor_num"`
+	CursorText  *string    `json:"cursor_text"`
+	PageSize    int64      `json:"page_size"`
+}
+
+type GetLinksPageByUserRow struct {
+	ID               int64      `json:"id"`
+	OriginalUrl      string     `json:"original_url"`
+	Name             string     `json:"name"`
+	CreatedAt        time.Time  `json:"created_at"`
+	FolderID         *int64     `json:"folder_id"`
+	TransitionsCount int64      `json:"transitions_count"`
+	UniqueVisitors   int64      `json:"unique_visitors"`
+	LastTransitionAt *time.Time `json:"last_transition_at"`
+	Broken           bool       `json:"broken"`
+	TagIds           []int64    `json:"tag_ids"`
+	SortTime         time.Time  `json:"sort_time"`
+	SortNum          int64      `json:"sort_num"`
+	SortText         string     `json:"sort_text"`
+}
+
+// Keyset page of the link list. Each row carries its sort key (sort_time,
+// sort_num, sort_text, id); the unused parts are constants, so one row
+// comparison against the cursor works for every sort column. Counts and
+// the last scan come from the rollups.
+func (q *Queries) GetLinksPageByUser(ctx context.Context, arg GetLinksPageByUserParams) ([]GetLinksPageByUserRow, error) {
+	rows, err := q.db.Query(ctx, getLinksPageByUser,
+		arg.UserID,
+		arg.Search,
+		arg.FolderID,
+		arg.TagID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Domain,
+		arg.HasScans,
+		arg.SortBy,
+		arg.CursorID,
+		arg.SortOrder,
+		arg.CursorTime,
+		arg.CursorNum,
+		arg.CursorText,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLinksPageByUserRow
+	for rows.Next() {
+		var i GetLinksPageByUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.OriginalUrl,
 			&i.Name,
 			&i.CreatedAt,
+			&i.FolderID,
 			&i.TransitionsCount,
+			&i.UniqueVisitors,
+			&i.LastTransitionAt,
+			&i.Broken,
+			&i.TagIds,
+			&i.SortTime,
+			&i.SortNum,
+			&i.SortText,
 		); err != nil {
 			return nil, err
 		}
@@ -314,15 +610,19 @@ const getTransitionsByLinkID = `-- name: GetTransitionsByLinkID :many
 SELECT
   t.id,
   t.country,
+  t.region,
   t.city,
   t.referer,
   t.user_agent,
   t.browser,
   t.os,
-  t.created_at
+  t.created_at,
+  t.revision_id,
+  t.schedule_id,
+  t.geo_source
 FROM transitions t
 JOIN links l ON l.id = t.link_id
-WHERE t.link_id = $1 AND l.user_id = $2
+WHERE t.link_id = $1 AND l.user_id = $2 AND l.deleted_at IS NULL
 ORDER BY t.created_at DESC
 `
 
@@ -332,14 +632,18 @@ type GetTransitionsByLinkIDParams struct {
 }
 
 type GetTransitionsByLinkIDRow struct {
-	ID        int64     `json:"id"`
-	Country   *string   `json:"country"`
-	City      *string   `json:"city"`
-	Referer   *string   `json:"referer"`
-	UserAgent *string   `json:"user_agent"`
-	Browser   *string   `json:"browser"`
-	Os        *string   `json:"os"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int64     `json:"id"`
+	Country    *string   `json:"country"`
+	Region     *string   `json:"region"`
+	City       *string   `json:"city"`
+	Referer    *string   `json:"referer"`
+	UserAgent  *string   `json:"user_agent"`
+	Browser    *string   `json:"browser"`
+	Os         *string   `json:"os"`
+	CreatedAt  time.Time `json:"created_at"`
+	RevisionID *int64    `json:"revision_id"`
+	ScheduleID *int64    `json:"schedule_id"`
+	GeoSource  *string   `json:"geo_source"`
 }
 
 func (q *Queries) GetTransitionsByLinkID(ctx context.Context, arg GetTransitionsByLinkIDParams) ([]GetTransitionsByLinkIDRow, error) {
@@ -354,12 +658,16 @@ func (q *Queries) GetTransitionsByLinkID(ctx context.Context, arg GetTransitions
 		if err := rows.Scan(
 			&i.ID,
 			&i.Country,
+			&i.Region,
 			&i.City,
 			&i.Referer,
 			&i.UserAgent,
 			&i.Browser,
 			&i.Os,
 			&i.CreatedAt,
+			&i.RevisionID,
+			&i.ScheduleID,
+			&i.GeoSource,
 		); err != nil {
 			return nil, err
 		}
@@ -371,6 +679,157 @@ func (q *Queries) GetTransitionsByLinkID(ctx context.Context, arg GetTransitions
 	return items, nil
 }
 
+const getTrashedLink = `-- name: GetTrashedLink :one
+SELECT id, user_id, hash, file_id FROM links
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+`
+
+type GetTrashedLinkParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+type GetTrashedLinkRow struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Hash   string `json:"hash"`
+	FileID *int64 `json:"file_id"`
+}
+
+func (q *Queries) GetTrashedLink(ctx context.Context, arg GetTrashedLinkParams) (GetTrashedLinkRow, error) {
+	row := q.db.QueryRow(ctx, getTrashedLink, arg.ID, arg.UserID)
+	var i GetTrashedLinkRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Hash,
+		&i.FileID,
+	)
+	return i, err
+}
+
+const getTrashedLinks = `-- name: GetTrashedLinks :many
+SELECT id, original_url, name, hash, created_at, deleted_at FROM links
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+`
+
+type GetTrashedLinksRow struct {
+	ID          int64      `json:"id"`
+	OriginalUrl string     `json:"original_url"`
+	Name        string     `json:"name"`
+	Hash        string     `json:"hash"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+func (q *Queries) GetTrashedLinks(ctx context.Context, userID int64) ([]GetTrashedLinksRow, error) {
+	rows, err := q.db.Query(ctx, getTrashedLinks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrashedLinksRow
+	for rows.Next() {
+		var i GetTrashedLinksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginalUrl,
+			&i.Name,
+			&i.Hash,
+			&i.CreatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isLinkHashTaken = `-- name: IsLinkHashTaken :one
+SELECT EXISTS (
+  SELECT 1 FROM links WHERE hash = $1
+)
+`
+
+// Trashed links count too: their hashes stay reserved until the purge.
+func (q *Queries) IsLinkHashTaken(ctx context.Context, hash string) (bool, error) {
+	row := q.db.QueryRow(ctx, isLinkHashTaken, hash)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const lockExistingLinks = `-- name: LockExistingLinks :many
+SELECT id FROM links
+WHERE id = ANY($1::bigint[])
+FOR KEY SHARE
+`
+
+// Returns those of the links that still exist and keeps them from being
+// purged until the transaction ends, so their scans can be written.
+func (q *Queries) LockExistingLinks(ctx context.Context, ids []int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, lockExistingLinks, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const nextLinkHashSequence = `-- name: NextLinkHashSequence :one
+SELECT nextval('link_hash_seq')::bigint
+`
+
+func (q *Queries) NextLinkHashSequence(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, nextLinkHashSequence)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const notifyLinkChanged = `-- name: NotifyLinkChanged :exec
+SELECT pg_notify('link_changes', $1::text)
+`
+
+func (q *Queries) NotifyLinkChanged(ctx context.Context, hash string) error {
+	_, err := q.db.Exec(ctx, notifyLinkChanged, hash)
+	return err
+}
+
+const restoreLink = `-- name: RestoreLink :one
+UPDATE links SET deleted_at = NULL
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+RETURNING hash
+`
+
+type RestoreLinkParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) RestoreLink(ctx context.Context, arg RestoreLinkParams) (string, error) {
+	row := q.db.QueryRow(ctx, restoreLink, arg.ID, arg.UserID)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
 const searchLinksByName = `-- name: SearchLinksByName :many
 SELECT id, original_url, name FROM links
 WHERE user_id = $1 AND name ILIKE '%' || $2 || '%'
@@ -407,80 +866,90 @@ func (q *Queries) SearchLinksByName(ctx context.Context, arg SearchLinksByNamePa
 	return items, nil
 }
 
-const searchLinksSummaryByName = `-- name: SearchLinksSummaryByName :many
-SELECT 
-  l.id,
-  l.original_url,
-  l.name,
-  l.created_at,
-  COALESCE(COUNT(t.id), 0) AS transitions_count
-FROM links l
-LEFT JOIN transitions t ON t.link_id = l.id
-WHERE l.user_id = $1 AND l.name ILIKE '%' || $2 || '%'
-GROUP BY l.id
-ORDER BY l.created_at DESC
+const setLinkInterstitial = `-- name: SetLinkInterstitial :execrows
+UPDATE links SET interstitial = $1
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
 `
 
-type SearchLinksSummaryByNameParams struct {
-	UserID  int64   `json:"user_id"`
-	Column2 *string `json:"column_2"`
+type SetLinkInterstitialParams struct {
+	Interstitial bool  `json:"interstitial"`
+	ID           int64 `json:"id"`
+	UserID       int64 `json:"user_id"`
 }
 
-type SearchLinksSummaryByNameRow struct {
-	ID               int64       `json:"id"`
-	OriginalUrl      string      `json:"original_url"`
-	Name             string      `json:"name"`
-	CreatedAt        time.Time   `json:"created_at"`
-	TransitionsCount interface{} `json:"transitions_count"`
+func (q *Queries) SetLinkInterstitial(ctx context.Context, arg SetLinkInterstitialParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setLinkInterstitial, arg.Interstitial, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-func (q *Queries) SearchLinksSummaryByName(ctx context.Context, arg SearchLinksSummaryByNameParams) ([]SearchLinksSummaryByNameRow, error) {
-	rows, err := q.db.Query(ctx, searchLinksSummaryByName, arg.UserID, arg.Column2)
+const setLinkRedirectMode = `-- name: SetLinkRedirectMode :execrows
+UPDATE links SET redirect_mode = $1
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+`
+
+type SetLinkRedirectModeParams struct {
+	RedirectMode string `json:"redirect_mode"`
+	ID           int64  `json:"id"`
+	UserID       int64  `json:"user_id"`
+}
+
+func (q *Queries) SetLinkRedirectMode(ctx context.Context, arg SetLinkRedirectModeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setLinkRedirectMode, arg.RedirectMode, arg.ID, arg.UserID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []SearchLinksSummaryByNameRow
-	for rows.Next() {
-		var i SearchLinksSummaryByNameRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.OriginalUrl,
-			&i.Name,
-			&i.CreatedAt,
-			&i.TransitionsCount,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
+}
+
+const trashLink = `-- name: TrashLink :one
+UPDATE links SET deleted_at = now()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+RETURNING hash
+`
+
+type TrashLinkParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
 }
 
-const updateLinkURL = `-- name: UpdateLinkURL :execrows
+func (q *Queries) TrashLink(ctx context.Context, arg TrashLinkParams) (string, error) {
+	row := q.db.QueryRow(ctx, trashLink, arg.ID, arg.UserID)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
+const updateLinkURL = `-- name: UpdateLinkURL :one
 UPDATE links
 SET
     original_url = $1,
+    domain_id = $2,
     updated_at = now()
 WHERE
-    id = $2 AND user_id = $3
+    id = $3 AND user_id = $4 AND deleted_at IS NULL
+RETURNING hash
 `
 
 type UpdateLinkURLParams struct {
 	OriginalUrl string `json:"original_url"`
+	DomainID    *int64 `json:"domain_id"`
 	ID          int64  `json:"id"`
 	UserID      int64  `json:"user_id"`
 }
 
-func (q *Queries) UpdateLinkURL(ctx context.Context, arg UpdateLinkURLParams) (int64, error) {
-	result, err := q.db.Exec(ctx, updateLinkURL, arg.OriginalUrl, arg.ID, arg.UserID)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected(), nil
+func (q *Queries) UpdateLinkURL(ctx context.Context, arg UpdateLinkURLParams) (string, error) {
+	row := q.db.QueryRow(ctx, updateLinkURL,
+		arg.OriginalUrl,
+		arg.DomainID,
+		arg.ID,
+		arg.UserID,
+	)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
 }
 
 const updateQRCodeParams = `-- name: UpdateQRCodeParams :exec
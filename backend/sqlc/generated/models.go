@@ -8,14 +8,116 @@ import (
 	"time"
 )
 
+type BlockedDomain struct {
+	ID        int64     `json:"id"`
+	Host      string    `json:"host"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Domain struct {
+	ID                int64      `json:"id"`
+	UserID            int64      `json:"user_id"`
+	Host              string     `json:"host"`
+	VerificationToken string     `json:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type Folder struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Link struct {
+	ID           int64      `json:"id"`
+	OriginalUrl  string     `json:"original_url"`
+	Hash         string     `json:"hash"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	UserID       int64      `json:"user_id"`
+	Name         string     `json:"name"`
+	DomainID     *int64     `json:"domain_id"`
+	RevisionID   *int64     `json:"revision_id"`
+	FileID       *int64     `json:"file_id"`
+	FolderID     *int64     `json:"folder_id"`
+	DeletedAt    *time.Time `json:"deleted_at"`
+	ClonedFrom   *int64     `json:"cloned_from"`
+	Interstitial bool       `json:"interstitial"`
+	RedirectMode string     `json:"redirect_mode"`
+}
+
+type LinkFile struct {
 	ID          int64     `json:"id"`
-	OriginalUrl string    `json:"original_url"`
-	Hash        string    `json:"hash"`
+	LinkID      int64     `json:"link_id"`
+	StorageKey  string    `json:"storage_key"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-	UserID      int64     `json:"user_id"`
-	Name        string    `json:"name"`
+}
+
+type LinkHealth struct {
+	LinkID              int64     `json:"link_id"`
+	StatusCode          *int64    `json:"status_code"`
+	RedirectChain       []string  `json:"redirect_chain"`
+	Error               *string   `json:"error"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int64     `json:"consecutive_failures"`
+	CheckedAt           time.Time `json:"checked_at"`
+	Broken              bool      `json:"broken"`
+}
+
+type LinkPreview struct {
+	LinkID      int64     `json:"link_id"`
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	ImageUrl    *string   `json:"image_url"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	OriginalUrl string    `json:"original_url"`
+}
+
+type LinkPreviewView struct {
+	ID        int64     `json:"id"`
+	LinkID    int64     `json:"link_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LinkRevision struct {
+	ID             int64     `json:"id"`
+	LinkID         int64     `json:"link_id"`
+	UserID         int64     `json:"user_id"`
+	Action         string    `json:"action"`
+	OriginalUrl    string    `json:"original_url"`
+	Color          string    `json:"color"`
+	Background     string    `json:"background"`
+	Smoothing      *float64  `json:"smoothing"`
+	DomainID       *int64    `json:"domain_id"`
+	RolledBackFrom *int64    `json:"rolled_back_from"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type LinkSchedule struct {
+	ID          int64      `json:"id"`
+	LinkID      int64      `json:"link_id"`
+	Kind        string     `json:"kind"`
+	OriginalUrl string     `json:"original_url"`
+	TimeZone    string     `json:"time_zone"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Weekdays    int64      `json:"weekdays"`
+	StartMinute int64      `json:"start_minute"`
+	EndMinute   int64      `json:"end_minute"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type LinkTag struct {
+	LinkID    int64     `json:"link_id"`
+	TagID     int64     `json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type QrCode struct {
@@ -26,22 +128,100 @@ type QrCode struct {
 	Smoothing  *float64 `json:"smoothing"`
 }
 
-type Transition struct {
+type RollupState struct {
+	Name                string      `json:"name"`
+	LastTransitionID    int64       `json:"last_transition_id"`
+	UpdatedAt           time.Time   `json:"updated_at"`
+	SettledTransitionID int64       `json:"settled_transition_id"`
+	PendingTransitionID int64       `json:"pending_transition_id"`
+	PendingXmax         interface{} `json:"pending_xmax"`
+	BackfilledAt        *time.Time  `json:"backfilled_at"`
+}
+
+type Tag struct {
 	ID        int64     `json:"id"`
-	LinkID    int64     `json:"link_id"`
-	Country   *string   `json:"country"`
-	City      *string   `json:"city"`
-	Referer   *string   `json:"referer"`
-	UserAgent *string   `json:"user_agent"`
-	Browser   *string   `json:"browser"`
-	Os        *string   `json:"os"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type Transition struct {
+	ID          int64     `json:"id"`
+	LinkID      int64     `json:"link_id"`
+	Country     *string   `json:"country"`
+	City        *string   `json:"city"`
+	Referer     *string   `json:"referer"`
+	UserAgent   *string   `json:"user_agent"`
+	Browser     *string   `json:"browser"`
+	Os          *string   `json:"os"`
+	CreatedAt   time.Time `json:"created_at"`
+	RevisionID  *int64    `json:"revision_id"`
+	ViaPreview  bool      `json:"via_preview"`
+	ScheduleID  *int64    `json:"schedule_id"`
+	VisitorHash *string   `json:"visitor_hash"`
+	Region      *string   `json:"region"`
+	GeoSource   *string   `json:"geo_source"`
+}
+
+type TransitionRollupsDaily struct {
+	LinkID           int64     `json:"link_id"`
+	Bucket           time.Time `json:"bucket"`
+	Dimension        string    `json:"dimension"`
+	Value            string    `json:"value"`
+	TransitionsCount int64     `json:"transitions_count"`
+	UniqueVisitors   int64     `json:"unique_visitors"`
+}
+
+type TransitionRollupsHourly struct {
+	LinkID           int64     `json:"link_id"`
+	Bucket           time.Time `json:"bucket"`
+	Dimension        string    `json:"dimension"`
+	Value            string    `json:"value"`
+	TransitionsCount int64     `json:"transitions_count"`
+	UniqueVisitors   int64     `json:"unique_visitors"`
+}
+
+type TransitionRollupsLatest struct {
+	LinkID           int64     `json:"link_id"`
+	LastTransitionAt time.Time `json:"last_transition_at"`
+}
+
 type User struct {
 	ID             int64     `json:"id"`
 	Name           string    `json:"name"`
 	Email          string    `json:"email"`
 	HashedPassword string    `json:"hashed_password"`
 	CreatedAt      time.Time `json:"created_at"`
+	IsAdmin        bool      `json:"is_admin"`
+}
+
+type VisitorSalt struct {
+	Day       time.Time `json:"day"`
+	Salt      []byte    `json:"salt"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int64      `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode *int64     `json:"last_status_code"`
+	LastError      *string    `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
 }
@@ -6,26 +6,185 @@ package sqldb
 
 import (
 	"context"
+
+	"time"
 )
 
 type Querier interface {
+	AddLinkTags(ctx context.Context, arg AddLinkTagsParams) error
+	// Takes up to batch_size due deliveries and hides them from other
+	// dispatchers until lease_until, counting the attempt up front so a crash
+	// mid-send still uses it up. Paused webhooks only get test events.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	ClearFolderFromLinks(ctx context.Context, folderID *int64) error
+	CountLinksByDomain(ctx context.Context, domainID *int64) (int64, error)
+	CountLinksByUser(ctx context.Context, arg CountLinksByUserParams) (int64, error)
+	CountTagsByIDs(ctx context.Context, arg CountTagsByIDsParams) (int64, error)
+	CreateBlockedDomain(ctx context.Context, arg CreateBlockedDomainParams) (BlockedDomain, error)
+	CreateDomain(ctx context.Context, arg CreateDomainParams) (Domain, error)
+	CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error)
 	CreateLink(ctx context.Context, arg CreateLinkParams) (Link, error)
+	CreateLinkFile(ctx context.Context, arg CreateLinkFileParams) (LinkFile, error)
+	CreateLinkPreviewViews(ctx context.Context, linkIds []int64) error
+	CreateLinkRevision(ctx context.Context, arg CreateLinkRevisionParams) (LinkRevision, error)
+	CreateLinkSchedule(ctx context.Context, arg CreateLinkScheduleParams) (LinkSchedule, error)
 	CreateQRCode(ctx context.Context, arg CreateQRCodeParams) (QrCode, error)
-	CreateTransition(ctx context.Context, arg CreateTransitionParams) error
+	CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error)
+	CreateTransitions(ctx context.Context, arg []CreateTransitionsParams) (int64, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVisitorSalt(ctx context.Context, arg CreateVisitorSaltParams) error
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	DeleteBlockedDomain(ctx context.Context, id int64) (int64, error)
+	DeleteDailyRollupsByLinkID(ctx context.Context, linkID int64) error
+	DeleteDomain(ctx context.Context, arg DeleteDomainParams) (int64, error)
+	// Drops delivered and dead deliveries created before created_before.
+	DeleteFinishedWebhookDeliveries(ctx context.Context, createdBefore time.Time) (int64, error)
+	DeleteFolder(ctx context.Context, arg DeleteFolderParams) (int64, error)
+	DeleteHourlyRollupsByLinkID(ctx context.Context, linkID int64) error
+	DeleteLatestRollupByLinkID(ctx context.Context, linkID int64) error
 	DeleteLink(ctx context.Context, arg DeleteLinkParams) (int64, error)
+	DeleteLinkFile(ctx context.Context, id int64) error
+	DeleteLinkHealthByLinkID(ctx context.Context, linkID int64) error
+	DeleteLinkPreviewByLinkID(ctx context.Context, linkID int64) error
+	DeleteLinkPreviewViewsByLinkID(ctx context.Context, linkID int64) error
+	DeleteLinkRevisionsByLinkID(ctx context.Context, linkID int64) error
+	DeleteLinkSchedule(ctx context.Context, arg DeleteLinkScheduleParams) (int64, error)
+	DeleteLinkSchedulesByLinkID(ctx context.Context, linkID int64) error
+	DeleteLinkTagsByLinkID(ctx context.Context, linkID int64) error
+	DeleteLinkTagsByTagID(ctx context.Context, tagID int64) error
 	DeleteQRCodeByLinkID(ctx context.Context, linkID int64) error
+	DeleteTag(ctx context.Context, arg DeleteTagParams) (int64, error)
 	DeleteTransitionsByLinkID(ctx context.Context, linkID int64) error
+	DeleteVisitorSaltsBefore(ctx context.Context, day time.Time) error
+	DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (int64, error)
+	DeleteWebhookDeliveriesByWebhookID(ctx context.Context, webhookID int64) error
+	// Adds the event to the outbox of every active webhook of the user that
+	// subscribes to its type.
+	EnqueueWebhookEvent(ctx context.Context, arg EnqueueWebhookEventParams) error
+	// EnqueueWebhookEvent for a batch of events of one type, given as parallel
+	// arrays with one entry per event.
+	EnqueueWebhookEvents(ctx context.Context, arg EnqueueWebhookEventsParams) error
+	GetBlockedDomains(ctx context.Context) ([]BlockedDomain, error)
+	// Adds up the hourly rollups per UTC day.
+	GetCampaignDailyTransitions(ctx context.Context, arg GetCampaignDailyTransitionsParams) ([]GetCampaignDailyTransitionsRow, error)
+	// Transition counts come from the hourly rollups, so the range is widened to
+	// whole hours; the last transition is looked up in transitions.
+	GetCampaignLinkStats(ctx context.Context, arg GetCampaignLinkStatsParams) ([]GetCampaignLinkStatsRow, error)
+	GetDomainByID(ctx context.Context, arg GetDomainByIDParams) (Domain, error)
+	GetDomainByUserAndHost(ctx context.Context, arg GetDomainByUserAndHostParams) (Domain, error)
+	GetDomainsByUser(ctx context.Context, userID int64) ([]Domain, error)
+	GetExpiredTrashedLinks(ctx context.Context, arg GetExpiredTrashedLinksParams) ([]GetExpiredTrashedLinksRow, error)
+	GetFolderByID(ctx context.Context, arg GetFolderByIDParams) (Folder, error)
+	GetFolderByName(ctx context.Context, arg GetFolderByNameParams) (Folder, error)
+	GetFoldersByUser(ctx context.Context, userID int64) ([]GetFoldersByUserRow, error)
+	// The last id handed out to a transition, whether committed or not.
+	GetLastTransitionID(ctx context.Context) (int64, error)
 	GetLinkAndQRCodeByID(ctx context.Context, arg GetLinkAndQRCodeByIDParams) (GetLinkAndQRCodeByIDRow, error)
 	GetLinkByHash(ctx context.Context, hash string) (Link, error)
+	GetLinkFile(ctx context.Context, id int64) (LinkFile, error)
+	GetLinkHealth(ctx context.Context, linkID int64) (LinkHealth, error)
+	GetLinkPreview(ctx context.Context, linkID int64) (LinkPreview, error)
+	GetLinkPreviewStats(ctx context.Context, linkID int64) (GetLinkPreviewStatsRow, error)
+	GetLinkRevision(ctx context.Context, arg GetLinkRevisionParams) (LinkRevision, error)
+	GetLinkRevisions(ctx context.Context, arg GetLinkRevisionsParams) ([]GetLinkRevisionsRow, error)
+	GetLinkSchedulesByLinkID(ctx context.Context, linkID int64) ([]LinkSchedule, error)
+	// Adds up the hourly rollups of every dimension and keeps the top_n values
+	// of each. An empty value stands for "not recorded".
+	GetLinkStatsBreakdowns(ctx context.Context, arg GetLinkStatsBreakdownsParams) ([]GetLinkStatsBreakdownsRow, error)
+	// Reads the rollups, so from_time and to_time are widened to whole hours.
+	// Unique visitors of hourly buckets come from the hourly rollups; coarser
+	// buckets add up the daily rollups of the UTC dates that fall in them.
+	GetLinkStatsSeries(ctx context.Context, arg GetLinkStatsSeriesParams) ([]GetLinkStatsSeriesRow, error)
+	// Transitions come from the hourly rollups, unique visitors from the daily
+	// ones, i.e. they cover the whole UTC days the range touches.
+	GetLinkStatsTotals(ctx context.Context, arg GetLinkStatsTotalsParams) (GetLinkStatsTotalsRow, error)
 	GetLinksByUserID(ctx context.Context, userID int64) ([]GetLinksByUserIDRow, error)
-	GetLinksSummaryByUser(ctx context.Context, userID int64) ([]GetLinksSummaryByUserRow, error)
+	GetLinksDueForHealthCheck(ctx context.Context, arg GetLinksDueForHealthCheckParams) ([]GetLinksDueForHealthCheckRow, error)
+	GetLinksForExport(ctx context.Context, userID int64) ([]GetLinksForExportRow, error)
+	// Keyset page of the link list. Each row carries its sort key (sort_time,
+	// sort_num, sort_text, id); the unused parts are constants, so one row
+	// comparison against the cursor works for every sort column. Counts and
+	// the last scan come from the rollups.
+	GetLinksPageByUser(ctx context.Context, arg GetLinksPageByUserParams) ([]GetLinksPageByUserRow, error)
+	GetRollupBackfilled(ctx context.Context, name string) (bool, error)
+	// The pending id is settled once every transaction that was running when it
+	// was recorded has ended.
+	GetRollupSettling(ctx context.Context, name string) (GetRollupSettlingRow, error)
+	// Last id of the next batch after after_id, at most settled_id.
+	GetRollupUpperBound(ctx context.Context, arg GetRollupUpperBoundParams) (int64, error)
+	GetRollupWatermark(ctx context.Context, name string) (int64, error)
+	GetTagByID(ctx context.Context, arg GetTagByIDParams) (Tag, error)
+	GetTagByName(ctx context.Context, arg GetTagByNameParams) (Tag, error)
+	GetTagsByLinkID(ctx context.Context, linkID int64) ([]GetTagsByLinkIDRow, error)
+	GetTagsByUser(ctx context.Context, userID int64) ([]GetTagsByUserRow, error)
 	GetTransitionsByLinkID(ctx context.Context, arg GetTransitionsByLinkIDParams) ([]GetTransitionsByLinkIDRow, error)
+	GetTrashedLink(ctx context.Context, arg GetTrashedLinkParams) (GetTrashedLinkRow, error)
+	GetTrashedLinks(ctx context.Context, userID int64) ([]GetTrashedLinksRow, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetVerifiedDomainByHost(ctx context.Context, host string) (Domain, error)
+	GetVisitorSalt(ctx context.Context, day time.Time) ([]byte, error)
+	GetWebhookByID(ctx context.Context, arg GetWebhookByIDParams) (Webhook, error)
+	// Newest first; before_id pages back through older deliveries.
+	GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	GetWebhooksByUser(ctx context.Context, userID int64) ([]Webhook, error)
+	IsAnyDomainBlocked(ctx context.Context, hosts []string) (bool, error)
+	// Trashed links count too: their hashes stay reserved until the purge.
+	IsLinkHashTaken(ctx context.Context, hash string) (bool, error)
+	// Returns those of the links that still exist and keeps them from being
+	// purged until the transaction ends, so their scans can be written.
+	LockExistingLinks(ctx context.Context, ids []int64) ([]int64, error)
+	MarkDomainVerified(ctx context.Context, arg MarkDomainVerifiedParams) (Domain, error)
+	// Records a failed attempt. status stays 'pending' to retry at
+	// next_attempt_at, or becomes 'dead' once the attempts are used up.
+	MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error
+	MarkWebhookDeliverySucceeded(ctx context.Context, arg MarkWebhookDeliverySucceededParams) error
+	NextLinkHashSequence(ctx context.Context) (int64, error)
+	// Tells the redirect caches of every instance that the host was verified
+	// or deleted, on the link changes channel.
+	NotifyDomainChanged(ctx context.Context, host string) error
+	NotifyLinkChanged(ctx context.Context, hash string) error
+	// Same as RecountTransitionsHourly for UTC days.
+	RecountTransitionsDaily(ctx context.Context, arg RecountTransitionsDailyParams) error
+	// Recounts every hour that has transitions with ids in (after_id, up_to_id]
+	// from all its transitions up to watermark, the id the aggregator has
+	// rolled up to. The result does not depend on earlier runs, so recounting
+	// the same ids twice is harmless.
+	RecountTransitionsHourly(ctx context.Context, arg RecountTransitionsHourlyParams) error
+	RenameFolder(ctx context.Context, arg RenameFolderParams) (Folder, error)
+	RenameTag(ctx context.Context, arg RenameTagParams) (Tag, error)
+	RestoreLink(ctx context.Context, arg RestoreLinkParams) (string, error)
+	// Queues a delivery again with a fresh set of attempts.
+	RetryWebhookDelivery(ctx context.Context, arg RetryWebhookDeliveryParams) (WebhookDelivery, error)
+	// Moves each link's last transition time forward to its newest transition
+	// with an id in (after_id, up_to_id]. Safe to repeat.
+	RollupLastTransitions(ctx context.Context, arg RollupLastTransitionsParams) error
+	// Same as RollupTransitionsHourly for UTC days.
+	RollupTransitionsDaily(ctx context.Context, arg RollupTransitionsDailyParams) error
+	// Adds the transitions with ids in (after_id, up_to_id] to their hourly
+	// buckets. A visitor is new to a bucket unless a transition rolled up
+	// earlier (id <= after_id) has the same visitor hash and dimension value
+	// there; that is an index lookup per visitor, not a recount of the bucket.
+	// Every id range must be rolled up exactly once.
+	RollupTransitionsHourly(ctx context.Context, arg RollupTransitionsHourlyParams) error
 	SearchLinksByName(ctx context.Context, arg SearchLinksByNameParams) ([]SearchLinksByNameRow, error)
-	SearchLinksSummaryByName(ctx context.Context, arg SearchLinksSummaryByNameParams) ([]SearchLinksSummaryByNameRow, error)
-	UpdateLinkURL(ctx context.Context, arg UpdateLinkURLParams) (int64, error)
+	SetLinkFile(ctx context.Context, arg SetLinkFileParams) error
+	SetLinkFolder(ctx context.Context, arg SetLinkFolderParams) (int64, error)
+	SetLinkInterstitial(ctx context.Context, arg SetLinkInterstitialParams) (int64, error)
+	SetLinkRedirectMode(ctx context.Context, arg SetLinkRedirectModeParams) (int64, error)
+	SetLinkRevision(ctx context.Context, arg SetLinkRevisionParams) error
+	SetRollupBackfilled(ctx context.Context, name string) error
+	// Must run in a later statement than GetLastTransitionID, so the snapshot
+	// includes every transaction that could hold an id up to the pending one.
+	SetRollupPending(ctx context.Context, arg SetRollupPendingParams) error
+	SetRollupWatermark(ctx context.Context, arg SetRollupWatermarkParams) error
+	TrashLink(ctx context.Context, arg TrashLinkParams) (string, error)
+	UpdateLinkFile(ctx context.Context, arg UpdateLinkFileParams) error
+	UpdateLinkURL(ctx context.Context, arg UpdateLinkURLParams) (string, error)
 	UpdateQRCodeParams(ctx context.Context, arg UpdateQRCodeParamsParams) error
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error)
+	UpsertLinkHealth(ctx context.Context, arg UpsertLinkHealthParams) (LinkHealth, error)
+	UpsertLinkPreview(ctx context.Context, arg UpsertLinkPreviewParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,21 @@
+-- name: CreateBlockedDomain :one
+INSERT INTO blocked_domains (
+  host,
+  reason
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (host) DO UPDATE SET reason = EXCLUDED.reason
+RETURNING *;
+
+-- name: GetBlockedDomains :many
+SELECT * FROM blocked_domains
+ORDER BY host;
+
+-- name: IsAnyDomainBlocked :one
+SELECT EXISTS (
+  SELECT 1 FROM blocked_domains WHERE host = ANY(sqlc.arg(hosts)::varchar[])
+);
+
+-- name: DeleteBlockedDomain :execrows
+DELETE FROM blocked_domains WHERE id = $1;
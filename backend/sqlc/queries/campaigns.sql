@@ -0,0 +1,46 @@
+-- name: GetCampaignLinkStats :many
+-- Transition counts come from the hourly rollups, so the range is widened to
+-- whole hours; the last transition is looked up in transitions.
+SELECT
+    l.id,
+    l.name,
+    l.hash,
+    COALESCE(SUM(r.transitions_count), 0)::bigint AS transitions_count,
+    MAX(ls.created_at) AS last_transition_at
+FROM links l
+LEFT JOIN transition_rollups_hourly r ON r.link_id = l.id AND r.dimension = 'total'
+    AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.bucket > sqlc.narg(from_time) - interval '1 hour')
+    AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.bucket < sqlc.narg(to_time))
+LEFT JOIN LATERAL (
+    SELECT t.created_at FROM transitions t
+    WHERE t.link_id = l.id
+        AND (sqlc.narg(from_time)::timestamptz IS NULL OR t.created_at >= sqlc.narg(from_time))
+        AND (sqlc.narg(to_time)::timestamptz IS NULL OR t.created_at < sqlc.narg(to_time))
+    ORDER BY t.created_at DESC
+    LIMIT 1
+) ls ON true
+WHERE l.user_id = sqlc.arg(user_id) AND l.deleted_at IS NULL
+    AND (sqlc.narg(folder_id)::integer IS NULL OR l.folder_id = sqlc.narg(folder_id))
+    AND (sqlc.narg(tag_id)::integer IS NULL OR EXISTS (
+        SELECT 1 FROM link_tags lt WHERE lt.link_id = l.id AND lt.tag_id = sqlc.narg(tag_id)
+    ))
+GROUP BY l.id
+ORDER BY transitions_count DESC, l.id;
+
+-- name: GetCampaignDailyTransitions :many
+-- Adds up the hourly rollups per UTC day.
+SELECT
+    date_trunc('day', r.bucket, 'UTC')::timestamptz AS day,
+    SUM(r.transitions_count)::bigint AS transitions_count
+FROM transition_rollups_hourly r
+JOIN links l ON l.id = r.link_id
+WHERE l.user_id = sqlc.arg(user_id) AND l.deleted_at IS NULL
+    AND (sqlc.narg(folder_id)::integer IS NULL OR l.folder_id = sqlc.narg(folder_id))
+    AND (sqlc.narg(tag_id)::integer IS NULL OR EXISTS (
+        SELECT 1 FROM link_tags lt WHERE lt.link_id = l.id AND lt.tag_id = sqlc.narg(tag_id)
+    ))
+    AND r.dimension = 'total'
+    AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.bucket > sqlc.narg(from_time) - interval '1 hour')
+    AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.bucket < sqlc.narg(to_time))
+GROUP BY day
+ORDER BY day;
@@ -0,0 +1,43 @@
+-- name: CreateDomain :one
+INSERT INTO domains (
+  user_id,
+  host,
+  verification_token
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetDomainByUserAndHost :one
+SELECT * FROM domains
+WHERE user_id = $1 AND host = $2;
+
+-- name: GetVerifiedDomainByHost :one
+SELECT * FROM domains
+WHERE host = $1 AND verified_at IS NOT NULL LIMIT 1;
+
+-- name: GetDomainByID :one
+SELECT * FROM domains
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetDomainsByUser :many
+SELECT * FROM domains
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: MarkDomainVerified :one
+UPDATE domains
+SET verified_at = now()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: CountLinksByDomain :one
+SELECT COUNT(*) FROM links WHERE domain_id = $1;
+
+-- name: DeleteDomain :execrows
+DELETE FROM domains WHERE id = $1 AND user_id = $2;
+
+-- name: NotifyDomainChanged :exec
+-- Tells the redirect caches of every instance that the host was verified
+-- or deleted, on the link changes channel.
+SELECT pg_notify('link_changes', 'domain:' || sqlc.arg(host)::text);
@@ -0,0 +1,43 @@
+-- name: CreateFolder :one
+INSERT INTO folders (
+  user_id,
+  name
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: GetFolderByID :one
+SELECT * FROM folders
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetFolderByName :one
+SELECT * FROM folders
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetFoldersByUser :many
+SELECT
+    f.id,
+    f.name,
+    f.created_at,
+    COUNT(l.id) AS links_count
+FROM folders f
+LEFT JOIN links l ON l.folder_id = f.id AND l.deleted_at IS NULL
+WHERE f.user_id = $1
+GROUP BY f.id
+ORDER BY f.name;
+
+-- name: RenameFolder :one
+UPDATE folders
+SET name = $1
+WHERE id = $2 AND user_id = $3
+RETURNING *;
+
+-- name: ClearFolderFromLinks :exec
+UPDATE links SET folder_id = NULL WHERE folder_id = $1;
+
+-- name: SetLinkFolder :execrows
+UPDATE links SET folder_id = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,32 @@
+-- name: CreateLinkFile :one
+INSERT INTO link_files (
+  link_id,
+  storage_key,
+  filename,
+  content_type,
+  size
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetLinkFile :one
+SELECT * FROM link_files
+WHERE id = $1;
+
+-- name: UpdateLinkFile :exec
+UPDATE link_files
+SET
+    storage_key = $1,
+    filename = $2,
+    content_type = $3,
+    size = $4,
+    updated_at = now()
+WHERE
+    id = $5;
+
+-- name: SetLinkFile :exec
+UPDATE links SET file_id = $1, updated_at = now() WHERE id = $2;
+
+-- name: DeleteLinkFile :exec
+DELETE FROM link_files WHERE id = $1;
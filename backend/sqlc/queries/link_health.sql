@@ -0,0 +1,39 @@
+-- name: GetLinksDueForHealthCheck :many
+SELECT
+  l.id,
+  l.original_url
+FROM links l
+LEFT JOIN link_health h ON h.link_id = l.id
+WHERE l.file_id IS NULL AND l.deleted_at IS NULL AND (h.checked_at IS NULL OR h.checked_at < $1)
+ORDER BY h.checked_at NULLS FIRST
+LIMIT $2;
+
+-- name: UpsertLinkHealth :one
+INSERT INTO link_health (
+  link_id,
+  status_code,
+  redirect_chain,
+  error,
+  healthy,
+  consecutive_failures,
+  broken,
+  checked_at
+) VALUES (
+  $1, $2, $3, $4, $5, CASE WHEN $5::boolean THEN 0 ELSE 1 END, NOT $5 AND 1 >= sqlc.arg(broken_after)::integer, now()
+)
+ON CONFLICT (link_id) DO UPDATE SET
+  status_code = EXCLUDED.status_code,
+  redirect_chain = EXCLUDED.redirect_chain,
+  error = EXCLUDED.error,
+  healthy = EXCLUDED.healthy,
+  consecutive_failures = CASE WHEN EXCLUDED.healthy THEN 0 ELSE link_health.consecutive_failures + 1 END,
+  broken = NOT EXCLUDED.healthy AND link_health.consecutive_failures + 1 >= sqlc.arg(broken_after),
+  checked_at = EXCLUDED.checked_at
+RETURNING *;
+
+-- name: GetLinkHealth :one
+SELECT * FROM link_health
+WHERE link_id = $1;
+
+-- name: DeleteLinkHealthByLinkID :exec
+DELETE FROM link_health WHERE link_id = $1;
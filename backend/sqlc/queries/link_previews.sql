@@ -0,0 +1,36 @@
+-- name: GetLinkPreview :one
+SELECT * FROM link_previews
+WHERE link_id = $1;
+
+-- name: UpsertLinkPreview :exec
+INSERT INTO link_previews (
+  link_id,
+  title,
+  description,
+  image_url,
+  original_url,
+  fetched_at
+) VALUES (
+  $1, $2, $3, $4, $5, now()
+)
+ON CONFLICT (link_id) DO UPDATE SET
+  title = EXCLUDED.title,
+  description = EXCLUDED.description,
+  image_url = EXCLUDED.image_url,
+  original_url = EXCLUDED.original_url,
+  fetched_at = EXCLUDED.fetched_at;
+
+-- name: DeleteLinkPreviewByLinkID :exec
+DELETE FROM link_previews WHERE link_id = $1;
+
+-- name: CreateLinkPreviewViews :exec
+INSERT INTO link_preview_views (link_id)
+SELECT unnest(sqlc.arg(link_ids)::bigint[]);
+
+-- name: DeleteLinkPreviewViewsByLinkID :exec
+DELETE FROM link_preview_views WHERE link_id = $1;
+
+-- name: GetLinkPreviewStats :one
+SELECT
+  (SELECT COUNT(*) FROM link_preview_views v WHERE v.link_id = $1)::bigint AS preview_views,
+  (SELECT COUNT(*) FROM transitions t WHERE t.link_id = $1 AND t.via_preview)::bigint AS click_throughs;
@@ -0,0 +1,46 @@
+-- name: CreateLinkRevision :one
+INSERT INTO link_revisions (
+  link_id,
+  user_id,
+  action,
+  original_url,
+  color,
+  background,
+  smoothing,
+  domain_id,
+  rolled_back_from
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: SetLinkRevision :exec
+UPDATE links SET revision_id = $1 WHERE id = $2;
+
+-- name: GetLinkRevision :one
+SELECT r.* FROM link_revisions r
+JOIN links l ON l.id = r.link_id
+WHERE r.id = $1 AND r.link_id = $2 AND l.user_id = $3 AND l.deleted_at IS NULL;
+
+-- name: GetLinkRevisions :many
+SELECT
+  r.id,
+  r.user_id,
+  r.action,
+  r.original_url,
+  r.color,
+  r.background,
+  r.smoothing,
+  r.domain_id,
+  r.rolled_back_from,
+  r.created_at,
+  COUNT(t.id) AS transitions_count
+FROM link_revisions r
+JOIN links l ON l.id = r.link_id
+LEFT JOIN transitions t ON t.revision_id = r.id
+WHERE r.link_id = $1 AND l.user_id = $2 AND l.deleted_at IS NULL
+GROUP BY r.id
+ORDER BY r.id DESC;
+
+-- name: DeleteLinkRevisionsByLinkID :exec
+DELETE FROM link_revisions WHERE link_id = $1;
@@ -0,0 +1,28 @@
+-- name: CreateLinkSchedule :one
+INSERT INTO link_schedules (
+  link_id,
+  kind,
+  original_url,
+  time_zone,
+  starts_at,
+  ends_at,
+  weekdays,
+  start_minute,
+  end_minute
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetLinkSchedulesByLinkID :many
+SELECT * FROM link_schedules
+WHERE link_id = $1
+ORDER BY id;
+
+-- name: DeleteLinkSchedule :one
+DELETE FROM link_schedules
+WHERE id = $1 AND link_id = $2
+RETURNING id;
+
+-- name: DeleteLinkSchedulesByLinkID :exec
+DELETE FROM link_schedules WHERE link_id = $1;
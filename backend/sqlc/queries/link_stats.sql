@@ -0,0 +1,71 @@
+-- name: GetLinkStatsSeries :many
+-- Reads the rollups, so from_time and to_time are widened to whole hours.
+-- Unique visitors of hourly buckets come from the hourly rollups; coarser
+-- buckets add up the daily rollups of the UTC dates that fall in them.
+WITH hourly AS (
+    SELECT
+        date_trunc(sqlc.arg(granularity)::text, r.bucket, sqlc.arg(time_zone)::text) AS bucket,
+        SUM(r.transitions_count) AS transitions_count,
+        SUM(r.unique_visitors) AS unique_visitors
+    FROM transition_rollups_hourly r
+    WHERE r.link_id = sqlc.arg(link_id) AND r.dimension = 'total'
+        AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.bucket > sqlc.narg(from_time) - interval '1 hour')
+        AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.bucket < sqlc.narg(to_time))
+    GROUP BY 1
+), daily AS (
+    SELECT
+        date_trunc(sqlc.arg(granularity), (r.bucket AT TIME ZONE 'UTC') AT TIME ZONE sqlc.arg(time_zone), sqlc.arg(time_zone)) AS bucket,
+        SUM(r.unique_visitors) AS unique_visitors
+    FROM transition_rollups_daily r
+    WHERE sqlc.arg(granularity) <> 'hour' AND r.link_id = sqlc.arg(link_id) AND r.dimension = 'total'
+        AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.bucket > sqlc.narg(from_time) - interval '24 hours')
+        AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.bucket < sqlc.narg(to_time))
+    GROUP BY 1
+)
+SELECT
+    COALESCE(h.bucket, d.bucket)::timestamptz AS bucket,
+    COALESCE(h.transitions_count, 0)::bigint AS transitions_count,
+    (CASE WHEN sqlc.arg(granularity) = 'hour' THEN COALESCE(h.unique_visitors, 0) ELSE COALESCE(d.unique_visitors, 0) END)::bigint AS unique_visitors
+FROM hourly h
+FULL JOIN daily d ON d.bucket = h.bucket
+ORDER BY 1;
+
+-- name: GetLinkStatsBreakdowns :many
+-- Adds up the hourly rollups of every dimension and keeps the top_n values
+-- of each. An empty value stands for "not recorded".
+WITH counts AS (
+    SELECT r.dimension, r.value, SUM(r.transitions_count) AS transitions_count
+    FROM transition_rollups_hourly r
+    WHERE r.link_id = sqlc.arg(link_id) AND r.dimension <> 'total'
+        AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.bucket > sqlc.narg(from_time) - interval '1 hour')
+        AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.bucket < sqlc.narg(to_time))
+    GROUP BY r.dimension, r.value
+), ranked AS (
+    SELECT
+        dimension,
+        value,
+        transitions_count,
+        row_number() OVER (PARTITION BY dimension ORDER BY transitions_count DESC, value) AS rank
+    FROM counts
+)
+SELECT dimension::text, value::text, transitions_count::bigint
+FROM ranked
+WHERE rank <= sqlc.arg(top_n)::bigint
+ORDER BY dimension, rank;
+
+-- name: GetLinkStatsTotals :one
+-- Transitions come from the hourly rollups, unique visitors from the daily
+-- ones, i.e. they cover the whole UTC days the range touches.
+SELECT
+    (SELECT COALESCE(SUM(r.transitions_count), 0)
+        FROM transition_rollups_hourly r
+        WHERE r.link_id = sqlc.arg(link_id) AND r.dimension = 'total'
+            AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.bucket > sqlc.narg(from_time) - interval '1 hour')
+            AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.bucket < sqlc.narg(to_time))
+    )::bigint AS transitions_count,
+    (SELECT COALESCE(SUM(r.unique_visitors), 0)
+        FROM transition_rollups_daily r
+        WHERE r.link_id = sqlc.arg(link_id) AND r.dimension = 'total'
+            AND (sqlc.narg(from_time)::timestamptz IS NULL OR r.bucket > sqlc.narg(from_time) - interval '24 hours')
+            AND (sqlc.narg(to_time)::timestamptz IS NULL OR r.bucket < sqlc.narg(to_time))
+    )::bigint AS unique_visitors;
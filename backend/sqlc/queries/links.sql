@@ -3,16 +3,27 @@ INSERT INTO links (
   original_url,
   hash,
   user_id,
-  name
+  name,
+  domain_id,
+  cloned_from,
+  interstitial,
+  redirect_mode
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, original_url, hash, created_at, updated_at, user_id, name;
+ON CONFLICT (hash) DO NOTHING
+RETURNING id, original_url, hash, created_at, updated_at, user_id, name, domain_id, revision_id, file_id, folder_id, deleted_at, cloned_from, interstitial, redirect_mode;
 
 -- name: GetLinkByHash :one
-SELECT id, original_url, hash, created_at, updated_at, user_id, name FROM links
+SELECT id, original_url, hash, created_at, updated_at, user_id, name, domain_id, revision_id, file_id, folder_id, deleted_at, cloned_from, interstitial, redirect_mode FROM links
 WHERE hash = $1 LIMIT 1;
 
+-- name: IsLinkHashTaken :one
+-- Trashed links count too: their hashes stay reserved until the purge.
+SELECT EXISTS (
+  SELECT 1 FROM links WHERE hash = $1
+);
+
 -- name: GetLinksByUserID :many
 SELECT id, original_url, name FROM links
 WHERE user_id = $1;
@@ -21,31 +32,108 @@ WHERE user_id = $1;
 SELECT id, original_url, name FROM links
 WHERE user_id = $1 AND name ILIKE '%' || $2 || '%';
 
--- name: GetLinksSummaryByUser :many
-SELECT 
-  l.id,
-  l.original_url,
-  l.name,
-  l.created_at,
-  COALESCE(COUNT(t.id), 0) AS transitions_count
-FROM links l
-LEFT JOIN transitions t ON t.link_id = l.id
-WHERE l.user_id = $1
-GROUP BY l.id
-ORDER BY l.created_at DESC;
-
--- name: SearchLinksSummaryByName :many
-SELECT 
-  l.id,
-  l.original_url,
-  l.name,
-  l.created_at,
-  COALESCE(COUNT(t.id), 0) AS transitions_count
+-- name: CountLinksByUser :one
+SELECT COUNT(*) FROM links l
+WHERE l.user_id = sqlc.arg(user_id) AND l.deleted_at IS NULL
+  AND (sqlc.narg(search)::text IS NULL OR l.name ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(folder_id)::integer IS NULL OR l.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tag_id)::integer IS NULL OR EXISTS (
+    SELECT 1 FROM link_tags lt WHERE lt.link_id = l.id AND lt.tag_id = sqlc.narg(tag_id)
+  ))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR l.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR l.created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(domain)::text IS NULL OR
+    lower(substring(l.original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)')) IN (sqlc.narg(domain), 'www.' || sqlc.narg(domain)))
+  AND (sqlc.narg(has_scans)::boolean IS NULL OR
+    EXISTS (SELECT 1 FROM transition_rollups_latest lr WHERE lr.link_id = l.id) = sqlc.narg(has_scans));
+
+-- name: GetLinksPageByUser :many
+-- Keyset page of the link list. Each row carries its sort key (sort_time,
+-- sort_num, sort_text, id); the unused parts are constants, so one row
+-- comparison against the cursor works for every sort column. Counts and
+-- the last scan come from the rollups.
+WITH summary AS (
+  SELECT
+    l.id,
+    l.original_url,
+    l.name,
+    l.created_at,
+    l.folder_id,
+    ts.transitions_count,
+    ts.unique_visitors,
+    lr.last_transition_at,
+    EXISTS (SELECT 1 FROM link_health h WHERE h.link_id = l.id AND h.broken) AS broken,
+    ARRAY(SELECT lt.tag_id FROM link_tags lt WHERE lt.link_id = l.id ORDER BY lt.tag_id)::integer[] AS tag_ids
+  FROM links l
+  LEFT JOIN LATERAL (
+    SELECT
+      COALESCE(SUM(r.transitions_count), 0)::bigint AS transitions_count,
+      COALESCE(SUM(r.unique_visitors), 0)::bigint AS unique_visitors
+    FROM transition_rollups_daily r WHERE r.link_id = l.id AND r.dimension = 'total'
+  ) ts ON true
+  LEFT JOIN transition_rollups_latest lr ON lr.link_id = l.id
+  WHERE l.user_id = sqlc.arg(user_id) AND l.deleted_at IS NULL
+    AND (sqlc.narg(search)::text IS NULL OR l.name ILIKE '%' || sqlc.narg(search) || '%')
+    AND (sqlc.narg(folder_id)::integer IS NULL OR l.folder_id = sqlc.narg(folder_id))
+    AND (sqlc.narg(tag_id)::integer IS NULL OR EXISTS (
+      SELECT 1 FROM link_tags lt WHERE lt.link_id = l.id AND lt.tag_id = sqlc.narg(tag_id)
+    ))
+    AND (sqlc.narg(created_from)::timestamptz IS NULL OR l.created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::timestamptz IS NULL OR l.created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(domain)::text IS NULL OR
+      lower(substring(l.original_url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)')) IN (sqlc.narg(domain), 'www.' || sqlc.narg(domain)))
+    AND (sqlc.narg(has_scans)::boolean IS NULL OR (lr.link_id IS NOT NULL) = sqlc.narg(has_scans))
+), keyed AS (
+  SELECT
+    s.*,
+    (CASE sqlc.arg(sort_by)::text
+      WHEN 'created_at' THEN s.created_at
+      WHEN 'last_scan' THEN COALESCE(s.last_transition_at, 'epoch')
+      ELSE 'epoch'
+    END)::timestamptz AS sort_time,
+    (CASE sqlc.arg(sort_by)::text WHEN 'transitions' THEN s.transitions_count ELSE 0 END)::bigint AS sort_num,
+    (CASE sqlc.arg(sort_by)::text WHEN 'name' THEN s.name ELSE '' END)::text AS sort_text
+  FROM summary s
+)
+SELECT
+  k.id, k.original_url, k.name, k.created_at, k.folder_id, k.transitions_count, k.unique_visitors, k.last_transition_at,
+  k.broken, k.tag_ids, k.sort_time, k.sort_num, k.sort_text
+FROM keyed k
+WHERE sqlc.narg(cursor_id)::integer IS NULL
+  OR (sqlc.arg(sort_order)::text = 'asc' AND (k.sort_time, k.sort_num, k.sort_text, k.id) >
+    (sqlc.narg(cursor_time)::timestamptz, sqlc.narg(cursor_num)::bigint, sqlc.narg(cursor_text)::text, sqlc.narg(cursor_id)))
+  OR (sqlc.arg(sort_order)::text <> 'asc' AND (k.sort_time, k.sort_num, k.sort_text, k.id) <
+    (sqlc.narg(cursor_time), sqlc.narg(cursor_num), sqlc.narg(cursor_text), sqlc.narg(cursor_id)))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_order) = 'asc' THEN k.sort_time END ASC,
+  CASE WHEN sqlc.arg(sort_order) = 'asc' THEN k.sort_num END ASC,
+  CASE WHEN sqlc.arg(sort_order) = 'asc' THEN k.sort_text END ASC,
+  CASE WHEN sqlc.arg(sort_order) = 'asc' THEN k.id END ASC,
+  k.sort_time DESC, k.sort_num DESC, k.sort_text DESC, k.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetLinksForExport :many
+SELECT
+    l.name,
+    l.original_url,
+    l.hash,
+    d.host AS domain_host,
+    qc.color,
+    qc.background,
+    qc.smoothing,
+    l.interstitial,
+    l.redirect_mode,
+    f.filename AS file_name,
+    COUNT(t.id) AS transitions_count,
+    l.created_at
 FROM links l
+JOIN qr_codes qc ON qc.link_id = l.id
+LEFT JOIN domains d ON d.id = l.domain_id
+LEFT JOIN link_files f ON f.id = l.file_id
 LEFT JOIN transitions t ON t.link_id = l.id
-WHERE l.user_id = $1 AND l.name ILIKE '%' || $2 || '%'
-GROUP BY l.id
-ORDER BY l.created_at DESC;
+WHERE l.user_id = $1 AND l.deleted_at IS NULL
+GROUP BY l.id, d.host, qc.color, qc.background, qc.smoothing, f.filename
+ORDER BY l.created_at, l.id;
 
 -- name: CreateQRCode :one
 INSERT INTO qr_codes (
@@ -68,21 +156,33 @@ SELECT
     l.name,
     qc.color,
     qc.background,
-    qc.smoothing
+    qc.smoothing,
+    l.domain_id,
+    d.host AS domain_host,
+    l.revision_id,
+    l.file_id,
+    l.folder_id,
+    l.cloned_from,
+    l.interstitial,
+    l.redirect_mode
 FROM
     links l
 JOIN
     qr_codes qc ON l.id = qc.link_id
+LEFT JOIN
+    domains d ON d.id = l.domain_id
 WHERE
-    l.id = $1 AND l.user_id = $2;
+    l.id = $1 AND l.user_id = $2 AND l.deleted_at IS NULL;
 
--- name: UpdateLinkURL :execrows
+-- name: UpdateLinkURL :one
 UPDATE links
 SET
     original_url = $1,
+    domain_id = $2,
     updated_at = now()
 WHERE
-    id = $2 AND user_id = $3;
+    id = $3 AND user_id = $4 AND deleted_at IS NULL
+RETURNING hash;
 
 -- name: UpdateQRCodeParams :exec
 UPDATE qr_codes
@@ -93,32 +193,50 @@ SET
 WHERE
     link_id = $4;
 
--- name: CreateTransition :exec
+-- name: LockExistingLinks :many
+-- Returns those of the links that still exist and keeps them from being
+-- purged until the transaction ends, so their scans can be written.
+SELECT id FROM links
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+FOR KEY SHARE;
+
+-- name: CreateTransitions :copyfrom
 INSERT INTO transitions (
   link_id,
   country,
+  region,
   city,
   referer,
   user_agent,
   browser,
-  os
+  os,
+  revision_id,
+  via_preview,
+  schedule_id,
+  visitor_hash,
+  geo_source,
+  created_at
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 );
 
 -- name: GetTransitionsByLinkID :many
 SELECT
   t.id,
   t.country,
+  t.region,
   t.city,
   t.referer,
   t.user_agent,
   t.browser,
   t.os,
-  t.created_at
+  t.created_at,
+  t.revision_id,
+  t.schedule_id,
+  t.geo_source
 FROM transitions t
 JOIN links l ON l.id = t.link_id
-WHERE t.link_id = $1 AND l.user_id = $2
+WHERE t.link_id = $1 AND l.user_id = $2 AND l.deleted_at IS NULL
 ORDER BY t.created_at DESC;
 
 -- name: DeleteTransitionsByLinkID :exec
@@ -128,4 +246,43 @@ DELETE FROM transitions WHERE link_id = $1;
 DELETE FROM qr_codes WHERE link_id = $1;
 
 -- name: DeleteLink :execrows
-DELETE FROM links WHERE id = $1 AND user_id = $2;
\ No newline at end of file
+DELETE FROM links WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;
+
+-- name: SetLinkInterstitial :execrows
+UPDATE links SET interstitial = $1
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL;
+
+-- name: SetLinkRedirectMode :execrows
+UPDATE links SET redirect_mode = $1
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL;
+
+-- name: TrashLink :one
+UPDATE links SET deleted_at = now()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+RETURNING hash;
+
+-- name: RestoreLink :one
+UPDATE links SET deleted_at = NULL
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+RETURNING hash;
+
+-- name: GetTrashedLinks :many
+SELECT id, original_url, name, hash, created_at, deleted_at FROM links
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: GetTrashedLink :one
+SELECT id, user_id, hash, file_id FROM links
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;
+
+-- name: GetExpiredTrashedLinks :many
+SELECT id, user_id, hash, file_id FROM links
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2;
+
+-- name: NextLinkHashSequence :one
+SELECT nextval('link_hash_seq')::bigint;
+
+-- name: NotifyLinkChanged :exec
+SELECT pg_notify('link_changes', sqlc.arg(hash)::text);
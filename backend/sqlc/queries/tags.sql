@@ -0,0 +1,60 @@
+-- name: CreateTag :one
+INSERT INTO tags (
+  user_id,
+  name
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: GetTagByID :one
+SELECT * FROM tags
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetTagByName :one
+SELECT * FROM tags
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetTagsByUser :many
+SELECT
+    t.id,
+    t.name,
+    t.created_at,
+    COUNT(l.id) AS links_count
+FROM tags t
+LEFT JOIN link_tags lt ON lt.tag_id = t.id
+LEFT JOIN links l ON l.id = lt.link_id AND l.deleted_at IS NULL
+WHERE t.user_id = $1
+GROUP BY t.id
+ORDER BY t.name;
+
+-- name: GetTagsByLinkID :many
+SELECT t.id, t.name, t.created_at
+FROM tags t
+JOIN link_tags lt ON lt.tag_id = t.id
+WHERE lt.link_id = $1
+ORDER BY t.name;
+
+-- name: CountTagsByIDs :one
+SELECT COUNT(*) FROM tags
+WHERE user_id = sqlc.arg(user_id) AND id = ANY(sqlc.arg(ids)::integer[]);
+
+-- name: RenameTag :one
+UPDATE tags
+SET name = $1
+WHERE id = $2 AND user_id = $3
+RETURNING *;
+
+-- name: AddLinkTags :exec
+INSERT INTO link_tags (link_id, tag_id)
+SELECT sqlc.arg(link_id), unnest(sqlc.arg(tag_ids)::integer[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteLinkTagsByLinkID :exec
+DELETE FROM link_tags WHERE link_id = $1;
+
+-- name: DeleteLinkTagsByTagID :exec
+DELETE FROM link_tags WHERE tag_id = $1;
+
+-- name: DeleteTag :execrows
+DELETE FROM tags WHERE id = $1 AND user_id = $2;
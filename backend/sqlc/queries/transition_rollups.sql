@@ -0,0 +1,207 @@
+-- name: GetRollupWatermark :one
+SELECT last_transition_id FROM rollup_state
+WHERE name = $1
+FOR UPDATE;
+
+-- name: SetRollupWatermark :exec
+UPDATE rollup_state SET last_transition_id = $2, updated_at = now()
+WHERE name = $1;
+
+-- name: GetRollupSettling :one
+-- The pending id is settled once every transaction that was running when it
+-- was recorded has ended.
+SELECT
+    settled_transition_id,
+    pending_transition_id,
+    (pending_xmax IS NOT NULL AND pending_xmax <= pg_snapshot_xmin(pg_current_snapshot()))::boolean AS pending_settled
+FROM rollup_state
+WHERE name = $1
+FOR UPDATE;
+
+-- name: GetLastTransitionID :one
+-- The last id handed out to a transition, whether committed or not.
+SELECT COALESCE(pg_sequence_last_value(pg_get_serial_sequence('transitions', 'id')::regclass), 0)::bigint AS last_transition_id;
+
+-- name: SetRollupPending :exec
+-- Must run in a later statement than GetLastTransitionID, so the snapshot
+-- includes every transaction that could hold an id up to the pending one.
+UPDATE rollup_state SET
+    settled_transition_id = sqlc.arg(settled_transition_id),
+    pending_transition_id = sqlc.arg(pending_transition_id),
+    pending_xmax = pg_snapshot_xmax(pg_current_snapshot()),
+    updated_at = now()
+WHERE name = sqlc.arg(name);
+
+-- name: GetRollupBackfilled :one
+SELECT backfilled_at IS NOT NULL AS backfilled FROM rollup_state
+WHERE name = $1;
+
+-- name: SetRollupBackfilled :exec
+UPDATE rollup_state SET backfilled_at = now(), updated_at = now()
+WHERE name = $1;
+
+-- name: GetRollupUpperBound :one
+-- Last id of the next batch after after_id, at most settled_id.
+SELECT COALESCE(MAX(b.id), sqlc.arg(after_id))::bigint AS up_to_id
+FROM (
+    SELECT t.id
+    FROM transitions t
+    WHERE t.id > sqlc.arg(after_id) AND t.id <= sqlc.arg(settled_id)
+    ORDER BY t.id
+    LIMIT sqlc.arg(batch_size)
+) b;
+
+-- name: RollupTransitionsHourly :exec
+-- Adds the transitions with ids in (after_id, up_to_id] to their hourly
+-- buckets. A visitor is new to a bucket unless a transition rolled up
+-- earlier (id <= after_id) has the same visitor hash and dimension value
+-- there; that is an index lookup per visitor, not a recount of the bucket.
+-- Every id range must be rolled up exactly once.
+WITH batch AS (
+    SELECT t.link_id, date_trunc('hour', t.created_at, 'UTC') AS bucket, d.dimension, COALESCE(d.value, '') AS value, t.visitor_hash
+    FROM transitions t
+    CROSS JOIN LATERAL (VALUES
+        ('total', ''),
+        ('country', t.country),
+        ('city', t.city),
+        ('browser', t.browser),
+        ('os', t.os),
+        ('referer', t.referer)
+    ) AS d(dimension, value)
+    WHERE t.id > sqlc.arg(after_id) AND t.id <= sqlc.arg(up_to_id)
+), seen AS (
+    SELECT b.link_id, b.bucket, b.dimension, b.value, b.visitor_hash, EXISTS (
+        SELECT 1 FROM transitions p
+        WHERE p.link_id = b.link_id AND p.visitor_hash = b.visitor_hash
+            AND p.created_at >= b.bucket AND p.created_at < b.bucket + interval '1 hour'
+            AND p.id <= sqlc.arg(after_id)
+            AND CASE b.dimension
+                WHEN 'country' THEN COALESCE(p.country, '')
+                WHEN 'city' THEN COALESCE(p.city, '')
+                WHEN 'browser' THEN COALESCE(p.browser, '')
+                WHEN 'os' THEN COALESCE(p.os, '')
+                WHEN 'referer' THEN COALESCE(p.referer, '')
+                ELSE ''
+            END = b.value
+    ) AS counted
+    FROM batch b
+)
+INSERT INTO transition_rollups_hourly (link_id, bucket, dimension, value, transitions_count, unique_visitors)
+SELECT s.link_id, s.bucket, s.dimension, s.value, COUNT(*), COUNT(DISTINCT s.visitor_hash) FILTER (WHERE NOT s.counted)
+FROM seen s
+GROUP BY s.link_id, s.bucket, s.dimension, s.value
+ON CONFLICT (link_id, dimension, bucket, value) DO UPDATE
+SET transitions_count = transition_rollups_hourly.transitions_count + EXCLUDED.transitions_count,
+    unique_visitors = transition_rollups_hourly.unique_visitors + EXCLUDED.unique_visitors;
+
+-- name: RollupTransitionsDaily :exec
+-- Same as RollupTransitionsHourly for UTC days.
+WITH batch AS (
+    SELECT t.link_id, date_trunc('day', t.created_at, 'UTC') AS bucket, d.dimension, COALESCE(d.value, '') AS value, t.visitor_hash
+    FROM transitions t
+    CROSS JOIN LATERAL (VALUES
+        ('total', ''),
+        ('country', t.country),
+        ('city', t.city),
+        ('browser', t.browser),
+        ('os', t.os),
+        ('referer', t.referer)
+    ) AS d(dimension, value)
+    WHERE t.id > sqlc.arg(after_id) AND t.id <= sqlc.arg(up_to_id)
+), seen AS (
+    SELECT b.link_id, b.bucket, b.dimension, b.value, b.visitor_hash, EXISTS (
+        SELECT 1 FROM transitions p
+        WHERE p.link_id = b.link_id AND p.visitor_hash = b.visitor_hash
+            AND p.created_at >= b.bucket AND p.created_at < b.bucket + interval '24 hours'
+            AND p.id <= sqlc.arg(after_id)
+            AND CASE b.dimension
+                WHEN 'country' THEN COALESCE(p.country, '')
+                WHEN 'city' THEN COALESCE(p.city, '')
+                WHEN 'browser' THEN COALESCE(p.browser, '')
+                WHEN 'os' THEN COALESCE(p.os, '')
+                WHEN 'referer' THEN COALESCE(p.referer, '')
+                ELSE ''
+            END = b.value
+    ) AS counted
+    FROM batch b
+)
+INSERT INTO transition_rollups_daily (link_id, bucket, dimension, value, transitions_count, unique_visitors)
+SELECT s.link_id, s.bucket, s.dimension, s.value, COUNT(*), COUNT(DISTINCT s.visitor_hash) FILTER (WHERE NOT s.counted)
+FROM seen s
+GROUP BY s.link_id, s.bucket, s.dimension, s.value
+ON CONFLICT (link_id, dimension, bucket, value) DO UPDATE
+SET transitions_count = transition_rollups_daily.transitions_count + EXCLUDED.transitions_count,
+    unique_visitors = transition_rollups_daily.unique_visitors + EXCLUDED.unique_visitors;
+
+-- name: RecountTransitionsHourly :exec
+-- Recounts every hour that has transitions with ids in (after_id, up_to_id]
+-- from all its transitions up to watermark, the id the aggregator has
+-- rolled up to. The result does not depend on earlier runs, so recounting
+-- the same ids twice is harmless.
+WITH dirty AS (
+    SELECT DISTINCT t.link_id, date_trunc('hour', t.created_at, 'UTC') AS bucket
+    FROM transitions t
+    WHERE t.id > sqlc.arg(after_id) AND t.id <= sqlc.arg(up_to_id)
+)
+INSERT INTO transition_rollups_hourly (link_id, bucket, dimension, value, transitions_count, unique_visitors)
+SELECT dr.link_id, dr.bucket, d.dimension, COALESCE(d.value, ''), COUNT(*), COUNT(DISTINCT t.visitor_hash)
+FROM dirty dr
+JOIN transitions t ON t.link_id = dr.link_id
+    AND t.created_at >= dr.bucket AND t.created_at < dr.bucket + interval '1 hour'
+    AND t.id <= sqlc.arg(watermark)
+CROSS JOIN LATERAL (VALUES
+    ('total', ''),
+    ('country', t.country),
+    ('city', t.city),
+    ('browser', t.browser),
+    ('os', t.os),
+    ('referer', t.referer)
+) AS d(dimension, value)
+GROUP BY dr.link_id, dr.bucket, d.dimension, COALESCE(d.value, '')
+ON CONFLICT (link_id, dimension, bucket, value) DO UPDATE
+SET transitions_count = EXCLUDED.transitions_count, unique_visitors = EXCLUDED.unique_visitors;
+
+-- name: RecountTransitionsDaily :exec
+-- Same as RecountTransitionsHourly for UTC days.
+WITH dirty AS (
+    SELECT DISTINCT t.link_id, date_trunc('day', t.created_at, 'UTC') AS bucket
+    FROM transitions t
+    WHERE t.id > sqlc.arg(after_id) AND t.id <= sqlc.arg(up_to_id)
+)
+INSERT INTO transition_rollups_daily (link_id, bucket, dimension, value, transitions_count, unique_visitors)
+SELECT dr.link_id, dr.bucket, d.dimension, COALESCE(d.value, ''), COUNT(*), COUNT(DISTINCT t.visitor_hash)
+FROM dirty dr
+JOIN transitions t ON t.link_id = dr.link_id
+    AND t.created_at >= dr.bucket AND t.created_at < dr.bucket + interval '24 hours'
+    AND t.id <= sqlc.arg(watermark)
+CROSS JOIN LATERAL (VALUES
+    ('total', ''),
+    ('country', t.country),
+    ('city', t.city),
+    ('browser', t.browser),
+    ('os', t.os),
+    ('referer', t.referer)
+) AS d(dimension, value)
+GROUP BY dr.link_id, dr.bucket, d.dimension, COALESCE(d.value, '')
+ON CONFLICT (link_id, dimension, bucket, value) DO UPDATE
+SET transitions_count = EXCLUDED.transitions_count, unique_visitors = EXCLUDED.unique_visitors;
+
+-- name: RollupLastTransitions :exec
+-- Moves each link's last transition time forward to its newest transition
+-- with an id in (after_id, up_to_id]. Safe to repeat.
+INSERT INTO transition_rollups_latest (link_id, last_transition_at)
+SELECT t.link_id, MAX(t.created_at)
+FROM transitions t
+WHERE t.id > sqlc.arg(after_id) AND t.id <= sqlc.arg(up_to_id)
+GROUP BY t.link_id
+ON CONFLICT (link_id) DO UPDATE
+SET last_transition_at = GREATEST(transition_rollups_latest.last_transition_at, EXCLUDED.last_transition_at);
+
+-- name: DeleteHourlyRollupsByLinkID :exec
+DELETE FROM transition_rollups_hourly WHERE link_id = $1;
+
+-- name: DeleteDailyRollupsByLinkID :exec
+DELETE FROM transition_rollups_daily WHERE link_id = $1;
+
+-- name: DeleteLatestRollupByLinkID :exec
+DELETE FROM transition_rollups_latest WHERE link_id = $1;
@@ -0,0 +1,118 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  user_id,
+  url,
+  secret,
+  events
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetWebhooksByUser :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetWebhookByID :one
+SELECT * FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET
+    url = COALESCE(sqlc.narg(url)::varchar, url),
+    events = COALESCE(sqlc.narg(events)::varchar[], events),
+    active = COALESCE(sqlc.narg(active)::boolean, active)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING *;
+
+-- name: DeleteWebhook :one
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2
+RETURNING id;
+
+-- name: EnqueueWebhookEvent :exec
+-- Adds the event to the outbox of every active webhook of the user that
+-- subscribes to its type.
+INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+SELECT w.id, sqlc.arg(event_id)::varchar, sqlc.arg(event_type)::varchar, sqlc.arg(payload)::jsonb
+FROM webhooks w
+WHERE w.user_id = sqlc.arg(user_id) AND w.active AND sqlc.arg(event_type) = ANY(w.events);
+
+-- name: EnqueueWebhookEvents :exec
+-- EnqueueWebhookEvent for a batch of events of one type, given as parallel
+-- arrays with one entry per event.
+INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+SELECT w.id, e.event_id, sqlc.arg(event_type)::varchar, e.payload::jsonb
+FROM unnest(sqlc.arg(user_ids)::bigint[], sqlc.arg(event_ids)::varchar[], sqlc.arg(payloads)::text[]) AS e(user_id, event_id, payload)
+JOIN webhooks w ON w.user_id = e.user_id
+WHERE w.active AND sqlc.arg(event_type) = ANY(w.events);
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  webhook_id,
+  event_id,
+  event_type,
+  payload,
+  next_attempt_at
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ClaimWebhookDeliveries :many
+-- Takes up to batch_size due deliveries and hides them from other
+-- dispatchers until lease_until, counting the attempt up front so a crash
+-- mid-send still uses it up. Paused webhooks only get test events.
+WITH due AS (
+    SELECT d.id
+    FROM webhook_deliveries d
+    JOIN webhooks w ON w.id = d.webhook_id
+    WHERE d.status = 'pending' AND d.next_attempt_at <= now()
+        AND (w.active OR d.event_type = 'webhook.test')
+    ORDER BY d.next_attempt_at, d.id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE OF d SKIP LOCKED
+)
+UPDATE webhook_deliveries d
+SET next_attempt_at = sqlc.arg(lease_until), attempts = d.attempts + 1
+FROM due, webhooks w
+WHERE d.id = due.id AND w.id = d.webhook_id
+RETURNING d.id, d.event_id, d.event_type, d.payload, d.attempts, w.url, w.secret;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded', last_status_code = $2, last_error = NULL, delivered_at = now()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- Records a failed attempt. status stays 'pending' to retry at
+-- next_attempt_at, or becomes 'dead' once the attempts are used up.
+UPDATE webhook_deliveries
+SET status = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5
+WHERE id = $1;
+
+-- name: GetWebhookDeliveries :many
+-- Newest first; before_id pages back through older deliveries.
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: RetryWebhookDelivery :one
+-- Queues a delivery again with a fresh set of attempts.
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = now()
+WHERE id = $1 AND webhook_id = $2 AND status <> 'pending'
+RETURNING *;
+
+-- name: DeleteWebhookDeliveriesByWebhookID :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = $1;
+
+-- name: DeleteFinishedWebhookDeliveries :execrows
+-- Drops delivered and dead deliveries created before created_before.
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending' AND created_at < sqlc.arg(created_before);